@@ -3,8 +3,11 @@ package app
 import (
 	"crypto/x509"
 	"os"
+	"strconv"
+	"time"
 
 	fmt "github.com/jhunt/go-ansi"
+	"github.com/SomeBlackMagic/vault-cli-manager/rc"
 	"github.com/SomeBlackMagic/vault-cli-manager/vault"
 )
 
@@ -28,12 +31,31 @@ func Connect(auth bool) *vault.Vault {
 		return false
 	}
 
+	//rc.Apply has already put the target's retry settings in the environment
+	retry, err := TargetRetryPolicy(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "@R{!! %s}\n", err)
+		os.Exit(1)
+	}
+
+	workers := os.Getenv("SAFE_WORKERS")
+	if workers == "" {
+		workers = os.Getenv("SAFE_TARGET_WORKERS")
+	}
+	concurrency, err := Concurrency(workers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "@R{!! %s}\n", err)
+		os.Exit(1)
+	}
+
 	conf := vault.VaultConfig{
-		URL:        getVaultURL(),
-		Token:      os.Getenv("VAULT_TOKEN"),
-		Namespace:  os.Getenv("VAULT_NAMESPACE"),
-		SkipVerify: shouldSkipVerify(),
-		CACerts:    caCertPool,
+		URL:         getVaultURL(),
+		Token:       os.Getenv("VAULT_TOKEN"),
+		Namespace:   os.Getenv("VAULT_NAMESPACE"),
+		SkipVerify:  shouldSkipVerify(),
+		CACerts:     caCertPool,
+		Retry:       retry,
+		Concurrency: concurrency,
 	}
 
 	if auth && conf.Token == "" {
@@ -55,6 +77,123 @@ func Connect(auth bool) *vault.Vault {
 	return v
 }
 
+// ConnectTo connects to the named target from the given configuration, rather
+// than the one that rc.Apply put in the environment, so that more than one
+// Vault can be used at a time.
+func ConnectTo(c rc.Config, target string) (*vault.Vault, error) {
+	t, err := c.Vault(target)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("Target '%s' not found in ~/.saferc", target)
+	}
+	if t.Token == "" {
+		return nil, fmt.Errorf("You are not authenticated to target '%s'; try `safe -T %s auth`", target, target)
+	}
+
+	var caCertPool *x509.CertPool
+	if len(t.CACerts) > 0 {
+		caCertPool = x509.NewCertPool()
+		for _, cert := range t.CACerts {
+			caCertPool.AppendCertsFromPEM([]byte(cert))
+		}
+	}
+
+	retry, err := TargetRetryPolicy(t.Retry)
+	if err != nil {
+		return nil, fmt.Errorf("Bad retry configuration for target '%s': %w", target, err)
+	}
+
+	workers := os.Getenv("SAFE_WORKERS")
+	if workers == "" {
+		workers = t.Workers
+	}
+	concurrency, err := Concurrency(workers)
+	if err != nil {
+		return nil, fmt.Errorf("Bad workers configuration for target '%s': %w", target, err)
+	}
+
+	return vault.NewVault(vault.VaultConfig{
+		URL:         t.URL,
+		Token:       t.Token,
+		Namespace:   t.Namespace,
+		SkipVerify:  t.SkipVerify,
+		CACerts:     caCertPool,
+		Retry:       retry,
+		Concurrency: concurrency,
+	})
+}
+
+// Concurrency reads a workers setting, as given to --workers or in ~/.saferc:
+// either how many requests to make at once when walking a tree of secrets, or
+// "auto" to let that grow and shrink with how quickly Vault answers. An empty
+// setting means one worker per CPU.
+func Concurrency(workers string) (vault.Concurrency, error) {
+	if workers == "" {
+		return vault.Concurrency{}, nil
+	}
+	if workers == "auto" {
+		return vault.Concurrency{Adaptive: true}, nil
+	}
+	n, err := strconv.Atoi(workers)
+	if err != nil || n < 1 {
+		return vault.Concurrency{}, fmt.Errorf("Workers must be a number, at least 1, or `auto' (not `%s')", workers)
+	}
+	return vault.Concurrency{Workers: n}, nil
+}
+
+// TargetRetryPolicy reads the retry settings of a target, from its retry
+// block in ~/.saferc (which may be nil), letting any of SAFE_RETRY_ATTEMPTS,
+// SAFE_RETRY_MIN_BACKOFF and SAFE_RETRY_MAX_BACKOFF exported by the user win
+// over it.
+func TargetRetryPolicy(r *rc.Retry) (vault.RetryPolicy, error) {
+	if r == nil {
+		r = &rc.Retry{}
+	}
+	attempts := ""
+	if r.MaxAttempts != 0 {
+		attempts = strconv.Itoa(r.MaxAttempts)
+	}
+	setting := func(name, fallback string) string {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		return fallback
+	}
+	return RetryPolicy(
+		setting("SAFE_RETRY_ATTEMPTS", attempts),
+		setting("SAFE_RETRY_MIN_BACKOFF", r.MinBackoff),
+		setting("SAFE_RETRY_MAX_BACKOFF", r.MaxBackoff))
+}
+
+// RetryPolicy builds a retry policy from its settings, as given in the
+// environment or ~/.saferc. Settings left empty keep their defaults; backoffs
+// are Go durations, like "500ms" or "30s".
+func RetryPolicy(attempts, minBackoff, maxBackoff string) (vault.RetryPolicy, error) {
+	var p vault.RetryPolicy
+	var err error
+	if attempts != "" {
+		p.MaxAttempts, err = strconv.Atoi(attempts)
+		if err != nil || p.MaxAttempts < 1 {
+			return p, fmt.Errorf("Retry attempts must be a number, at least 1 (not `%s')", attempts)
+		}
+	}
+	if minBackoff != "" {
+		p.MinBackoff, err = time.ParseDuration(minBackoff)
+		if err != nil || p.MinBackoff <= 0 {
+			return p, fmt.Errorf("Invalid minimum retry backoff `%s'", minBackoff)
+		}
+	}
+	if maxBackoff != "" {
+		p.MaxBackoff, err = time.ParseDuration(maxBackoff)
+		if err != nil || p.MaxBackoff <= 0 {
+			return p, fmt.Errorf("Invalid maximum retry backoff `%s'", maxBackoff)
+		}
+	}
+	return p, nil
+}
+
 // getVaultURL exits program with error if no Vault targeted
 func getVaultURL() string {
 	ret := os.Getenv("VAULT_ADDR")
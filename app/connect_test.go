@@ -0,0 +1,110 @@
+package app
+
+import (
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/rc"
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Connect", func() {
+	Describe("ConnectTo", func() {
+		c := rc.Config{
+			Version: 1,
+			Vaults: map[string]*rc.Vault{
+				"prod":    {URL: "https://prod.example.com", Token: "s.prod"},
+				"staging": {URL: "https://staging.example.com"},
+			},
+		}
+
+		It("connects to a named target", func() {
+			v, err := ConnectTo(c, "prod")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(v).ToNot(BeNil())
+		})
+
+		It("refuses unknown targets", func() {
+			_, err := ConnectTo(c, "dev")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("refuses targets without a token", func() {
+			_, err := ConnectTo(c, "staging")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not authenticated"))
+		})
+	})
+
+	Describe("RetryPolicy", func() {
+		It("leaves unset values to the defaults", func() {
+			p, err := RetryPolicy("", "", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(Equal(vault.RetryPolicy{}))
+		})
+
+		It("parses attempts and backoffs", func() {
+			p, err := RetryPolicy("6", "100ms", "1m")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(Equal(vault.RetryPolicy{MaxAttempts: 6, MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Minute}))
+		})
+
+		It("rejects nonsense", func() {
+			_, err := RetryPolicy("0", "", "")
+			Expect(err).To(HaveOccurred())
+			_, err = RetryPolicy("", "soon", "")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("TargetRetryPolicy", func() {
+		AfterEach(func() {
+			os.Unsetenv("SAFE_RETRY_ATTEMPTS")
+			os.Unsetenv("SAFE_RETRY_MAX_BACKOFF")
+		})
+
+		It("lets settings exported by the user win over those of the target", func() {
+			os.Setenv("SAFE_RETRY_ATTEMPTS", "2")
+			p, err := TargetRetryPolicy(&rc.Retry{MaxAttempts: 6, MinBackoff: "100ms"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(Equal(vault.RetryPolicy{MaxAttempts: 2, MinBackoff: 100 * time.Millisecond}))
+		})
+
+		It("reads the environment alone for targets without retry settings", func() {
+			os.Setenv("SAFE_RETRY_MAX_BACKOFF", "1m")
+			p, err := TargetRetryPolicy(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(Equal(vault.RetryPolicy{MaxBackoff: time.Minute}))
+		})
+	})
+
+	Describe("Concurrency", func() {
+		It("defaults to one worker per CPU", func() {
+			c, err := Concurrency("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c).To(Equal(vault.Concurrency{}))
+		})
+
+		It("takes a fixed number of workers", func() {
+			c, err := Concurrency("16")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c).To(Equal(vault.Concurrency{Workers: 16}))
+		})
+
+		It("takes auto for adaptive concurrency", func() {
+			c, err := Concurrency("auto")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c.Adaptive).To(BeTrue())
+		})
+
+		It("rejects nonsense", func() {
+			_, err := Concurrency("0")
+			Expect(err).To(HaveOccurred())
+			_, err = Concurrency("lots")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,301 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	"github.com/SomeBlackMagic/vault-cli-manager/vaultsync"
+)
+
+const (
+	TextOutput = "text"
+	JSONOutput = "json"
+	YAMLOutput = "yaml"
+)
+
+// Output prints what read-only commands find, either as text for people, or
+// as a JSON or YAML document for scripts, in the shapes below.  These shapes
+// are documented by 'safe help output', and only ever gain fields.
+type Output struct {
+	Format string
+	W      io.Writer
+}
+
+// NewOutput returns an Output to standard output in the given format (json,
+// yaml or text), which is text if none is given.
+func NewOutput(format string) (*Output, error) {
+	switch format {
+	case "":
+		format = TextOutput
+	case TextOutput, JSONOutput, YAMLOutput:
+	default:
+		return nil, fmt.Errorf("Unsupported output format `%s' (expected json, yaml or text)", format)
+	}
+	return &Output{Format: format, W: os.Stdout}, nil
+}
+
+// Structured says whether the output is a JSON or YAML document.
+func (o *Output) Structured() bool {
+	return o.Format == JSONOutput || o.Format == YAMLOutput
+}
+
+// Print prints v as a JSON or YAML document, or calls text to print it as
+// text instead.
+func (o *Output) Print(v interface{}, text func() error) error {
+	switch o.Format {
+	case JSONOutput:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(o.W, "%s\n", string(b))
+		return err
+
+	case YAMLOutput:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(o.W, "---\n%s", string(b))
+		return err
+
+	default:
+		return text()
+	}
+}
+
+// OutputSecret is a secret, as printed by get, versions, tree and paths.
+type OutputSecret struct {
+	Path string `json:"path" yaml:"path"`
+	//Data holds the keys of the secret and their values, when those were
+	// asked for, and Keys just the names of its keys, when only those were
+	Data     map[string]string `json:"data,omitempty" yaml:"data,omitempty"`
+	Keys     []string          `json:"keys,omitempty" yaml:"keys,omitempty"`
+	Versions []OutputVersion   `json:"versions,omitempty" yaml:"versions,omitempty"`
+	Summary  *OutputSummary    `json:"summary,omitempty" yaml:"summary,omitempty"`
+}
+
+// OutputVersion is a version of a secret.  KV v1 secrets have a single
+// version, 1, with no times.
+type OutputVersion struct {
+	Version uint `json:"version" yaml:"version"`
+	//State is alive, deleted or destroyed
+	State     string     `json:"state" yaml:"state"`
+	CreatedAt *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" yaml:"deleted_at,omitempty"`
+}
+
+// OutputSummary sums up a secret, for ls -l, tree --long and paths --long.
+type OutputSummary struct {
+	Versions int `json:"versions" yaml:"versions"`
+	//State is the state of the latest version
+	State     string     `json:"state" yaml:"state"`
+	CreatedAt *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" yaml:"updated_at,omitempty"`
+	//Keys is how many keys the latest version has, and Size how many bytes
+	// their values come to
+	Keys int `json:"keys" yaml:"keys"`
+	Size int `json:"size" yaml:"size"`
+}
+
+// OutputListing is what ls prints for each path it lists.
+type OutputListing struct {
+	Path    string              `json:"path" yaml:"path"`
+	Entries []OutputListedEntry `json:"entries" yaml:"entries"`
+}
+
+// OutputListedEntry is something listed by ls.
+type OutputListedEntry struct {
+	Name string `json:"name" yaml:"name"`
+	//Type is secret, or folder for anything with secrets beneath it
+	Type    string         `json:"type" yaml:"type"`
+	Summary *OutputSummary `json:"summary,omitempty" yaml:"summary,omitempty"`
+}
+
+// OutputMatch is a key that find found.
+type OutputMatch struct {
+	Path    string `json:"path" yaml:"path"`
+	Key     string `json:"key" yaml:"key"`
+	Version uint   `json:"version,omitempty" yaml:"version,omitempty"`
+	Value   string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// OutputRefs is what refs prints: the references made by the secrets beneath
+// a path, and those made to them.
+type OutputRefs struct {
+	Path     string      `json:"path" yaml:"path"`
+	Outbound []OutputRef `json:"outbound" yaml:"outbound"`
+	Inbound  []OutputRef `json:"inbound" yaml:"inbound"`
+}
+
+// OutputRef is a reference from one key to another.
+type OutputRef struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+// OutputUsage is what du prints for each folder: how much is stored in it,
+// and in the folders beneath it.
+type OutputUsage struct {
+	Path    string `json:"path" yaml:"path"`
+	Secrets int    `json:"secrets" yaml:"secrets"`
+	//Keys and Bytes are left out by du --quick, which does not read values
+	Keys      *int `json:"keys,omitempty" yaml:"keys,omitempty"`
+	Bytes     *int `json:"bytes,omitempty" yaml:"bytes,omitempty"`
+	Versions  int  `json:"versions" yaml:"versions"`
+	Deleted   int  `json:"deleted" yaml:"deleted"`
+	Destroyed int  `json:"destroyed" yaml:"destroyed"`
+}
+
+// OutputCertificate is what x509 show prints for each path: the details of
+// the certificate there, or why it could not be read as one.
+type OutputCertificate struct {
+	Path  string `json:"path" yaml:"path"`
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+
+	Subject        string     `json:"subject,omitempty" yaml:"subject,omitempty"`
+	Issuer         string     `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	Intermediaries []string   `json:"intermediaries,omitempty" yaml:"intermediaries,omitempty"`
+	SelfSigned     bool       `json:"self_signed,omitempty" yaml:"self_signed,omitempty"`
+	CA             bool       `json:"ca,omitempty" yaml:"ca,omitempty"`
+	NotBefore      *time.Time `json:"not_before,omitempty" yaml:"not_before,omitempty"`
+	NotAfter       *time.Time `json:"not_after,omitempty" yaml:"not_after,omitempty"`
+	//KeyUsage names the purposes the certificate is for, as x509 show lists
+	// them (digital-signature, server-auth and so on)
+	KeyUsage           []string `json:"key_usage,omitempty" yaml:"key_usage,omitempty"`
+	SignatureAlgorithm string   `json:"signature_algorithm,omitempty" yaml:"signature_algorithm,omitempty"`
+	DNSNames           []string `json:"dns_names,omitempty" yaml:"dns_names,omitempty"`
+	EmailAddresses     []string `json:"email_addresses,omitempty" yaml:"email_addresses,omitempty"`
+	IPAddresses        []string `json:"ip_addresses,omitempty" yaml:"ip_addresses,omitempty"`
+	Serial             string   `json:"serial,omitempty" yaml:"serial,omitempty"`
+}
+
+// OutputChange is a secret that diff found to differ between its two sides.
+type OutputChange struct {
+	Path string `json:"path" yaml:"path"`
+	//Change is added (only in B), removed (only in A) or changed
+	Change string            `json:"change" yaml:"change"`
+	Keys   []OutputKeyChange `json:"keys,omitempty" yaml:"keys,omitempty"`
+}
+
+// OutputKeyChange is a key that differs between the sides of a diff, or a
+// field of its value, when that is a JSON object or array on both sides.
+type OutputKeyChange struct {
+	Key    string `json:"key" yaml:"key"`
+	Field  string `json:"field,omitempty" yaml:"field,omitempty"`
+	Change string `json:"change" yaml:"change"`
+	//Old and New are its values on either side, given by diff --show-values
+	Old interface{} `json:"old,omitempty" yaml:"old,omitempty"`
+	New interface{} `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// OutputTarget is a Vault target, as printed by targets and target.
+type OutputTarget struct {
+	Name      string `json:"name" yaml:"name"`
+	URL       string `json:"url" yaml:"url"`
+	Verify    bool   `json:"verify" yaml:"verify"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Strongbox bool   `json:"strongbox" yaml:"strongbox"`
+}
+
+// OutputEnv is what env prints: the environment variables for the current
+// target, leaving out those that are not set (other than VAULT_ADDR).
+type OutputEnv struct {
+	Addr      string `json:"VAULT_ADDR" yaml:"VAULT_ADDR"`
+	Token     string `json:"VAULT_TOKEN,omitempty" yaml:"VAULT_TOKEN,omitempty"`
+	Skip      string `json:"VAULT_SKIP_VERIFY,omitempty" yaml:"VAULT_SKIP_VERIFY,omitempty"`
+	Namespace string `json:"VAULT_NAMESPACE,omitempty" yaml:"VAULT_NAMESPACE,omitempty"`
+}
+
+// NewOutputUsage describes the usage of a folder, with its keys and bytes if
+// values were read.
+func NewOutputUsage(u vault.FolderUsage, values bool) OutputUsage {
+	o := OutputUsage{Path: u.Path, Secrets: u.Secrets, Versions: u.Versions, Deleted: u.Deleted, Destroyed: u.Destroyed}
+	if values {
+		o.Keys, o.Bytes = &u.Keys, &u.Bytes
+	}
+	return o
+}
+
+// NewOutputSecret describes a secret found by walking a tree, with the keys
+// of its latest version if keys is set.
+func NewOutputSecret(e vault.SecretEntry, keys bool) OutputSecret {
+	s := OutputSecret{Path: e.Path}
+	for _, v := range e.Versions {
+		s.Versions = append(s.Versions, NewOutputVersion(v.Number, v.State, v.CreatedAt, v.DeletedAt))
+	}
+	if keys && len(e.Versions) > 0 && e.Versions[len(e.Versions)-1].Data != nil {
+		s.Keys = e.Versions[len(e.Versions)-1].Data.Keys()
+	}
+	return s
+}
+
+// NewOutputVersion describes a version of a secret, in the given state (one
+// of vault.SecretStateAlive, SecretStateDeleted or SecretStateDestroyed).
+func NewOutputVersion(number uint, state uint, created, deleted time.Time) OutputVersion {
+	v := OutputVersion{Version: number, State: stateName(state)}
+	if !created.IsZero() {
+		v.CreatedAt = &created
+	}
+	if !deleted.IsZero() {
+		v.DeletedAt = &deleted
+	}
+	return v
+}
+
+// NewOutputSummary describes the summary of a secret.
+func NewOutputSummary(s vault.SecretSummary) *OutputSummary {
+	o := &OutputSummary{Versions: s.Versions, State: stateName(s.State), Keys: s.Keys, Size: s.Size}
+	if !s.CreatedAt.IsZero() {
+		o.CreatedAt = &s.CreatedAt
+	}
+	if !s.UpdatedAt.IsZero() {
+		o.UpdatedAt = &s.UpdatedAt
+	}
+	return o
+}
+
+// NewOutputChange describes a secret that differs, with the values of its keys
+// if values is set.
+func NewOutputChange(c vaultsync.Change, values bool) OutputChange {
+	o := OutputChange{Path: c.Path, Change: changeName(c.Type)}
+	for _, kc := range c.KeyChanges() {
+		k := OutputKeyChange{Key: kc.Key, Field: kc.Field, Change: changeName(kc.Type)}
+		if values {
+			k.Old, k.New = kc.OldValue, kc.NewValue
+		}
+		o.Keys = append(o.Keys, k)
+	}
+	return o
+}
+
+// changeName names a vaultsync.ChangeType, as diff describes it.
+func changeName(t vaultsync.ChangeType) string {
+	switch t {
+	case vaultsync.ChangeAdd:
+		return "added"
+	case vaultsync.ChangeDelete:
+		return "removed"
+	case vaultsync.ChangeModify:
+		return "changed"
+	}
+	return "same"
+}
+
+// stateName names one of vault.SecretStateAlive, SecretStateDeleted or
+// SecretStateDestroyed.
+func stateName(state uint) string {
+	switch state {
+	case vault.SecretStateDeleted:
+		return "deleted"
+	case vault.SecretStateDestroyed:
+		return "destroyed"
+	}
+	return "alive"
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	"github.com/SomeBlackMagic/vault-cli-manager/vaultsync"
+)
+
+var _ = Describe("Output", func() {
+	It("defaults to text, and rejects formats it does not know", func() {
+		out, err := NewOutput("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out.Format).To(Equal(TextOutput))
+		Expect(out.Structured()).To(BeFalse())
+
+		_, err = NewOutput("xml")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("prints documents, or calls back for text", func() {
+		var buf bytes.Buffer
+		secrets := []OutputSecret{{Path: "secret/a", Keys: []string{"k"}}}
+
+		Expect((&Output{Format: JSONOutput, W: &buf}).Print(secrets, nil)).To(Succeed())
+		Expect(buf.String()).To(MatchJSON(`[{"path": "secret/a", "keys": ["k"]}]`))
+
+		buf.Reset()
+		Expect((&Output{Format: YAMLOutput, W: &buf}).Print(secrets, nil)).To(Succeed())
+		Expect(buf.String()).To(Equal("---\n- path: secret/a\n  keys:\n  - k\n"))
+
+		called := false
+		Expect((&Output{Format: TextOutput, W: &buf}).Print(secrets, func() error {
+			called = true
+			return nil
+		})).To(Succeed())
+		Expect(called).To(BeTrue())
+	})
+
+	It("describes secrets found by walking trees", func() {
+		created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		data := vault.NewSecret()
+		data.Set("b", "2", false)
+		data.Set("a", "1", false)
+
+		s := NewOutputSecret(vault.SecretEntry{
+			Path: "secret/a",
+			Versions: []vault.SecretVersion{
+				{Number: 1, State: vault.SecretStateDestroyed, CreatedAt: created},
+				{Number: 2, State: vault.SecretStateDeleted, CreatedAt: created, DeletedAt: created},
+				{Number: 3, State: vault.SecretStateAlive, CreatedAt: created, Data: data},
+			},
+		}, true)
+
+		Expect(s.Path).To(Equal("secret/a"))
+		Expect(s.Keys).To(Equal([]string{"a", "b"}))
+		Expect(s.Data).To(BeNil())
+		Expect(s.Versions).To(Equal([]OutputVersion{
+			{Version: 1, State: "destroyed", CreatedAt: &created},
+			{Version: 2, State: "deleted", CreatedAt: &created, DeletedAt: &created},
+			{Version: 3, State: "alive", CreatedAt: &created},
+		}))
+
+		Expect(NewOutputSecret(vault.SecretEntry{Path: "secret/b"}, true).Keys).To(BeNil())
+	})
+
+	It("describes the keys that differ, and their values only if asked", func() {
+		c := vaultsync.Change{
+			Type:       vaultsync.ChangeModify,
+			Path:       "secret/app",
+			LocalData:  map[string]interface{}{"password": "new", "config": map[string]interface{}{"host": "b"}},
+			RemoteData: map[string]interface{}{"password": "old", "config": map[string]interface{}{"host": "a"}, "gone": "x"},
+		}
+		Expect(NewOutputChange(c, false)).To(Equal(OutputChange{Path: "secret/app", Change: "changed", Keys: []OutputKeyChange{
+			{Key: "config", Field: "host", Change: "changed"},
+			{Key: "gone", Change: "removed"},
+			{Key: "password", Change: "changed"},
+		}}))
+		Expect(NewOutputChange(c, true).Keys[2]).To(Equal(OutputKeyChange{Key: "password", Change: "changed", Old: "old", New: "new"}))
+	})
+
+	It("prints token statuses the same way as JSON and YAML", func() {
+		var buf bytes.Buffer
+		status := TokenStatus{Valid: true, Info: vaultkv.TokenInfo{
+			CreationTime: time.Unix(100, 0),
+			ExpireTime:   time.Unix(3700, 0),
+			Renewable:    true,
+			Policies:     []string{"default"},
+			TTL:          time.Hour,
+		}}
+
+		Expect((&Output{Format: JSONOutput, W: &buf}).Print(status, nil)).To(Succeed())
+		Expect(buf.String()).To(MatchJSON(`{"valid": true, "creation_time": 100, "expire_time": 3700,
+			"renewable": true, "policies": ["default"], "ttl": 3600}`))
+
+		buf.Reset()
+		Expect((&Output{Format: YAMLOutput, W: &buf}).Print(status, nil)).To(Succeed())
+		Expect(buf.String()).To(MatchYAML("valid: true\ncreation_time: 100\nexpire_time: 3700\nrenewable: true\npolicies: [default]\nttl: 3600\n"))
+	})
+})
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	"github.com/jhunt/go-ansi"
+	"github.com/mattn/go-isatty"
+)
+
+// Progress shows how far a walk of a tree of secrets has got, on a single line
+// of standard error that it keeps rewriting. It shows nothing unless standard
+// error is a terminal, so that it stays out of logs and pipelines.
+type Progress struct {
+	out     io.Writer
+	label   string
+	start   time.Time
+	shown   time.Time
+	every   time.Duration
+	written bool
+	lock    sync.Mutex
+}
+
+func NewProgress(label string) *Progress {
+	p := &Progress{label: label, start: time.Now(), every: 100 * time.Millisecond}
+	if isatty.IsTerminal(os.Stderr.Fd()) {
+		p.out = os.Stderr
+	}
+	return p
+}
+
+// Update shows the given progress, unless it was last shown only a moment ago.
+func (p *Progress) Update(tp vault.TreeProgress) {
+	if p == nil || p.out == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(p.shown) < p.every {
+		return
+	}
+	p.shown = now
+	ansi.Fprintf(p.out, "\r\033[K%s", p.Line(tp, now))
+	p.written = true
+}
+
+// Done takes the progress line off the screen.
+func (p *Progress) Done() {
+	if p == nil || p.out == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.written {
+		fmt.Fprintf(p.out, "\r\033[K")
+		p.written = false
+	}
+}
+
+// Pause takes the progress line off the screen while fn prints something, so
+// that the two don't end up on the same line. The next update puts it back.
+func (p *Progress) Pause(fn func() error) error {
+	if p == nil || p.out == nil {
+		return fn()
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.written {
+		fmt.Fprintf(p.out, "\r\033[K")
+		p.written = false
+	}
+	return fn()
+}
+
+// Line describes the given progress, as of the given time. The estimate of the
+// time left assumes secrets will keep being fetched as quickly as they have
+// been, and that no more will be discovered.
+func (p *Progress) Line(tp vault.TreeProgress, now time.Time) string {
+	line := fmt.Sprintf("@C{%s}: discovered @G{%d} secrets, fetched @G{%d}", p.label, tp.Discovered, tp.Fetched)
+
+	elapsed := now.Sub(p.start)
+	if tp.Fetched > 0 && tp.Discovered > tp.Fetched && elapsed >= time.Second {
+		perSecret := elapsed / time.Duration(tp.Fetched)
+		eta := perSecret * time.Duration(tp.Discovered-tp.Fetched)
+		line += fmt.Sprintf(" (about @Y{%s} left)", eta.Round(time.Second))
+	}
+	return line
+}
+
+// ConstructSecrets walks the tree of secrets under the given path like
+// v.ConstructSecrets, showing its progress, and stopping cleanly if
+// interrupted.
+func ConstructSecrets(v *vault.Vault, path string, opts vault.TreeOpts) (vault.Secrets, error) {
+	ctx, stop := Interruptible()
+	defer stop()
+
+	progress := NewProgress(path)
+	opts.Progress = progress.Update
+	secrets, err := v.ConstructSecretsContext(ctx, path, opts)
+	progress.Done()
+
+	if errors.Is(err, context.Canceled) {
+		return nil, fmt.Errorf("Interrupted while walking `%s'", path)
+	}
+	return secrets, err
+}
+
+// WalkSecrets walks the tree of secrets under the given path like
+// v.WalkSecrets, handing each secret to fn as soon as it is fetched, showing
+// its progress in between, and stopping cleanly if interrupted.
+func WalkSecrets(v *vault.Vault, path string, opts vault.TreeOpts, fn func(vault.SecretEntry) error) error {
+	ctx, stop := Interruptible()
+	defer stop()
+
+	progress := NewProgress(path)
+	opts.Progress = progress.Update
+	err := v.WalkSecrets(ctx, path, opts, func(e vault.SecretEntry) error {
+		return progress.Pause(func() error { return fn(e) })
+	})
+	progress.Done()
+
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("Interrupted while walking `%s'", path)
+	}
+	return err
+}
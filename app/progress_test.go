@@ -0,0 +1,53 @@
+package app
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Progress", func() {
+	p := NewProgress("secret")
+	start := p.start
+
+	It("counts discovered and fetched secrets", func() {
+		line := p.Line(vault.TreeProgress{Discovered: 10, Fetched: 3}, start.Add(500*time.Millisecond))
+		Expect(line).To(ContainSubstring("discovered @G{10} secrets, fetched @G{3}"))
+		Expect(line).ToNot(ContainSubstring("left"))
+	})
+
+	It("estimates the time left from how quickly secrets are fetched", func() {
+		line := p.Line(vault.TreeProgress{Discovered: 30, Fetched: 10}, start.Add(5*time.Second))
+		Expect(line).To(ContainSubstring("about @Y{10s} left"))
+	})
+
+	It("does not estimate once everything discovered is fetched", func() {
+		line := p.Line(vault.TreeProgress{Discovered: 10, Fetched: 10}, start.Add(5*time.Second))
+		Expect(line).ToNot(ContainSubstring("left"))
+	})
+
+	It("does nothing when not on a terminal", func() {
+		Expect(func() {
+			(&Progress{}).Update(vault.TreeProgress{Discovered: 1})
+			(&Progress{}).Done()
+		}).ToNot(Panic())
+	})
+
+	It("still prints what it is paused for when not on a terminal", func() {
+		printed := false
+		Expect((&Progress{}).Pause(func() error { printed = true; return nil })).To(Succeed())
+		Expect(printed).To(BeTrue())
+	})
+})
+
+var _ = Describe("Interruptible", func() {
+	It("is cancelled once stopped", func() {
+		ctx, stop := Interruptible()
+		Expect(ctx.Err()).ToNot(HaveOccurred())
+		stop()
+		Expect(ctx.Err()).To(HaveOccurred())
+	})
+})
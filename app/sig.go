@@ -1,13 +1,59 @@
 package app
 
 import (
+	"context"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
+	"github.com/SomeBlackMagic/vault-cli-manager/rc"
+	"github.com/jhunt/go-ansi"
 	"golang.org/x/term"
 )
 
+// interrupts holds the cancel function of the operation that an interrupt
+// should stop, rather than exiting, if there is one, or the process that
+// signals should be passed on to.
+var interrupts struct {
+	lock    sync.Mutex
+	cancel  context.CancelFunc
+	forward *os.Process
+}
+
+// Interruptible returns a context that is cancelled when safe is interrupted
+// (i.e. with Ctrl-C), so that a long-running operation can stop cleanly
+// instead of safe exiting outright. Interrupting it a second time exits as
+// usual. Call stop once the operation is over.
+func Interruptible() (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	interrupts.lock.Lock()
+	interrupts.cancel = cancel
+	interrupts.lock.Unlock()
+
+	return ctx, func() {
+		interrupts.lock.Lock()
+		interrupts.cancel = nil
+		interrupts.lock.Unlock()
+		cancel()
+	}
+}
+
+// ForwardSignals passes the signals that would otherwise make safe exit on to
+// p instead, until stop is called, so that p decides whether and how to exit.
+func ForwardSignals(p *os.Process) (stop func()) {
+	interrupts.lock.Lock()
+	interrupts.forward = p
+	interrupts.lock.Unlock()
+
+	return func() {
+		interrupts.lock.Lock()
+		interrupts.forward = nil
+		interrupts.lock.Unlock()
+	}
+}
+
 func Signals() {
 	prev, err := term.GetState(int(os.Stdin.Fd()))
 	if err != nil {
@@ -15,9 +61,29 @@ func Signals() {
 	}
 
 	s := make(chan os.Signal, 1)
-	signal.Notify(s, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
-	for range s {
+	signal.Notify(s, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGHUP)
+	for sig := range s {
+		interrupts.lock.Lock()
+		forward := interrupts.forward
+		interrupts.lock.Unlock()
+		if forward != nil {
+			_ = forward.Signal(sig)
+			continue
+		}
+
+		interrupts.lock.Lock()
+		cancel := interrupts.cancel
+		interrupts.cancel = nil
+		interrupts.lock.Unlock()
+
+		if cancel != nil && sig == syscall.SIGINT {
+			ansi.Fprintf(os.Stderr, "\n@Y{Stopping; interrupt again to quit now}\n")
+			cancel()
+			continue
+		}
+
 		term.Restore(int(os.Stdin.Fd()), prev)
+		rc.Cleanup()
 		os.Exit(1)
 	}
 }
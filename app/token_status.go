@@ -49,7 +49,17 @@ func (t TokenStatus) String() string {
 	return ansi.Sprintf(strings.Join(retArray, "\n")) + "\n"
 }
 
-func (t TokenStatus) MarshalJSON() ([]byte, error) {
+// tokenStatusDocument is what auth status prints as JSON or YAML.
+type tokenStatusDocument struct {
+	Valid        bool     `json:"valid" yaml:"valid"`
+	CreationTime int64    `json:"creation_time" yaml:"creation_time"`
+	ExpireTime   int64    `json:"expire_time" yaml:"expire_time"`
+	Renewable    bool     `json:"renewable" yaml:"renewable"`
+	Policies     []string `json:"policies" yaml:"policies"`
+	TTL          int64    `json:"ttl" yaml:"ttl"`
+}
+
+func (t TokenStatus) document() tokenStatusDocument {
 	floorZero := func(i int64) int64 {
 		if i < 0 {
 			i = 0
@@ -57,14 +67,7 @@ func (t TokenStatus) MarshalJSON() ([]byte, error) {
 		return i
 	}
 
-	outStruct := struct {
-		Valid        bool     `json:"valid"`
-		CreationTime int64    `json:"creation_time"`
-		ExpireTime   int64    `json:"expire_time"`
-		Renewable    bool     `json:"renewable"`
-		Policies     []string `json:"policies"`
-		TTL          int64    `json:"ttl"`
-	}{
+	return tokenStatusDocument{
 		Valid:        t.Valid,
 		CreationTime: floorZero(t.Info.CreationTime.Unix()),
 		ExpireTime:   floorZero(t.Info.ExpireTime.Unix()),
@@ -72,6 +75,13 @@ func (t TokenStatus) MarshalJSON() ([]byte, error) {
 		Policies:     t.Info.Policies,
 		TTL:          floorZero(int64(t.Info.TTL.Seconds())),
 	}
+}
+
+func (t TokenStatus) MarshalJSON() ([]byte, error) {
+	doc := t.document()
+	return json.Marshal(&doc)
+}
 
-	return json.Marshal(&outStruct)
+func (t TokenStatus) MarshalYAML() (interface{}, error) {
+	return t.document(), nil
 }
@@ -2,9 +2,13 @@ package app
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"time"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/rc"
 )
 
 func Duration(s string) (time.Duration, error) {
@@ -29,6 +33,21 @@ func Duration(s string) (time.Duration, error) {
 	return 0, fmt.Errorf("unrecognized time spec '%s'", s)
 }
 
+// Timestamp parses a point in time given either as an RFC 3339 timestamp (the
+// seconds may be left off), a date (YYYY-MM-DD, UTC), or a Duration relative
+// to now (i.e. '7d' ago).
+func Timestamp(s string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := Duration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp '%s' (expected RFC 3339, YYYY-MM-DD, or a duration like 7d)", s)
+}
+
 func Uniq(l []string) []string {
 	seen := make(map[string]bool)
 	u := make([]string, 0)
@@ -42,3 +61,30 @@ func Uniq(l []string) []string {
 
 	return u
 }
+
+// WriteFileAtomic writes data to a temporary file beside path, and then
+// renames it over path, so that nothing ever reads it half-written.  If safe
+// is interrupted first, the temporary file is shredded.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	rc.AddCleanup(f.Name())
+	//Once renamed, there is nothing left to shred; this just stops tracking it
+	defer rc.Shred(f.Name())
+
+	if _, err = f.Write(data); err == nil {
+		err = f.Chmod(perm)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
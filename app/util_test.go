@@ -1,6 +1,8 @@
 package app
 
 import (
+	"os"
+	"path/filepath"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -91,6 +93,39 @@ var _ = Describe("Util", func() {
 		})
 	})
 
+	Describe("Timestamp", func() {
+		now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+		It("parses RFC 3339 timestamps", func() {
+			t, err := Timestamp("2024-06-01T08:30:00+02:00", now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t.UTC()).To(Equal(time.Date(2024, 6, 1, 6, 30, 0, 0, time.UTC)))
+		})
+
+		It("parses RFC 3339 timestamps without seconds", func() {
+			t, err := Timestamp("2024-06-01T12:00Z", now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)))
+		})
+
+		It("parses dates as midnight UTC", func() {
+			t, err := Timestamp("2024-06-01", now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("parses durations as that long ago", func() {
+			t, err := Timestamp("2d", now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal(time.Date(2024, 6, 8, 12, 0, 0, 0, time.UTC)))
+		})
+
+		It("rejects anything else", func() {
+			_, err := Timestamp("yesterday", now)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("Uniq", func() {
 		It("deduplicates preserving order", func() {
 			result := Uniq([]string{"a", "b", "a", "c", "b"})
@@ -122,4 +157,40 @@ var _ = Describe("Util", func() {
 			Expect(result).To(BeEmpty())
 		})
 	})
+
+	Describe("WriteFileAtomic", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "safe-atomic-")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("replaces the file with the given permissions, leaving nothing else behind", func() {
+			path := filepath.Join(dir, "out.conf")
+			Expect(os.WriteFile(path, []byte("old"), 0644)).To(Succeed())
+
+			Expect(WriteFileAtomic(path, []byte("new"), 0600)).To(Succeed())
+			b, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(Equal("new"))
+
+			info, err := os.Stat(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			entries, err := os.ReadDir(dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+
+		It("fails when the directory does not exist", func() {
+			Expect(WriteFileAtomic(filepath.Join(dir, "nope", "out.conf"), []byte("x"), 0600)).ToNot(Succeed())
+		})
+	})
 })
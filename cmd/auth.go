@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"os"
 
 	"github.com/cloudfoundry-community/vaultkv"
@@ -33,7 +32,8 @@ Flags:
               familiar with the API, this is the part that comes after v1/auth.
               Defaults to the name of auth type (e.g. "userpass"), which is
               the default when creating auth backends with the Vault CLI.
-  -j, --json  For auth status, returns the information as a JSON object.
+  -j, --json  For auth status, returns the information as a JSON object
+              (the same as --output json).
 `,
 		Type: app.AdministrativeCommand,
 	}, func(command string, args ...string) error {
@@ -118,6 +118,13 @@ Flags:
 			token = result.ClientToken
 
 		case "status":
+			out, err := app.NewOutput(opt.Output)
+			if err != nil {
+				return err
+			}
+			if opt.Auth.JSON {
+				out.Format = app.JSONOutput
+			}
 			v := app.Connect(false)
 			tokenInfo, err := v.Client().Client.TokenInfoSelf()
 			var tokenObj app.TokenStatus
@@ -132,20 +139,10 @@ Flags:
 				tokenObj.Valid = true
 			}
 
-			var output string
-			if opt.Auth.JSON {
-				outputBytes, err := json.MarshalIndent(tokenObj, "", "  ")
-				if err != nil {
-					panic("Could not marshal json from TokenStatus object")
-				}
-
-				output = string(append(outputBytes, '\n'))
-			} else {
-				output = tokenObj.String()
-			}
-
-			fmt.Printf(output)
-			return nil
+			return out.Print(tokenObj, func() error {
+				fmt.Printf(tokenObj.String())
+				return nil
+			})
 
 		default:
 			return fmt.Errorf("Unrecognized authentication method '%s'", method)
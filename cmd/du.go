@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/app"
+	"github.com/SomeBlackMagic/vault-cli-manager/rc"
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	fmt "github.com/jhunt/go-ansi"
+)
+
+func registerDuCommands(r *app.Runner, opt *Options) {
+	r.Dispatch("du", &app.Help{
+		Summary: "Sum up the secrets in each folder beneath a path",
+		Usage:   "safe du [-d DEPTH] [-q] [-s FIELD] PATH",
+		Type:    app.NonDestructiveCommand,
+		Description: `
+Walks every secret beneath PATH, and prints, for PATH and each folder
+beneath it, how much is stored there (counting everything in the folders
+beneath it as well):
+
+    secrets     How many secrets there are, including those whose latest
+                version is deleted or destroyed.
+    keys        How many keys the latest versions of those secrets have.
+    bytes       How many bytes the values of those keys come to.
+    versions    How many versions of those secrets are still kept.
+    deleted     How many of those versions are deleted (and so could still
+                be undeleted).
+    destroyed   How many of those versions are destroyed.
+
+KV v1 secrets always have a single version, which is never deleted.
+
+The following options are recognized:
+
+  -d, --depth   Only print folders down to DEPTH folders beneath PATH.
+                Anything deeper counts towards the folder above it.
+                -d 0 prints just PATH.
+
+  -q, --quick   Only read the versions of each secret, and not its
+                values, which is much faster.  keys and bytes are not
+                printed.
+
+  -s, --sort    Sort by path (the default), or by secrets, keys, bytes,
+                versions, deleted or destroyed, largest first.
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) != 1 {
+			r.ExitWithUsage("du")
+		}
+		//Sorting nothing checks the field to sort by, before the whole tree is
+		// walked only to find it wanting
+		if err := vault.SortUsage(nil, opt.Du.Sort); err != nil {
+			return err
+		}
+		if opt.Du.Quick && (opt.Du.Sort == "keys" || opt.Du.Sort == "bytes") {
+			return fmt.Errorf("Cannot sort by %s with --quick, which does not read values", opt.Du.Sort)
+		}
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
+
+		v := app.Connect(true)
+		path := vault.Canonicalize(args[0])
+		secrets, err := app.ConstructSecrets(v, path, vault.TreeOpts{
+			FetchKeys:           !opt.Du.Quick,
+			FetchAllVersions:    true,
+			FetchLatestKeysOnly: true,
+			AllowDeletedSecrets: true,
+		})
+		if err != nil {
+			return err
+		}
+
+		usage := secrets.Usage(path, opt.Du.Depth)
+		if err = vault.SortUsage(usage, opt.Du.Sort); err != nil {
+			return err
+		}
+		doc := make([]app.OutputUsage, len(usage))
+		for i, u := range usage {
+			doc[i] = app.NewOutputUsage(u, !opt.Du.Quick)
+		}
+
+		return out.Print(doc, func() error {
+			count := func(n *int) string {
+				if n == nil {
+					return "-"
+				}
+				return strconv.Itoa(*n)
+			}
+
+			tbl := app.Table{}
+			tbl.SetHeader("path", "secrets", "keys", "bytes", "versions", "deleted", "destroyed")
+			for _, u := range doc {
+				tbl.AddRow(fmt.Sprintf("@B{%s/}", u.Path), strconv.Itoa(u.Secrets),
+					count(u.Keys), count(u.Bytes), strconv.Itoa(u.Versions), strconv.Itoa(u.Deleted), strconv.Itoa(u.Destroyed))
+			}
+			tbl.Print()
+			return nil
+		})
+	})
+}
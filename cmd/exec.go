@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sort"
+	"syscall"
+
+	"github.com/jhunt/go-cli"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/app"
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
+	"github.com/SomeBlackMagic/vault-cli-manager/rc"
+	fmt "github.com/jhunt/go-ansi"
+)
+
+// execOptions are the options of safe exec.  It parses them itself, so that
+// the options of the command it runs are left alone.
+type execOptions struct {
+	Help   bool     `cli:"-h, --help"`
+	Map    []string `cli:"-m, --map"`
+	Path   []string `cli:"-p, --path"`
+	Config string   `cli:"-c, --config"`
+}
+
+func registerExecCommands(r *app.Runner, opt *Options) {
+	r.Dispatch("exec", &app.Help{
+		Summary: "Run a command with secrets in its environment",
+		Usage:   "safe exec [-c FILE] [-m NAME=PATH:KEY ...] [-p [PREFIX=]PATH ...] -- COMMAND [ARGS ...]",
+		Type:    app.NonDestructiveCommand,
+		Description: `
+Runs COMMAND with environment variables set from secrets, on top of those
+that safe itself was run with.  The secrets are only ever handed to COMMAND
+in its environment; they are never put on its command line, or on disk.
+
+-m (--map) NAME=PATH:KEY sets the variable NAME to the value of PATH:KEY.
+
+-p (--path) PATH sets a variable for every key of every secret beneath PATH,
+named after its path below PATH and the key itself, so that secret/app/db:pass
+beneath secret/app becomes DB_PASS.  -p PREFIX=PATH puts PREFIX in front of
+each name (i.e. -p APP_=secret/app sets APP_DB_PASS).  Two keys that make for
+the same variable are an error.
+
+Both can be given more than once.  Variables set with -m take precedence over
+those from -p.
+
+Projects can declare their variables in a .safe-exec.yml file instead, which
+is used if it is in the current directory (or wherever -c says):
+
+    map:
+      DB_PASSWORD: secret/db:password
+    paths:
+      - secret/app
+      - APP_=secret/app
+
+Any -m and -p options are added to those in the file.
+
+Signals that safe receives while COMMAND runs are passed on to it, and safe
+exits with the same exit code as COMMAND.
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+
+		var eo execOptions
+		_, args, err := cli.ParseArgs(&eo, args)
+		if err != nil {
+			return err
+		}
+		if eo.Help {
+			return r.Execute("help", "exec")
+		}
+		if len(args) == 0 {
+			r.ExitWithUsage("exec")
+		}
+
+		conf, err := execConfig(eo.Config)
+		if err != nil {
+			return err
+		}
+		for _, mapping := range eo.Map {
+			if err = conf.AddMapping(mapping); err != nil {
+				return err
+			}
+		}
+		conf.Paths = append(conf.Paths, eo.Path...)
+		if err = conf.Validate(); err != nil {
+			return err
+		}
+		if len(conf.Map) == 0 && len(conf.Paths) == 0 {
+			return fmt.Errorf("No secrets to set in the environment of %s (see 'safe help exec')", args[0])
+		}
+
+		v := app.Connect(true)
+		paths, prefixes := conf.References()
+		src, err := newSecretSource(v, paths, prefixes)
+		if err != nil {
+			return err
+		}
+		env, err := formats.ExecEnv(conf, src)
+		if err != nil {
+			return err
+		}
+
+		child := exec.Command(args[0], args[1:]...)
+		child.Stdin = os.Stdin
+		child.Stdout = os.Stdout
+		child.Stderr = os.Stderr
+		child.Env = os.Environ()
+		names := make([]string, 0, len(env))
+		for name := range env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child.Env = append(child.Env, name+"="+env[name])
+		}
+
+		if err = child.Start(); err != nil {
+			return err
+		}
+		stop := app.ForwardSignals(child.Process)
+		err = child.Wait()
+		stop()
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code := exitErr.ExitCode()
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+				code = 128 + int(status.Signal())
+			}
+			rc.Cleanup()
+			os.Exit(code)
+		}
+		return err
+	})
+}
+
+// execConfig reads the given .safe-exec.yml file, or the one in the current
+// directory if none is given and there is one.
+func execConfig(file string) (formats.ExecConfig, error) {
+	if file == "" {
+		if _, err := os.Stat(".safe-exec.yml"); err != nil {
+			return formats.ExecConfig{}, nil
+		}
+		file = ".safe-exec.yml"
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return formats.ExecConfig{}, err
+	}
+	conf, err := formats.ParseExecConfig(b)
+	if err != nil {
+		return conf, fmt.Errorf("%s: %s", file, err)
+	}
+	return conf, nil
+}
@@ -15,7 +15,7 @@ import (
 func registerGenerateCommands(r *app.Runner, opt *Options) {
 	r.Dispatch("gen", &app.Help{
 		Summary: "Generate a random password",
-		Usage:   "safe gen [-l <length>] [-p] PATH:KEY [PATH:KEY ...]",
+		Usage:   "safe gen [-l <length>] [-p] [--cas VERSION] [--patch] PATH:KEY [PATH:KEY ...]",
 		Type:    app.DestructiveCommand,
 		Description: `
 LENGTH defaults to 64 characters.
@@ -25,6 +25,10 @@ The following options are recognized:
   -l, --length  Specify the length of the random string to generate
 	-p, --policy  Specify a regex character grouping for limiting characters used
 	              to generate the password (e.g --policy a-z0-9)
+  --cas VERSION Only write the password if the secret is still at VERSION,
+                or does not exist yet if VERSION is 0 (KV v2 only)
+  --patch       Only send the new key, so that other keys changed at the
+                same time are not lost (see 'safe help set')
 `,
 	}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
@@ -42,8 +46,13 @@ The following options are recognized:
 			args = args[1:]
 		}
 
-		v := app.Connect(true)
+		wopts, err := writeOpts(opt.Gen.CAS)
+		if err != nil {
+			return err
+		}
 
+		//Every PATH:KEY is made sense of before anything is written
+		var targets [][2]string
 		for len(args) > 0 {
 			var path, key string
 			if vault.PathHasKey(args[0]) {
@@ -61,6 +70,16 @@ The following options are recognized:
 				}
 				args = args[2:]
 			}
+			targets = append(targets, [2]string{path, key})
+		}
+		if wopts.CAS != nil && len(targets) > 1 {
+			return fmt.Errorf("--cas can only be given when generating a single password")
+		}
+
+		v := app.Connect(true)
+
+		for _, target := range targets {
+			path, key := target[0], target[1]
 			s, err := v.Read(path)
 			if err != nil && !vault.IsNotFound(err) {
 				return err
@@ -72,14 +91,12 @@ The following options are recognized:
 				}
 				continue
 			}
-			err = s.Password(key, length, opt.Gen.Policy, opt.SkipIfExists)
+			err = writeChanges(v, path, s, func(s *vault.Secret) error {
+				return s.Password(key, length, opt.Gen.Policy, opt.SkipIfExists)
+			}, opt.Gen.Patch, wopts)
 			if err != nil {
 				return err
 			}
-
-			if err = v.Write(path, s); err != nil {
-				return err
-			}
 		}
 		return nil
 	})
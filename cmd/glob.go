@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/prompt"
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	fmt "github.com/jhunt/go-ansi"
+)
+
+// expandGlobs replaces each of paths that has a glob in it with the paths that
+// match it (and folders too, if folders is set), and says whether any did.  A
+// glob that matches nothing is an error.
+func expandGlobs(v *vault.Vault, paths []string, folders bool) ([]string, bool, error) {
+	expanded := make([]string, 0, len(paths))
+	globbed := false
+	for _, path := range paths {
+		if !vault.HasGlob(path) {
+			expanded = append(expanded, path)
+			continue
+		}
+
+		matches, err := v.Glob(path, folders)
+		if err != nil {
+			return nil, true, err
+		}
+		if len(matches) == 0 {
+			return nil, true, fmt.Errorf("No secrets match `%s'", path)
+		}
+		expanded = append(expanded, matches...)
+		globbed = true
+	}
+	return expanded, globbed, nil
+}
+
+// confirmGlobs lists the paths that globs expanded to, and asks whether to
+// go ahead and do what cmd does to them.
+func confirmGlobs(cmd string, paths []string) bool {
+	fmt.Fprintf(os.Stderr, "This will @R{%s}:\n", cmd)
+	for _, path := range paths {
+		fmt.Fprintf(os.Stderr, "  @C{%s}\n", path)
+	}
+	y := prompt.Normal("Are you sure? @Y{(y/n)} ")
+	y = strings.TrimSpace(y)
+	return y == "y" || y == "yes"
+}
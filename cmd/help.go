@@ -36,6 +36,32 @@ func registerHelpCommands(r *app.Runner, opt *Options, version string) {
 		fmt.Printf(`@G{[SCRIPTING]}
   @B{SAFE_TARGET}    The vault alias which requests are sent to.
 
+@G{[CONCURRENCY]}
+  @B{SAFE_WORKERS}   How many requests to make at once when walking a tree of
+                 secrets (one per CPU by default), or 'auto' to make more
+                 while Vault keeps answering as quickly, and fewer when it
+                 rate limits (429) or is overloaded (503). The same as the
+                 global --workers option, which overrides the workers key
+                 of the target in ~/.saferc. Give the global --timing option
+                 to see how long each command took, and how many requests
+                 it made.
+
+@G{[RETRIES]}
+  @B{SAFE_RETRY_ATTEMPTS}
+                 How many times to send a request that fails transiently
+                 (a 429 or 5xx, or a dropped connection) before giving up.
+                 Defaults to 4; 1 turns retries off.
+  @B{SAFE_RETRY_MIN_BACKOFF}
+                 The longest wait before the first retry (default 250ms).
+                 Each retry after that waits up to twice as long as the last.
+  @B{SAFE_RETRY_MAX_BACKOFF}
+                 The longest wait between retries (default 10s).
+
+  Only reads, and writes protected by a check-and-set version, are retried.
+  These can also be set per target in ~/.saferc, as the max_attempts,
+  min_backoff and max_backoff keys of its retry map. Retries are logged when
+  DEBUG is set.
+
 @G{[PROXYING]}
   @B{HTTP_PROXY}     The proxy to use for HTTP requests.
   @B{HTTPS_PROXY}    The proxy to use for HTTPS requests.
@@ -75,4 +101,95 @@ func registerHelpCommands(r *app.Runner, opt *Options, version string) {
 `)
 		return nil
 	})
+
+	r.HelpTopic("output", `
+The global --output option makes get, ls, tree, paths, versions, find, meta,
+refs, du, diff, x509 show, verify-export, targets, target, env and auth status
+print what they find as a single JSON (--output json) or YAML (--output yaml)
+document, rather than as text.  Fields may be added to these documents in
+later versions of safe, but none are taken away or changed.
+
+get, tree, paths and versions print a list of secrets:
+
+    path        The path of the secret (with ^N, if get was asked for a
+                version of it).
+    data        Its keys and their values, for get.
+    keys        Just the names of its keys, for get --keys, and tree and
+                paths --keys.
+    versions    Its versions, for versions, tree and paths, oldest first:
+
+      version     The number of the version (always 1 for KV v1 secrets).
+      state       alive, deleted or destroyed.
+      created_at  When it was written (KV v2 only).
+      deleted_at  When it was deleted, if it was (KV v2 only, and not
+                  given by versions).
+
+ls prints a list of the paths it listed:
+
+    path        The path that was listed.
+    entries     What is there, each with a name, and a type: secret, or
+                folder for anything with secrets beneath it.
+
+With -l (--long), the secrets printed by tree and paths, and those listed
+by ls, each have a summary as well: versions, state, created_at, updated_at,
+keys and size, as described by 'safe help tree'.
+
+find prints a list of the keys that matched, each with the path and key
+of the secret, the version that matched (with --versions), and the value
+(with --show).
+
+meta get and meta mount get print the settings described by 'safe help
+meta' and 'safe help meta mount', as their --json option does.
+
+refs prints the path it was given, and the references made by the secrets
+beneath it (outbound) and to them (inbound), each with the path:key that
+makes the reference (from) and the path:key it refers to (to).
+
+du prints a list of folders, each with its path, and the secrets, keys,
+bytes, versions, deleted and destroyed counts described by 'safe help du'
+(without keys and bytes, for du --quick).
+
+diff prints a list of the secrets that differ, each with its path (or A => B,
+when comparing two secrets A and B), how it changed (added, removed or
+changed), and its keys that differ, each with its key, the field of its value
+that differs (for values that are JSON objects or arrays on both sides), how
+it changed, and, with --show-values, its old and new values.
+
+x509 show prints a list of the paths it was given, each with the subject,
+issuer, intermediaries, self_signed, ca, not_before, not_after, key_usage,
+signature_algorithm, dns_names, email_addresses, ip_addresses and serial of
+the certificate there, or an error saying why it is not one.
+
+verify-export prints its report, as its --json option does: signature_valid,
+signature_error, key_trusted, checksum_valid, and the missing, extra and
+changed paths of the backup (and of the live Vault, with --live).
+
+targets prints a list of the targets there are, and target the current one,
+each with its name, url, verify, namespace (if it has one) and strongbox.
+env prints the VAULT_* environment variables that are set, and auth status
+whether the token is valid, with its creation_time, expire_time, renewable,
+policies and ttl.  Their --json options are the same as --output json.
+`)
+
+	r.HelpTopic("globs", `
+The paths given to get, versions, paths, meta get, x509 show, delete and
+undelete can be globs, which are matched against what is in Vault and
+replaced by the paths of every secret that matches:
+
+    *         Anything within one segment of a path (secret/*/db).
+    **        Any number of segments (secret/certs/**).
+    {a,b}     Either a or b (secret/{app,web}/db).
+
+A backslash in front of any of these makes it match itself instead.  Globs
+only apply to the path of a secret, not its key or version, which are kept
+as they are: 'safe get secret/*/db:password' gets the password of each of
+them.  The mount (i.e. secret/) can't be a glob.
+
+When they work on whole trees (paths, get -R, meta get -R and delete -R),
+globs match folders as well as secrets.  A glob that matches nothing is an
+error.  delete and undelete list the secrets that globs match, and ask
+before going ahead, unless -f (--force) is given.
+
+Quote globs, so that your shell leaves them alone.
+`)
 }
@@ -0,0 +1,355 @@
+package cmd
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/app"
+	"github.com/SomeBlackMagic/vault-cli-manager/rc"
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	fmt "github.com/jhunt/go-ansi"
+)
+
+func registerMetaCommands(r *app.Runner, opt *Options) {
+	r.Dispatch("meta", &app.Help{
+		Summary: "View and change the KV v2 metadata of secrets and mounts",
+		Usage:   "safe meta <get|set|mount> ...",
+		Type:    app.AdministrativeCommand,
+		Description: `
+KV v2 keeps a few settings alongside each secret, in its metadata:
+
+    max_versions            How many versions of the secret to keep (0 for
+                            the mount's setting).
+    cas_required            Whether every write must be a check-and-set
+                            (i.e. safe set --cas).
+    delete_version_after    How long to keep each version before deleting
+                            it, as a duration like 768h (0s for never).
+    custom_metadata.NAME    Free-form NAME=VALUE pairs, for your own use.
+
+Subcommands:
+
+    get     Show the metadata of secrets.
+    set     Change the metadata of secrets.
+    mount   Show or change the settings of a whole mount.
+
+`,
+	}, func(command string, args ...string) error {
+		r.ExitWithUsage("meta")
+		return nil
+	})
+
+	r.Dispatch("meta get", &app.Help{
+		Summary: "Show the KV v2 metadata of secrets",
+		Usage:   "safe meta get [-R] [--json] PATH [PATH ...]",
+		Type:    app.NonDestructiveCommand,
+		Description: `
+Shows the metadata settings of each secret given, or of every secret beneath
+each PATH if -R is given.  See 'safe help meta' for what they mean.
+
+With --json, a single PATH (without -R) is printed as a JSON object of its
+settings; otherwise, the settings of each secret are printed as an object,
+keyed by its path.
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) == 0 {
+			r.ExitWithUsage("meta get")
+		}
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
+		if opt.Meta.Get.JSON {
+			out.Format = app.JSONOutput
+		}
+		v := app.Connect(true)
+
+		args, globbed, err := expandGlobs(v, args, opt.Meta.Get.Recurse)
+		if err != nil {
+			return err
+		}
+		paths, err := metaPaths(v, args, opt.Meta.Get.Recurse)
+		if err != nil {
+			return err
+		}
+		all := map[string]vault.SecretMetadata{}
+		for _, path := range paths {
+			meta, err := v.Metadata(path)
+			if err != nil {
+				return err
+			}
+			all[path] = meta
+		}
+
+		var doc interface{} = all
+		if len(args) == 1 && !globbed && !opt.Meta.Get.Recurse {
+			doc = all[paths[0]]
+		}
+		return out.Print(doc, func() error {
+			for _, path := range paths {
+				meta := all[path]
+				settings := [][2]string{
+					{"max_versions", strconv.FormatUint(uint64(meta.MaxVersions), 10)},
+					{"cas_required", strconv.FormatBool(meta.CASRequired)},
+					{"delete_version_after", meta.DeleteVersionAfter},
+				}
+				keys := make([]string, 0, len(meta.CustomMetadata))
+				for key := range meta.CustomMetadata {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					settings = append(settings, [2]string{"custom_metadata." + key, meta.CustomMetadata[key]})
+				}
+
+				fmt.Printf("@G{%s}\n", path)
+				printSettings(settings)
+			}
+			return nil
+		})
+	})
+
+	r.Dispatch("meta set", &app.Help{
+		Summary: "Change the KV v2 metadata of secrets",
+		Usage:   "safe meta set [-R] [-f] PATH NAME=VALUE [NAME=VALUE ...]",
+		Type:    app.DestructiveCommand,
+		Description: `
+Changes the given metadata settings of the secret at PATH, or of every secret
+beneath it if -R is given, leaving its other settings alone.  See 'safe help
+meta' for the settings there are.  Setting custom_metadata.NAME to nothing
+removes it.
+
+For example:
+
+    safe meta set secret/db max_versions=5 custom_metadata.owner=dba
+
+Metadata can be set for a secret that does not exist yet, so that it
+applies from its first version on.
+
+-R asks for confirmation before changing anything, unless -f is given.
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) < 2 {
+			r.ExitWithUsage("meta set")
+		}
+		path, fields, err := parseSettings(args)
+		if err != nil {
+			return err
+		}
+		if err = applySettings(&vault.SecretMetadata{}, fields); err != nil {
+			return err
+		}
+
+		v := app.Connect(true)
+		paths, err := metaPaths(v, []string{path}, opt.Meta.Set.Recurse)
+		if err != nil {
+			return err
+		}
+		if opt.Meta.Set.Recurse && !opt.Meta.Set.Force && !recursively("set metadata of", path) {
+			return nil
+		}
+
+		for _, path := range paths {
+			meta, err := v.Metadata(path)
+			if err != nil && !vault.IsSecretNotFound(err) {
+				return err
+			}
+			if err = applySettings(&meta, fields); err != nil {
+				return err
+			}
+			if err = v.SetMetadata(path, meta); err != nil {
+				return err
+			}
+		}
+		if opt.Meta.Set.Recurse {
+			fmt.Fprintf(os.Stderr, "@G{Changed the metadata of %d secrets}\n", len(paths))
+		}
+		return nil
+	})
+
+	r.Dispatch("meta mount", &app.Help{
+		Summary: "View and change the settings of a mount",
+		Usage:   "safe meta mount <get|set> MOUNT ...",
+		Type:    app.AdministrativeCommand,
+		Description: `
+Each mount has settings of its own, which are changed by tuning it:
+
+    description             What the mount is for.
+    default_lease_ttl       How long leases last by default, as a duration
+    max_lease_ttl           like 768h, and how long they can last at most
+                            (0 for the system default).
+    listing_visibility      Whether the mount is listed in the UI when not
+                            logged in (unauth), or not (hidden).
+
+KV v2 mounts also have defaults for the metadata of their secrets, which
+apply when a secret does not have settings of its own:
+
+    max_versions            How many versions of each secret to keep.
+    cas_required            Whether every write must be a check-and-set.
+    delete_version_after    How long to keep each version before deleting
+                            it (0s for never).
+
+Subcommands:
+
+    get     Show the settings of a mount.
+    set     Change the settings of a mount.
+
+`,
+	}, func(command string, args ...string) error {
+		r.ExitWithUsage("meta mount")
+		return nil
+	})
+
+	r.Dispatch("meta mount get", &app.Help{
+		Summary: "Show the settings of a mount",
+		Usage:   "safe meta mount get [--json] MOUNT",
+		Type:    app.NonDestructiveCommand,
+		Description: `
+Shows the settings of the mount containing MOUNT (which can be any path in
+it).  See 'safe help meta mount' for what they mean.  With --json, they are
+printed as a JSON object, with the tuning of the mount under "tune", lease
+TTLs in seconds, and the KV v2 settings (if any) under "config".
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) != 1 {
+			r.ExitWithUsage("meta mount get")
+		}
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
+		if opt.Meta.Mount.Get.JSON {
+			out.Format = app.JSONOutput
+		}
+		v := app.Connect(true)
+
+		s, err := v.MountSettings(args[0])
+		if err != nil {
+			return err
+		}
+
+		ttl := func(seconds uint) string {
+			return (time.Duration(seconds) * time.Second).String()
+		}
+		settings := [][2]string{
+			{"description", s.Tuning.Description},
+			{"default_lease_ttl", ttl(s.Tuning.DefaultLeaseTTL)},
+			{"max_lease_ttl", ttl(s.Tuning.MaxLeaseTTL)},
+			{"listing_visibility", s.Tuning.ListingVisibility},
+		}
+		if s.Config != nil {
+			settings = append(settings,
+				[2]string{"max_versions", strconv.FormatUint(uint64(s.Config.MaxVersions), 10)},
+				[2]string{"cas_required", strconv.FormatBool(s.Config.CASRequired)},
+				[2]string{"delete_version_after", s.Config.DeleteVersionAfter},
+			)
+		}
+		return out.Print(s, func() error {
+			printSettings(settings)
+			return nil
+		})
+	})
+
+	r.Dispatch("meta mount set", &app.Help{
+		Summary: "Change the settings of a mount",
+		Usage:   "safe meta mount set MOUNT NAME=VALUE [NAME=VALUE ...]",
+		Type:    app.AdministrativeCommand,
+		Description: `
+Changes the given settings of the mount containing MOUNT (which can be any
+path in it), leaving its other settings alone.  See 'safe help meta mount'
+for the settings there are.
+
+For example:
+
+    safe meta mount set secret max_versions=20 description="App secrets"
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) < 2 {
+			r.ExitWithUsage("meta mount set")
+		}
+		mount, fields, err := parseSettings(args)
+		if err != nil {
+			return err
+		}
+
+		v := app.Connect(true)
+		s, err := v.MountSettings(mount)
+		if err != nil {
+			return err
+		}
+		if err = applySettings(&s, fields); err != nil {
+			return err
+		}
+		return v.SetMountSettings(mount, s)
+	})
+}
+
+// metaPaths returns the paths of the secrets whose metadata safe meta works
+// on: the paths given, or every secret beneath them, if recurse is set.
+func metaPaths(v *vault.Vault, args []string, recurse bool) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		if !recurse {
+			path, _, _ := vault.ParsePath(arg)
+			paths = append(paths, path)
+			continue
+		}
+
+		secrets, err := app.ConstructSecrets(v, arg, vault.TreeOpts{AllowDeletedSecrets: true})
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets {
+			paths = append(paths, secret.Path)
+		}
+	}
+	return paths, nil
+}
+
+// parseSettings splits the arguments of a meta set command into the path
+// that is first, and the NAME=VALUE settings after it.
+func parseSettings(args []string) (string, [][2]string, error) {
+	var fields [][2]string
+	for _, arg := range args[1:] {
+		name, value, found := strings.Cut(arg, "=")
+		if !found || name == "" {
+			return "", nil, fmt.Errorf("Expected a NAME=VALUE setting, not `%s'", arg)
+		}
+		fields = append(fields, [2]string{name, value})
+	}
+	return args[0], fields, nil
+}
+
+// settable is anything with settings that safe meta can change by name.
+type settable interface {
+	SetField(name, value string) error
+}
+
+// applySettings sets each of the settings given.
+func applySettings(to settable, fields [][2]string) error {
+	for _, field := range fields {
+		if err := to.SetField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printSettings prints the names and values of settings, lined up.
+func printSettings(settings [][2]string) {
+	wide := 0
+	for _, setting := range settings {
+		if len(setting[0]) > wide {
+			wide = len(setting[0])
+		}
+	}
+	for _, setting := range settings {
+		fmt.Printf("  @C{%s}%s  %s\n", setting[0], strings.Repeat(" ", wide-len(setting[0])), setting[1])
+	}
+}
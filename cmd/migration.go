@@ -1,15 +1,18 @@
 package cmd
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"io"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	fmt "github.com/jhunt/go-ansi"
 	"github.com/SomeBlackMagic/vault-cli-manager/app"
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
 	"github.com/SomeBlackMagic/vault-cli-manager/prompt"
 	"github.com/SomeBlackMagic/vault-cli-manager/rc"
 	"github.com/SomeBlackMagic/vault-cli-manager/vault"
@@ -22,11 +25,20 @@ type exportFormat struct {
 	//map from path string to map from version number to version info
 	Data               map[string]exportSecret `json:"data"`
 	RequiresVersioning map[string]bool         `json:"requires_versioning"`
+	//map from mount to its KV v2 configuration, if it differs from the defaults
+	Mounts map[string]vault.MountConfig `json:"mounts,omitempty"`
+	//when the export was started
+	ExportedAt *time.Time `json:"exported_at,omitempty"`
+	//set on incremental exports, which only hold the secrets changed after it
+	Since *time.Time `json:"since,omitempty"`
+	//paths of secrets removed since Since, for incremental exports
+	Tombstones []string `json:"tombstones,omitempty"`
 }
 
 type exportSecret struct {
-	FirstVersion uint            `json:"first,omitempty"`
-	Versions     []exportVersion `json:"versions"`
+	FirstVersion uint                  `json:"first,omitempty"`
+	Versions     []exportVersion       `json:"versions"`
+	Metadata     *vault.SecretMetadata `json:"metadata,omitempty"`
 }
 
 type exportVersion struct {
@@ -35,6 +47,189 @@ type exportVersion struct {
 	Value     map[string]string `json:"value,omitempty"`
 }
 
+// splitTarget separates a TARGET:path argument into the name of a target from
+// ~/.saferc and the path within it. An argument that does not start with the
+// name of a known target is a path in the current target, given as "". A name
+// given as @TARGET:path is explicit, and always taken to be a target.
+func splitTarget(c rc.Config, arg string) (string, string, bool) {
+	if i := strings.Index(arg, ":"); i > 1 && arg[0] == '@' {
+		return arg[1:i], arg[i+1:], true
+	}
+	if i := strings.Index(arg, ":"); i > 0 && !strings.Contains(arg[:i], "/") {
+		if _, found := c.Vaults[arg[:i]]; found {
+			return arg[:i], arg[i+1:], false
+		}
+	}
+	return "", arg, false
+}
+
+// connectPair connects to the Vaults that a copy or move reads from and writes
+// to, either of which may be named in its argument, and returns the paths
+// within them.
+func connectPair(c rc.Config, from, to string) (*vault.Vault, string, *vault.Vault, string, error) {
+	srcTarget, srcPath, srcExplicit := splitTarget(c, from)
+	dstTarget, dstPath, dstExplicit := splitTarget(c, to)
+
+	var current *vault.Vault
+	connect := func(target string) (*vault.Vault, error) {
+		if target == "" {
+			if current == nil {
+				current = app.Connect(true)
+			}
+			return current, nil
+		}
+		return app.ConnectTo(c, target)
+	}
+
+	//secret:key could be a key of the secret at secret, or the path key in a
+	// target named secret, so a target named after a mount must be explicit
+	// (which can only be checked if the token is allowed to list mounts)
+	for _, side := range []struct {
+		arg, target string
+		explicit    bool
+	}{{from, srcTarget, srcExplicit}, {to, dstTarget, dstExplicit}} {
+		if side.target == "" || side.explicit || os.Getenv("VAULT_ADDR") == "" {
+			continue
+		}
+		v, _ := connect("")
+		if mounted, _ := v.MountExists(side.target); mounted {
+			return nil, "", nil, "", fmt.Errorf("`%s' could be in target `%s', or in the mount `%s' of the current target; "+
+				"write @%s for the former, or /%s for the latter", side.arg, side.target, side.target, side.arg, side.arg)
+		}
+	}
+
+	src, err := connect(srcTarget)
+	if err != nil {
+		return nil, "", nil, "", err
+	}
+	dst := src
+	if dstTarget != srcTarget {
+		dst, err = connect(dstTarget)
+		if err != nil {
+			return nil, "", nil, "", err
+		}
+	}
+	return src, srcPath, dst, dstPath, nil
+}
+
+// resumable leaves the checkpoint of an operation that failed in place, and
+// says how to carry on from it.
+func resumable(checkpoint *vault.Checkpoint, operation, file string, err error) error {
+	if checkpoint != nil {
+		checkpoint.Close()
+		fmt.Fprintf(os.Stderr, "@Y{Progress was saved; run the %s again with} @C{--resume %s} @Y{to carry on}\n", operation, file)
+	}
+	return err
+}
+
+// exportedVersions reads a json export (of either format version) into the
+// form used by integrity manifests
+func exportedVersions(b []byte) (map[string][]formats.SecretVersion, error) {
+	ret := map[string][]formats.SecretVersion{}
+
+	var v2 []exportFormat
+	if err := json.Unmarshal(b, &v2); err == nil {
+		if len(v2) != 1 || v2[0].ExportVersion != 2 {
+			return nil, fmt.Errorf("Improperly formatted export file")
+		}
+		for path, secret := range v2[0].Data {
+			versions := []formats.SecretVersion{}
+			for _, version := range secret.Versions {
+				versions = append(versions, formats.SecretVersion{
+					Deleted:   version.Deleted,
+					Destroyed: version.Destroyed,
+					Data:      version.Value,
+				})
+			}
+			ret[path] = versions
+		}
+		return ret, nil
+	}
+
+	var v1 map[string]map[string]string
+	if err := json.Unmarshal(b, &v1); err != nil {
+		return nil, fmt.Errorf("Could not interpret export file: %w", err)
+	}
+	for path, data := range v1 {
+		ret[path] = []formats.SecretVersion{{Data: data}}
+	}
+	return ret, nil
+}
+
+// exportTimestamp returns when the versioned export in the given file was
+// taken, and the paths it holds, as the starting point of an incremental export
+func exportTimestamp(file string) (time.Time, []string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	var export []exportFormat
+	if err := json.Unmarshal(b, &export); err != nil || len(export) != 1 || export[0].ExportVersion != 2 {
+		return time.Time{}, nil, fmt.Errorf("`%s' is not an export in the versioned format; use --since instead", file)
+	}
+	if export[0].ExportedAt == nil {
+		return time.Time{}, nil, fmt.Errorf("`%s' does not record when it was exported; use --since instead", file)
+	}
+
+	paths := make([]string, 0, len(export[0].Data))
+	for path := range export[0].Data {
+		paths = append(paths, path)
+	}
+	return *export[0].ExportedAt, paths, nil
+}
+
+// underAnyRoot returns true if path is one of the given (canonicalized) roots,
+// or beneath one of them
+func underAnyRoot(path string, roots []string) bool {
+	path = vault.Canonicalize(path)
+	for _, root := range roots {
+		if path == root || strings.HasPrefix(path, strings.TrimSuffix(root, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// liveVersions puts a secret read from Vault into the form used by integrity
+// manifests, as it would have come out of an export of the given version
+// (taken without --deleted, so deleted versions count as destroyed)
+func liveVersions(s vault.SecretEntry, exportVersion uint) []formats.SecretVersion {
+	versions := []formats.SecretVersion{}
+	for _, version := range s.Versions {
+		data := map[string]string{}
+		for _, key := range version.Data.Keys() {
+			data[key] = version.Data.Get(key)
+		}
+		thisVersion := formats.SecretVersion{Data: data}
+		if exportVersion == 2 {
+			thisVersion.Destroyed = version.State != vault.SecretStateAlive
+		}
+		versions = append(versions, thisVersion)
+	}
+	return versions
+}
+
+// readKeyMaterial returns the contents of file if given, or else of the
+// secret at path (from defaultKey, unless path names a key)
+func readKeyMaterial(v *vault.Vault, file, path, defaultKey string) ([]byte, error) {
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	if !vault.PathHasKey(path) {
+		path = vault.EncodePath(path, defaultKey, 0)
+	}
+	s, err := v.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	value, err := s.SingleValue()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
 func registerMigrationCommands(r *app.Runner, opt *Options) {
 	r.Dispatch("delete", &app.Help{
 		Summary: "Remove one or more path from the Vault",
@@ -45,6 +240,10 @@ func registerMigrationCommands(r *app.Runner, opt *Options) {
 being marked as deleted. For KV v1 backends, this would do nothing.
 -a (--all) will delete (or destroy) all versions of the secret instead
 of just the specified (or latest if unspecified) version.
+
+PATHs can be globs (see 'safe help globs').  The secrets that match (and
+the folders, with -R) are listed, and must be confirmed unless -f (--force)
+is given.
 `}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
 
@@ -58,12 +257,20 @@ of just the specified (or latest if unspecified) version.
 			verb = "destroy"
 		}
 
+		args, globbed, err := expandGlobs(v, args, opt.Delete.Recurse)
+		if err != nil {
+			return err
+		}
+		if globbed && !opt.Delete.Force && !confirmGlobs(verb, args) {
+			return nil
+		}
+
 		for _, path := range args {
 			_, key, version := vault.ParsePath(path)
 
 			//Ignore -r if path has a version or key because that seems like a mistake
 			if opt.Delete.Recurse && (key == "" || version > 0) {
-				if !opt.Delete.Force && !recursively(verb, path) {
+				if !opt.Delete.Force && !globbed && !recursively(verb, path) {
 					continue /* skip this command, process the next */
 				}
 				if err := v.DeleteTree(path, vault.DeleteOpts{
@@ -86,7 +293,7 @@ of just the specified (or latest if unspecified) version.
 
 	r.Dispatch("undelete", &app.Help{
 		Summary: "Undelete a soft-deleted secret from a V2 backend",
-		Usage:   "safe undelete PATH [PATH ...]",
+		Usage:   "safe undelete [-af] PATH [PATH ...]",
 		Type:    app.DestructiveCommand,
 		Description: `
 If no version is specified, this attempts to undelete the newest version of the secret
@@ -95,6 +302,9 @@ This errors if the secret or version does not exist, of if the particular versio
 been irrevocably destroyed. An error also occurs if a key is specified.
 
 -a (--all) undeletes all versions of the given secret.
+
+PATHs can be globs (see 'safe help globs'), in which case the secrets that
+match are listed, and must be confirmed unless -f (--force) is given.
 `}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
 
@@ -103,6 +313,14 @@ been irrevocably destroyed. An error also occurs if a key is specified.
 		}
 		v := app.Connect(true)
 
+		args, globbed, err := expandGlobs(v, args, false)
+		if err != nil {
+			return err
+		}
+		if globbed && !opt.Undelete.Force && !confirmGlobs("undelete", args) {
+			return nil
+		}
+
 		for _, path := range args {
 			var err error
 			if opt.Undelete.All {
@@ -139,13 +357,31 @@ been irrevocably destroyed. An error also occurs if a key is specified.
 
 	r.Dispatch("revert", &app.Help{
 		Summary: "Revert a secret to a previous version",
-		Usage:   "safe revert PATH VERSION",
+		Usage:   "safe revert [-d] PATH VERSION\n       safe revert [-dRf] --as-of TIME PATH [PATH ...]",
 		Type:    app.DestructiveCommand,
 		Description: `
 -d (--deleted) will handle deleted versions by undeleting them, reading them, and then
 redeleting them.
+
+--as-of TIME puts each PATH back the way it was at TIME, which is an RFC 3339
+timestamp (2026-10-01T12:00Z), a date (YYYY-MM-DD), or a duration like 2d for that
+long ago. With -R (--recurse), every secret beneath each PATH is reverted. The
+version of each secret that was current at TIME, going by the KV v2 version
+metadata, is written back as a new version. Secrets created after TIME (or that
+were deleted at TIME) are deleted, and secrets that have not changed are left
+alone. Versions deleted since TIME can only be read back with -d; destroyed
+versions cannot be brought back, and are reported. Secrets in KV v1 mounts have
+no history, and are skipped.
+
+The plan is shown first, and must be confirmed, unless -f (--force) is given.
 `}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
+		if opt.Revert.AsOf != "" {
+			return revertAsOf(r, opt, args)
+		}
+		if opt.Revert.Recurse {
+			return fmt.Errorf("-R can only be used with --as-of")
+		}
 		if len(args) != 2 {
 			r.ExitWithUsage("revert")
 		}
@@ -233,7 +469,7 @@ redeleting them.
 
 	r.Dispatch("export", &app.Help{
 		Summary: "Export one or more subtrees for migration / backup purposes",
-		Usage:   "safe export [-ad] PATH [PATH ...]",
+		Usage:   "safe export [-ad] [--no-metadata] [--format json|k8s|bosh|credhub] [--since TIME|--since-export FILE] [--manifest FILE --signing-key FILE|--signing-key-path PATH[:KEY]] PATH [PATH ...]",
 		Type:    app.NonDestructiveCommand,
 		Description: `
 Normally, the export will get only the latest version of each secret, and encode it in a format that is backwards-
@@ -242,13 +478,125 @@ compatible with pre-1.0.0 versions of safe (and newer versions).
 incompatible with versions of safe prior to v1.0.0
 -d (--deleted) will cause safe to undelete, read, and then redelete deleted secrets in order to encode them in the
 backup. Without this, deleted versions will be ignored.
+KV v2 metadata (max_versions, cas_required, delete_version_after and custom_metadata) of each secret, and the
+configuration of each KV v2 mount, is included if it differs from the defaults. This also forces the V2 format.
+Metadata that the token may not read is left out, with a warning. --no-metadata leaves it all out.
+
+--format selects the output format:
+
+    json   The safe export format described above (default).
+    k8s    One Kubernetes Secret manifest per path, as a multi-document YAML
+           stream. Only the latest version of each secret is exported. Each
+           manifest records its Vault path in the safe/vault-path annotation,
+           so that 'safe import --format k8s' puts it back in the same place.
+
+           -n (--namespace) sets the namespace of every manifest.
+           --name-style picks how manifest names are derived from paths:
+             relative  path below the exported PATH, '/' becoming '-' (default)
+             full      the entire path, mount included
+             basename  the last path segment only
+           --name-prefix is prepended to every name.
+    bosh   A BOSH vars-store file. Each secret becomes a variable named after
+           its path below the exported PATH. Secrets laid out the way safe
+           generates passwords, certificates, ssh keys, rsa keys and users
+           become variables of that type; any other secret (one with just a
+           value key included) becomes a map of its keys. Only the latest
+           version of each secret is exported.
+    credhub
+           A file in the format of 'credhub export', with each secret named
+           /<path below the exported PATH>, typed as for bosh. Secrets that
+           do not fit one of those types are exported as json credentials.
+
+--manifest writes a signed integrity manifest of a json export to FILE. It lists
+every exported path, its number of versions and the SHA-256 of its canonicalized
+contents, along with the SHA-256 of the export itself, and is signed with the
+Ed25519 private key (PEM-encoded PKCS#8, as from 'openssl genpkey -algorithm
+ed25519') read from the file given by --signing-key, or from the Vault secret
+given by --signing-key-path (from its 'private' key, unless another is named).
+Use 'safe verify-export' to check the export against it.
+
+--since TIME makes an incremental json export, holding only the secrets with a
+KV v2 version created (or deleted) after TIME, which is an RFC 3339 timestamp,
+a date (YYYY-MM-DD), or a duration like 7d for that long ago. Secrets in KV v1
+mounts carry no timestamps, and are always included. Secrets whose latest
+version was deleted or destroyed since then are recorded as tombstones, so that
+importing the export deletes them too. --since-export FILE takes TIME from a
+previous export in the versioned format (a full export taken with --all, or an
+incremental one), and additionally records tombstones for secrets in FILE that
+are gone altogether. Use 'safe import BASE INCREMENTAL...' to apply a chain.
 `}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
 		if len(args) < 1 {
 			args = append(args, "secret")
 		}
+
+		incremental := opt.Export.Since != "" || opt.Export.SinceExport != ""
+		var since time.Time
+		var sinceBase []string
+		if incremental {
+			if opt.Export.Since != "" && opt.Export.SinceExport != "" {
+				return fmt.Errorf("--since and --since-export cannot be used together")
+			}
+			if opt.Export.Format != "" && opt.Export.Format != "json" {
+				return fmt.Errorf("Incremental exports can only be made with --format json")
+			}
+			if opt.Export.Manifest != "" {
+				return fmt.Errorf("--manifest cannot be used with incremental exports")
+			}
+
+			var err error
+			if opt.Export.Since != "" {
+				since, err = app.Timestamp(opt.Export.Since, time.Now())
+			} else {
+				since, sinceBase, err = exportTimestamp(opt.Export.SinceExport)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if opt.Export.Manifest != "" {
+			if opt.Export.Format != "" && opt.Export.Format != "json" {
+				return fmt.Errorf("--manifest can only be used with --format json")
+			}
+			if (opt.Export.SigningKey == "") == (opt.Export.SigningKeyPath == "") {
+				return fmt.Errorf("--manifest requires exactly one of --signing-key or --signing-key-path")
+			}
+		}
+
+		switch opt.Export.Format {
+		case "", "json":
+		case "bosh", "credhub":
+			if opt.Export.All {
+				return fmt.Errorf("--all cannot be used with --format %s, which only holds the latest version", opt.Export.Format)
+			}
+		case "k8s":
+			if opt.Export.All {
+				return fmt.Errorf("--all cannot be used with --format k8s, which only holds the latest version")
+			}
+			switch opt.Export.NameStyle {
+			case "", formats.K8sNameRelative, formats.K8sNameFull, formats.K8sNameBasename:
+			default:
+				return fmt.Errorf("Unknown --name-style `%s'", opt.Export.NameStyle)
+			}
+		default:
+			return fmt.Errorf("Unknown export format `%s'", opt.Export.Format)
+		}
+
 		v := app.Connect(true)
 
+		var key ed25519.PrivateKey
+		if opt.Export.Manifest != "" {
+			pem, err := readKeyMaterial(v, opt.Export.SigningKey, opt.Export.SigningKeyPath, "private")
+			if err != nil {
+				return err
+			}
+			key, err = formats.ParseEd25519PrivateKey(pem)
+			if err != nil {
+				return fmt.Errorf("Could not read signing key: %w", err)
+			}
+		}
+
 		var toExport interface{}
 
 		//Standardize and validate paths
@@ -279,22 +627,85 @@ backup. Without this, deleted versions will be ignored.
 			}
 		}
 
+		//Anything that changes while the export runs will be picked up by the
+		// next incremental export taken from this one
+		exportedAt := time.Now().UTC()
+
 		secrets := vault.Secrets{}
+		var tombstones []string
+		found := map[string]bool{}
 		for _, path := range args {
-			theseSecrets, err := v.ConstructSecrets(path, vault.TreeOpts{
+			err := app.WalkSecrets(v, path, vault.TreeOpts{
 				FetchKeys:           true,
 				FetchAllVersions:    opt.Export.All,
 				GetDeletedVersions:  opt.Export.Deleted,
-				AllowDeletedSecrets: opt.Export.Deleted,
+				AllowDeletedSecrets: opt.Export.Deleted || incremental,
+			}, func(s vault.SecretEntry) error {
+				if found[s.Path] {
+					return nil
+				}
+				found[s.Path] = true
+
+				//Incremental exports only hold on to what changed, so that the rest
+				// of the tree needn't be kept in memory until the walk is done
+				if incremental {
+					if len(s.Versions) == 0 {
+						return nil
+					}
+					if !opt.Export.Deleted && s.Versions[len(s.Versions)-1].State != vault.SecretStateAlive {
+						if s.RemovedSince(since) {
+							tombstones = append(tombstones, s.Path)
+						}
+						return nil
+					}
+					if !s.ChangedSince(since) {
+						return nil
+					}
+				}
+				secrets.Append(s)
+				return nil
 			})
 			if err != nil {
 				return err
 			}
+		}
+		secrets.Sort()
 
-			secrets = secrets.Merge(theseSecrets)
+		if incremental {
+			//Secrets that were destroyed entirely leave nothing behind to find
+			for _, path := range sinceBase {
+				if !found[path] && underAnyRoot(path, args) {
+					tombstones = append(tombstones, path)
+				}
+			}
+			sort.Strings(tombstones)
 		}
 
-		var mustV2Export bool
+		var b []byte
+		var err error
+		switch opt.Export.Format {
+		case "k8s":
+			b, err = formats.MarshalK8s(formats.Latest(secrets), formats.K8sOpts{
+				Namespace:  opt.Export.Namespace,
+				NameStyle:  opt.Export.NameStyle,
+				NamePrefix: opt.Export.NamePrefix,
+				Roots:      args,
+			})
+		case "bosh":
+			b, err = formats.MarshalBOSH(formats.Latest(secrets), formats.CredentialOpts{Roots: args})
+		case "credhub":
+			b, err = formats.MarshalCredHub(formats.Latest(secrets), formats.CredentialOpts{Roots: args})
+		}
+		if err != nil {
+			return err
+		}
+		if b != nil {
+			fmt.Printf("%s", string(b))
+			return nil
+		}
+
+		//Incremental exports need somewhere to put their tombstones
+		mustV2Export := incremental
 		//Determine if we can get away with a v1 export
 		for _, s := range secrets {
 			if len(s.Versions) > 1 {
@@ -303,6 +714,55 @@ backup. Without this, deleted versions will be ignored.
 			}
 		}
 
+		metadata := map[string]vault.SecretMetadata{}
+		mounts := map[string]vault.MountConfig{}
+		if opt.Export.Metadata {
+			seenMounts := map[string]bool{}
+			var v2Paths []string
+			for _, s := range secrets {
+				mountVersion, err := v.MountVersion(s.Path)
+				if err != nil {
+					return err
+				}
+				if mountVersion != 2 {
+					continue
+				}
+				v2Paths = append(v2Paths, s.Path)
+
+				mount, err := v.Client().MountPath(s.Path)
+				if err != nil {
+					return err
+				}
+				if !seenMounts[mount] {
+					seenMounts[mount] = true
+					conf, err := v.MountConfig(mount)
+					//Reading the mount config needs its own policy, which a token that can
+					// otherwise read the whole tree may well not have
+					if err != nil && !vault.IsForbidden(err) {
+						return err
+					}
+					if err == nil && !conf.IsDefault() {
+						mounts[mount] = conf
+						mustV2Export = true
+					}
+				}
+			}
+
+			all, forbidden, err := v.MetadataAll(v2Paths)
+			if err != nil {
+				return err
+			}
+			if len(forbidden) > 0 {
+				fmt.Fprintf(os.Stderr, "@Y{WARNING:} not allowed to read the metadata of %d secret(s), such as @C{%s}, so it is not exported\n", len(forbidden), forbidden[0])
+			}
+			for path, meta := range all {
+				if !meta.IsDefault() {
+					metadata[path] = meta
+					mustV2Export = true
+				}
+			}
+		}
+
 		v1Export := func() error {
 			export := make(map[string]*vault.Secret)
 			for _, s := range secrets {
@@ -314,7 +774,14 @@ backup. Without this, deleted versions will be ignored.
 		}
 
 		v2Export := func() error {
-			export := exportFormat{ExportVersion: 2, Data: map[string]exportSecret{}, RequiresVersioning: map[string]bool{}}
+			export := exportFormat{ExportVersion: 2, Data: map[string]exportSecret{}, RequiresVersioning: map[string]bool{}, ExportedAt: &exportedAt}
+			if incremental {
+				export.Since = &since
+				export.Tombstones = tombstones
+			}
+			if len(mounts) > 0 {
+				export.Mounts = mounts
+			}
 
 			for _, secret := range secrets {
 				if len(secret.Versions) > 1 {
@@ -323,6 +790,9 @@ backup. Without this, deleted versions will be ignored.
 				}
 
 				thisSecret := exportSecret{FirstVersion: secret.Versions[0].Number}
+				if meta, found := metadata[secret.Path]; found {
+					thisSecret.Metadata = &meta
+				}
 				//We want to omit the `first` key in the json if it's 1
 				if thisSecret.FirstVersion == 1 || opt.Export.Shallow {
 					thisSecret.FirstVersion = 0
@@ -343,15 +813,14 @@ backup. Without this, deleted versions will be ignored.
 				}
 
 				export.Data[secret.Path] = thisSecret
-
-				//Wrap export in array so that older versions of safe don't try to import this improperly.
-				toExport = []exportFormat{export}
 			}
 
+			//Wrap export in array so that older versions of safe don't try to import this improperly.
+			toExport = []exportFormat{export}
+
 			return nil
 		}
 
-		var err error
 		if mustV2Export {
 			err = v2Export()
 		} else {
@@ -361,41 +830,370 @@ backup. Without this, deleted versions will be ignored.
 		if err != nil {
 			return err
 		}
-		b, err := json.Marshal(&toExport)
+		b, err = json.Marshal(&toExport)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("%s\n", string(b))
+		b = append(b, '\n')
+
+		if opt.Export.Manifest != "" {
+			//Read the export back the way verify-export will, so that an
+			// untouched export always matches its manifest
+			exported, err := exportedVersions(b)
+			if err != nil {
+				return err
+			}
+
+			manifest := formats.Manifest{
+				Version:       formats.ManifestVersion,
+				CreatedAt:     time.Now().UTC(),
+				Roots:         args,
+				AllVersions:   opt.Export.All,
+				Deleted:       opt.Export.Deleted,
+				ExportVersion: 1,
+				ExportSHA256:  formats.SHA256(b),
+				Entries:       formats.ManifestEntries(exported),
+			}
+			if mustV2Export {
+				manifest.ExportVersion = 2
+			}
+			manifest.Sign(key)
+
+			mb, err := json.MarshalIndent(manifest, "", "  ")
+			if err != nil {
+				return err
+			}
+			err = os.WriteFile(opt.Export.Manifest, append(mb, '\n'), 0600)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("%s", string(b))
 
 		return nil
 	})
 
+	r.Dispatch("verify-export", &app.Help{
+		Summary: "Check a safe export against its integrity manifest",
+		Usage:   "safe verify-export [--live] [--json] [--public-key FILE|--public-key-path PATH[:KEY]] MANIFEST BACKUP",
+		Type:    app.NonDestructiveCommand,
+		Description: `
+Checks the signature of MANIFEST, as written by 'safe export --manifest', and
+then checks that the json export in the BACKUP file holds exactly the paths,
+version counts and contents listed in it.
+
+The signature is checked against the Ed25519 public key (PEM-encoded PKIX) read
+from the file given by --public-key, or from the Vault secret given by
+--public-key-path (from its 'public' key, unless another is named). A private
+key is accepted in either case. Without either, the key recorded in the
+manifest is used; that detects damage, but not a manifest re-signed by someone
+else.
+
+--live also checks the exported paths in the current Vault against the
+manifest, reading them the same way the export did.  It only reads, so it
+cannot check exports taken with --deleted, whose deleted versions could only be
+read by undeleting them.
+
+Paths that are in the manifest but not found are reported as missing, paths
+found but not in the manifest as extra, and paths whose versions or contents
+differ as changed. --json prints the report as JSON, as --output json does.
+
+Exits 0 if everything matches, and non-zero otherwise.
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) != 2 {
+			r.ExitWithUsage("verify-export")
+		}
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
+		if opt.VerifyExport.JSON {
+			out.Format = app.JSONOutput
+		}
+		if opt.VerifyExport.PublicKey != "" && opt.VerifyExport.PublicKeyPath != "" {
+			return fmt.Errorf("--public-key and --public-key-path cannot be used together")
+		}
+
+		mb, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		var manifest formats.Manifest
+		err = json.Unmarshal(mb, &manifest)
+		if err != nil {
+			return fmt.Errorf("Could not interpret manifest `%s': %w", args[0], err)
+		}
+		if manifest.Version != formats.ManifestVersion {
+			return fmt.Errorf("Unsupported manifest version %d", manifest.Version)
+		}
+		if opt.VerifyExport.Live && manifest.Deleted {
+			return fmt.Errorf("Cannot check an export taken with --deleted against the current Vault with --live, as that would undelete its deleted versions")
+		}
+
+		b, err := os.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+
+		var v *vault.Vault
+		if opt.VerifyExport.Live || opt.VerifyExport.PublicKeyPath != "" {
+			v = app.Connect(true)
+		}
+
+		var pub ed25519.PublicKey
+		if opt.VerifyExport.PublicKey != "" || opt.VerifyExport.PublicKeyPath != "" {
+			pem, err := readKeyMaterial(v, opt.VerifyExport.PublicKey, opt.VerifyExport.PublicKeyPath, "public")
+			if err != nil {
+				return err
+			}
+			pub, err = formats.ParseEd25519PublicKey(pem)
+			if err != nil {
+				return fmt.Errorf("Could not read public key: %w", err)
+			}
+		}
+
+		type report struct {
+			SignatureValid bool                  `json:"signature_valid" yaml:"signature_valid"`
+			SignatureError string                `json:"signature_error,omitempty" yaml:"signature_error,omitempty"`
+			KeyTrusted     bool                  `json:"key_trusted" yaml:"key_trusted"`
+			ChecksumValid  bool                  `json:"checksum_valid" yaml:"checksum_valid"`
+			Backup         formats.ManifestDiff  `json:"backup" yaml:"backup"`
+			Live           *formats.ManifestDiff `json:"live,omitempty" yaml:"live,omitempty"`
+		}
+		rpt := report{KeyTrusted: pub != nil}
+
+		if err := manifest.VerifySignature(pub); err != nil {
+			rpt.SignatureError = err.Error()
+		} else {
+			rpt.SignatureValid = true
+		}
+		rpt.ChecksumValid = formats.SHA256(b) == manifest.ExportSHA256
+
+		exported, err := exportedVersions(b)
+		if err != nil {
+			return err
+		}
+		rpt.Backup = manifest.Compare(formats.ManifestEntries(exported))
+
+		if opt.VerifyExport.Live {
+			live := map[string][]formats.SecretVersion{}
+			for _, path := range manifest.Roots {
+				secrets, err := app.ConstructSecrets(v, path, vault.TreeOpts{
+					FetchKeys:        true,
+					FetchAllVersions: manifest.AllVersions,
+				})
+				if err != nil {
+					return err
+				}
+				for _, s := range secrets {
+					live[s.Path] = liveVersions(s, manifest.ExportVersion)
+				}
+			}
+			diff := manifest.Compare(formats.ManifestEntries(live))
+			rpt.Live = &diff
+		}
+
+		ok := rpt.SignatureValid && rpt.ChecksumValid && rpt.Backup.Empty() && (rpt.Live == nil || rpt.Live.Empty())
+
+		err = out.Print(rpt, func() error {
+			if rpt.SignatureValid {
+				fmt.Printf("@G{signature valid}\n")
+				if !rpt.KeyTrusted {
+					fmt.Printf("@Y{WARNING:} checked against the key recorded in the manifest; use --public-key to check who signed it\n")
+				}
+			} else {
+				fmt.Printf("@R{signature invalid:} %s\n", rpt.SignatureError)
+			}
+			if rpt.ChecksumValid {
+				fmt.Printf("@G{backup checksum matches}\n")
+			} else {
+				fmt.Printf("@R{backup checksum does not match}\n")
+			}
+
+			printDiff := func(what string, diff formats.ManifestDiff) {
+				if diff.Empty() {
+					fmt.Printf("@G{%s matches the manifest (%d paths)}\n", what, len(manifest.Entries))
+					return
+				}
+				for _, path := range diff.Missing {
+					fmt.Printf("%s: @R{missing} @C{%s}\n", what, path)
+				}
+				for _, path := range diff.Extra {
+					fmt.Printf("%s: @Y{extra} @C{%s}\n", what, path)
+				}
+				for _, path := range diff.Changed {
+					fmt.Printf("%s: @M{changed} @C{%s}\n", what, path)
+				}
+			}
+			printDiff("backup", rpt.Backup)
+			if rpt.Live != nil {
+				printDiff("live", *rpt.Live)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			os.Exit(1)
+		}
+		return nil
+	})
+
 	r.Dispatch("import", &app.Help{
 		Summary: "Import name/value pairs into the current Vault",
-		Usage:   "safe import <backup/file.json",
+		Usage:   "safe import [-Iis] [--no-metadata] [--resume CHECKPOINT] [--format json|k8s|bosh|credhub [PREFIX]] [FILE ...] <backup/file.json",
 		Type:    app.DestructiveCommand,
 		Description: `
 -I (--ignore-destroyed) will keep destroyed versions from being replicated in the import by
 rting garbage data and then destroying it (which is originally done to preserve version numbering).
 -i (--ignore-deleted) will ignore deleted versions from being written during the import.
 -s (--shallow) will write only the latest version for each secret.
+--no-metadata will not restore KV v2 secret metadata or mount configuration, for use when
+the destination token is not allowed to write them.
+--resume CHECKPOINT records progress in the CHECKPOINT file as secrets are written. If
+the import is interrupted, running it again with the same input and --resume CHECKPOINT
+carries on where it stopped, without writing any version twice. The file is removed once
+the import has finished.
+
+--format selects the input format:
+
+    json   A file written by 'safe export' (default). Files given as FILE
+           arguments are imported in order, instead of standard input; this
+           applies a base export followed by a chain of incremental ones
+           (from 'safe export --since'). Each incremental export adds the
+           versions it holds that are newer than those already written
+           (keeping the history earlier exports in the chain wrote), and
+           deletes the secrets it has tombstones for. The chain is checked
+           for gaps before anything is written.
+    k8s    A multi-document YAML stream of Kubernetes Secret manifests. Each
+           manifest is written to the path in its safe/vault-path annotation,
+           or to PREFIX/<name> if it does not have one. Documents that are
+           not Secrets are skipped.
+    bosh   A BOSH vars-store file. Each variable is written to PREFIX/<name>,
+           which is required. Passwords, certificates, ssh keys, rsa keys and
+           users are laid out the way safe generates them (certificates with
+           certificate, key and combined keys, plus ca if signed by another
+           CA); any other map is written key for key.
+    credhub
+           A file written by 'credhub export'. Each credential is written to
+           PREFIX/<name>, or to <name> if no PREFIX is given, converted as for
+           bosh. value and json credentials are written key for key.
 `}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
-		b, err := io.ReadAll(os.Stdin)
-		if err != nil {
-			return err
-		}
-		if err != nil {
-			return err
-		}
 
 		if opt.SkipIfExists {
 			fmt.Fprintf(os.Stderr, "@R{!!} @C{--no-clobber} @R{is incompatible with} @C{safe import}\n")
 			r.ExitWithUsage("import")
 		}
 
+		switch opt.Import.Format {
+		case "", "json":
+		case "k8s", "credhub":
+			if len(args) > 1 {
+				r.ExitWithUsage("import")
+			}
+		case "bosh":
+			if len(args) != 1 {
+				r.ExitWithUsage("import")
+			}
+		default:
+			return fmt.Errorf("Unknown import format `%s'", opt.Import.Format)
+		}
+
+		isJSON := opt.Import.Format == "" || opt.Import.Format == "json"
+		var inputs [][]byte
+		var inputNames []string
+		if isJSON && len(args) > 0 {
+			for _, file := range args {
+				b, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				inputs = append(inputs, b)
+				inputNames = append(inputNames, file)
+			}
+		} else {
+			b, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			inputs = append(inputs, b)
+			inputNames = append(inputNames, "standard input")
+		}
+
 		v := app.Connect(true)
 
+		var checkpoint *vault.Checkpoint
+		if opt.Import.Resume != "" {
+			source := opt.Import.Format
+			for _, input := range inputs {
+				source += ":" + formats.SHA256(input)
+			}
+			var err error
+			checkpoint, err = vault.OpenCheckpoint(opt.Import.Resume, "import", source)
+			if err != nil {
+				return err
+			}
+		}
+
+		writeSecrets := func(secrets []formats.Secret) error {
+			for _, s := range secrets {
+				if checkpoint.Done(s.Path) {
+					continue
+				}
+				secret := vault.NewSecret()
+				for k, val := range s.Data {
+					secret.Set(k, val, false)
+				}
+				err := v.Write(s.Path, secret)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "wrote %s\n", s.Path)
+				if err = checkpoint.MarkDone(s.Path); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		//finish removes the checkpoint once everything has been imported, or
+		// says how to pick up from it if not
+		finish := func(err error) error {
+			if err != nil {
+				return resumable(checkpoint, "import", opt.Import.Resume, err)
+			}
+			return checkpoint.Remove()
+		}
+
+		if !isJSON {
+			prefix := ""
+			if len(args) == 1 {
+				prefix = args[0]
+			}
+
+			b := inputs[0]
+			var secrets []formats.Secret
+			var err error
+			switch opt.Import.Format {
+			case "k8s":
+				secrets, err = formats.UnmarshalK8s(b, prefix)
+			case "bosh":
+				secrets, err = formats.UnmarshalBOSH(b, prefix)
+			case "credhub":
+				secrets, err = formats.UnmarshalCredHub(b, prefix)
+			}
+			if err != nil {
+				return err
+			}
+			return finish(writeSecrets(secrets))
+		}
+
 		type importFunc func([]byte) error
 
 		v1Import := func(input []byte) error {
@@ -405,11 +1203,17 @@ rting garbage data and then destroying it (which is originally done to preserve
 				return err
 			}
 			for path, s := range data {
+				if checkpoint.Done(path) {
+					continue
+				}
 				err = v.Write(path, s)
 				if err != nil {
 					return err
 				}
 				fmt.Fprintf(os.Stderr, "wrote %s\n", path)
+				if err = checkpoint.MarkDone(path); err != nil {
+					return err
+				}
 			}
 			return nil
 		}
@@ -447,9 +1251,32 @@ rting garbage data and then destroying it (which is originally done to preserve
 				}
 			}
 
+			//KV v1 mounts have no metadata, so what the export has for secrets and
+			// mounts that end up in one is left out, with a warning for each mount
+			warned := map[string]bool{}
+			keepsMetadata := func(path string) (bool, error) {
+				mountVersion, err := v.MountVersion(path)
+				if err != nil || mountVersion == 2 {
+					return mountVersion == 2, err
+				}
+				mount, err := v.Client().MountPath(path)
+				if err != nil {
+					return false, err
+				}
+				mount = strings.Trim(mount, "/")
+				if !warned[mount] {
+					warned[mount] = true
+					fmt.Fprintf(os.Stderr, "@Y{WARNING:} @C{%s} is not a KV v2 mount, so its metadata is not imported\n", mount)
+				}
+				return false, nil
+			}
+
 			//Put the secrets in the places, writing the versions in the correct order and deleting/destroying secrets that
 			// need to be deleted/destroyed.
 			for path, secret := range data.Data {
+				if checkpoint.Done(path) {
+					continue
+				}
 				s := vault.SecretEntry{
 					Path: path,
 				}
@@ -486,85 +1313,181 @@ rting garbage data and then destroying it (which is originally done to preserve
 					})
 				}
 
+				//Incremental exports carry on from the history earlier imports
+				// wrote, rather than replacing it
 				err := s.Copy(v, s.Path, vault.TreeCopyOpts{
-					Clear: true,
-					Pad:   !(opt.Import.IgnoreDestroyed || opt.Import.Shallow),
+					Clear:      data.Since == nil,
+					Append:     data.Since != nil,
+					Pad:        !(opt.Import.IgnoreDestroyed || opt.Import.Shallow),
+					Checkpoint: checkpoint,
 				})
 				if err != nil {
 					return err
 				}
+
+				//Metadata goes in after the versions, because cas_required would
+				// otherwise refuse the writes that Copy just made
+				if secret.Metadata != nil && opt.Import.Metadata {
+					keep, err := keepsMetadata(path)
+					if err != nil {
+						return err
+					}
+					if keep {
+						err = v.SetMetadata(path, *secret.Metadata)
+						if err != nil {
+							return fmt.Errorf("Could not restore metadata of `%s': %w", path, err)
+						}
+					}
+				}
+
+				if err = checkpoint.MarkDone(path); err != nil {
+					return err
+				}
+			}
+
+			for _, path := range data.Tombstones {
+				err = v.Delete(path, vault.DeleteOpts{})
+				if err != nil && !vault.IsNotFound(err) {
+					return fmt.Errorf("Could not delete `%s': %w", path, err)
+				}
+			}
+
+			if opt.Import.Metadata {
+				for mount, conf := range data.Mounts {
+					keep, err := keepsMetadata(mount)
+					if err != nil {
+						return err
+					}
+					if !keep {
+						continue
+					}
+					err = v.SetMountConfig(mount, conf)
+					if err != nil {
+						return fmt.Errorf("Could not restore configuration of mount `%s': %w", mount, err)
+					}
+				}
 			}
 
 			return nil
 		}
 
-		var fn importFunc
-		//determine which version of the export format this is
-		var typeTest interface{}
-		json.Unmarshal(b, &typeTest)
-		switch v := typeTest.(type) {
-		case map[string]interface{}:
-			fn = v1Import
-		case []interface{}:
-			if len(v) == 1 {
-				if meta, isMap := (v[0]).(map[string]interface{}); isMap {
-					version, isFloat64 := meta["export_version"].(float64)
-					if isFloat64 && version == 2 {
-						fn = v2Import
+		//Work out the format of every file, and make sure a chain of
+		// incremental exports has no gaps, before writing anything
+		fns := make([]importFunc, len(inputs))
+		var lastExportedAt *time.Time
+		for i, input := range inputs {
+			//determine which version of the export format this is
+			var typeTest interface{}
+			json.Unmarshal(input, &typeTest)
+			switch v := typeTest.(type) {
+			case map[string]interface{}:
+				fns[i] = v1Import
+				lastExportedAt = nil
+			case []interface{}:
+				if len(v) == 1 {
+					if meta, isMap := (v[0]).(map[string]interface{}); isMap {
+						version, isFloat64 := meta["export_version"].(float64)
+						if isFloat64 && version == 2 {
+							fns[i] = v2Import
+						}
 					}
 				}
 			}
-		}
 
-		if fn == nil {
-			return fmt.Errorf("Unknown export file format - aborting")
+			if fns[i] == nil {
+				return fmt.Errorf("Unknown export file format in %s - aborting", inputNames[i])
+			}
+			if len(inputs) == 1 {
+				continue
+			}
+
+			var header []exportFormat
+			if err := json.Unmarshal(input, &header); err != nil || len(header) != 1 {
+				continue
+			}
+			if header[0].Since != nil && lastExportedAt != nil && header[0].Since.After(*lastExportedAt) {
+				return fmt.Errorf("%s holds changes since %s, but %s was only exported at %s; changes in between would be lost",
+					inputNames[i], header[0].Since.Format(time.RFC3339), inputNames[i-1], lastExportedAt.Format(time.RFC3339))
+			}
+			if header[0].ExportedAt != nil && lastExportedAt != nil && header[0].ExportedAt.Before(*lastExportedAt) {
+				return fmt.Errorf("%s was exported before %s; give the exports oldest first", inputNames[i], inputNames[i-1])
+			}
+			lastExportedAt = header[0].ExportedAt
 		}
 
-		return fn(b)
+		for i, input := range inputs {
+			//Files that were fully imported before an interruption are done
+			if i < checkpoint.Step() {
+				continue
+			}
+			if err := checkpoint.BeginStep(i); err != nil {
+				return err
+			}
+			if len(inputs) > 1 {
+				fmt.Fprintf(os.Stderr, "importing @C{%s}\n", inputNames[i])
+			}
+			if err := fns[i](input); err != nil {
+				return finish(err)
+			}
+		}
+		return finish(nil)
 	})
 
 	r.Dispatch("move", &app.Help{
 		Summary: "Move a secret from one path to another",
-		Usage:   "safe move [-rfd] OLD-PATH NEW-PATH",
+		Usage:   "safe move [-rfd] [TARGET:]OLD-PATH [TARGET:]NEW-PATH",
 		Type:    app.DestructiveCommand,
 		Description: `
 Specifying the --deep (-d) flag will cause versions to be grabbed from the source
 and overwrite all versions of the secret at the destination.
+
+Either path may be prefixed with the name of a target from 'safe targets' and a
+colon, to move secrets between Vaults without going through a file. Versions are
+carried over with --deep, unless the destination is a KV v1 mount, which only
+gets the latest living version. A target named after a mount of the current
+target has to be given as @TARGET:path, and the mount as /MOUNT:key.
 `}, func(command string, args ...string) error {
-		rc.Apply(opt.UseTarget)
+		cfg := rc.Apply(opt.UseTarget)
 		if len(args) != 2 {
 			r.ExitWithUsage("move")
 		}
 
-		v := app.Connect(true)
-		if vault.PathHasKey(args[0]) || vault.PathHasKey(args[1]) {
+		src, from, dst, to, err := connectPair(cfg, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if vault.PathHasKey(from) || vault.PathHasKey(to) {
 			if opt.Move.Deep {
 				return fmt.Errorf("Cannot deep copy a specific key")
 			}
 
-			if !vault.PathHasKey(args[0]) && vault.PathHasKey(args[1]) {
+			if !vault.PathHasKey(from) && vault.PathHasKey(to) {
 				return fmt.Errorf("Cannot move from entire secret into specific key")
 			}
 		}
 
-		if vault.PathHasVersion(args[1]) {
+		if vault.PathHasVersion(to) {
 			return fmt.Errorf("Cannot move to a specific destination version")
 		}
 
+		mv := func(oldpath, newpath string, opts vault.MoveCopyOpts) error {
+			return src.MoveTo(dst, oldpath, newpath, opts)
+		}
+
 		//Don't try to recurse if operating on a key
-		// args[0] is the source path. args[1] is the destination path.
-		if opt.Move.Recurse && !(vault.PathHasKey(args[0]) || vault.PathHasKey(args[1])) {
+		// from is the source path. to is the destination path.
+		if opt.Move.Recurse && !(vault.PathHasKey(from) || vault.PathHasKey(to)) {
 			if !opt.Move.Force && !recursively("move", args...) {
 				return nil /* skip this command, process the next */
 			}
-			err := v.MoveCopyTree(args[0], args[1], v.Move, vault.MoveCopyOpts{
+			err := src.MoveCopyTreeTo(dst, from, to, mv, vault.MoveCopyOpts{
 				SkipIfExists: opt.SkipIfExists, Quiet: opt.Quiet, Deep: opt.Move.Deep, DeletedVersions: opt.Move.Deep,
 			})
 			if err != nil && !(vault.IsNotFound(err) && opt.Move.Force) {
 				return err
 			}
 		} else {
-			err := v.Move(args[0], args[1], vault.MoveCopyOpts{
+			err := mv(from, to, vault.MoveCopyOpts{
 				SkipIfExists: opt.SkipIfExists, Quiet: opt.Quiet, Deep: opt.Move.Deep, DeletedVersions: opt.Move.Deep,
 			})
 			if err != nil && !(vault.IsNotFound(err) && opt.Move.Force) {
@@ -576,67 +1499,189 @@ and overwrite all versions of the secret at the destination.
 
 	r.Dispatch("copy", &app.Help{
 		Summary: "Copy a secret from one path to another",
-		Usage:   "safe copy [-rfd] OLD-PATH NEW-PATH",
+		Usage:   "safe copy [-rfd] [--resume CHECKPOINT] [TARGET:]OLD-PATH [TARGET:]NEW-PATH",
 		Type:    app.DestructiveCommand,
 		Description: `
 Specifying the --deep (-d) flag will cause all living versions to be grabbed from the source
 and overwrite all versions of the secret at the destination.
+
+Either path may be prefixed with the name of a target from 'safe targets' and a
+colon, to copy secrets between Vaults without going through a file. Versions are
+carried over with --deep, unless the destination is a KV v1 mount, which only
+gets the latest living version. A target named after a mount of the current
+target has to be given as @TARGET:path, and the mount as /MOUNT:key.
+
+Specifying --resume CHECKPOINT records progress in the CHECKPOINT file as secrets are
+copied. If the copy is interrupted, running it again with the same paths and flags and
+--resume CHECKPOINT carries on where it stopped, without writing any version twice. The
+file is removed once the copy has finished.
 `}, func(command string, args ...string) error {
-		rc.Apply(opt.UseTarget)
+		cfg := rc.Apply(opt.UseTarget)
 
 		if len(args) != 2 {
 			r.ExitWithUsage("copy")
 		}
-		v := app.Connect(true)
+		src, from, dst, to, err := connectPair(cfg, args[0], args[1])
+		if err != nil {
+			return err
+		}
 
-		if vault.PathHasKey(args[0]) || vault.PathHasKey(args[1]) {
+		if vault.PathHasKey(from) || vault.PathHasKey(to) {
 			if opt.Copy.Deep {
 				return fmt.Errorf("Cannot deep copy a specific key")
 			}
 
-			if !vault.PathHasKey(args[0]) && vault.PathHasKey(args[1]) {
+			if !vault.PathHasKey(from) && vault.PathHasKey(to) {
 				return fmt.Errorf("Cannot move from entire secret into specific key")
 			}
 		}
 
-		if vault.PathHasVersion(args[1]) {
+		if vault.PathHasVersion(to) {
 			return fmt.Errorf("Cannot copy to a specific destination version")
 		}
 
-		if opt.Copy.Recurse && vault.PathHasVersion(args[0]) {
+		if opt.Copy.Recurse && vault.PathHasVersion(from) {
 			return fmt.Errorf("Cannot recursively copy a path with specific version")
 		}
 
+		var checkpoint *vault.Checkpoint
+		if opt.Copy.Resume != "" {
+			source := fmt.Sprintf("%s -> %s (recurse: %t, deep: %t)",
+				vault.Canonicalize(args[0]), vault.Canonicalize(args[1]), opt.Copy.Recurse, opt.Copy.Deep)
+			checkpoint, err = vault.OpenCheckpoint(opt.Copy.Resume, "copy", source)
+			if err != nil {
+				return err
+			}
+		}
+
+		cp := func(oldpath, newpath string, opts vault.MoveCopyOpts) error {
+			return src.CopyTo(dst, oldpath, newpath, opts)
+		}
+
 		//Don't try to recurse if operating on a key
-		// args[0] is the source path. args[1] is the destination path.
-		if opt.Copy.Recurse && !(vault.PathHasKey(args[0]) || vault.PathHasKey(args[1])) {
+		// from is the source path. to is the destination path.
+		if opt.Copy.Recurse && !(vault.PathHasKey(from) || vault.PathHasKey(to)) {
 			if !opt.Copy.Force && !recursively("copy", args...) {
 				return nil /* skip this command, process the next */
 			}
-			err := v.MoveCopyTree(args[0], args[1], v.Copy, vault.MoveCopyOpts{
+			err := src.MoveCopyTreeTo(dst, from, to, cp, vault.MoveCopyOpts{
 				SkipIfExists:    opt.SkipIfExists,
 				Quiet:           opt.Quiet,
 				Deep:            opt.Copy.Deep,
 				DeletedVersions: opt.Copy.Deep,
+				Checkpoint:      checkpoint,
 			})
 			if err != nil && !(vault.IsNotFound(err) && opt.Copy.Force) {
-				return err
+				return resumable(checkpoint, "copy", opt.Copy.Resume, err)
 			}
 		} else {
-			err := v.Copy(args[0], args[1], vault.MoveCopyOpts{
+			err := cp(from, to, vault.MoveCopyOpts{
 				SkipIfExists:    opt.SkipIfExists,
 				Quiet:           opt.Quiet,
 				Deep:            opt.Copy.Deep,
 				DeletedVersions: opt.Copy.Deep,
+				Checkpoint:      checkpoint,
 			})
 			if err != nil && !(vault.IsNotFound(err) && opt.Copy.Force) {
-				return err
+				return resumable(checkpoint, "copy", opt.Copy.Resume, err)
 			}
 		}
-		return nil
+		return checkpoint.Remove()
 	})
 }
 
+// revertAsOf puts the given paths (or, with -R, subtrees) back the way they
+// were at the time given by --as-of
+func revertAsOf(r *app.Runner, opt *Options, args []string) error {
+	if len(args) < 1 {
+		r.ExitWithUsage("revert")
+	}
+	asOf, err := app.Timestamp(opt.Revert.AsOf, time.Now())
+	if err != nil {
+		return err
+	}
+
+	v := app.Connect(true)
+
+	var plans []vault.RevertPlan
+	for _, path := range args {
+		if vault.PathHasKey(path) || vault.PathHasVersion(path) {
+			return fmt.Errorf("Cannot revert `%s' as of a point in time; give a path without a key or version", path)
+		}
+		secrets, err := v.ConstructSecrets(path, vault.TreeOpts{
+			FetchKeys:           true,
+			FetchAllVersions:    true,
+			AllowDeletedSecrets: true,
+			GetDeletedVersions:  opt.Revert.Deleted,
+			GetOnly:             !opt.Revert.Recurse,
+		})
+		if err != nil {
+			return err
+		}
+		for _, s := range secrets {
+			plans = append(plans, s.RevertPlan(asOf, opt.Revert.Deleted))
+		}
+	}
+
+	var todo []vault.RevertPlan
+	tbl := app.Table{}
+	tbl.SetHeader("path", "action", "details")
+	for _, p := range plans {
+		switch p.Action {
+		case vault.RevertRestore:
+			tbl.AddRow(fmt.Sprintf("@C{%s}", p.Path), "@G{restore}", fmt.Sprintf("version %d as a new version (currently %d)", p.Target, p.Current))
+			todo = append(todo, p)
+		case vault.RevertDelete:
+			tbl.AddRow(fmt.Sprintf("@C{%s}", p.Path), "@R{delete}", p.Reason)
+			todo = append(todo, p)
+		case vault.RevertUnavailable:
+			tbl.AddRow(fmt.Sprintf("@C{%s}", p.Path), "@Y{cannot revert}", p.Reason)
+		default:
+			if !opt.Quiet {
+				tbl.AddRow(fmt.Sprintf("@C{%s}", p.Path), "skip", p.Reason)
+			}
+		}
+	}
+
+	fmt.Printf("Reverting to @M{%s}:\n", asOf.Local().Format(time.RFC3339))
+	tbl.Print()
+
+	if len(todo) == 0 {
+		fmt.Printf("Nothing to do.\n")
+		return nil
+	}
+
+	if !opt.Revert.Force {
+		what := fmt.Sprintf("as of %s", asOf.Local().Format(time.RFC3339))
+		if opt.Revert.Recurse && !recursively("revert", append(args, what)...) {
+			return nil
+		}
+		if !opt.Revert.Recurse {
+			y := strings.TrimSpace(prompt.Normal("Revert @C{%s} %s @Y{(y/n)} ", strings.Join(args, " "), what))
+			if y != "y" && y != "yes" {
+				return nil
+			}
+		}
+	}
+
+	for _, p := range todo {
+		switch p.Action {
+		case vault.RevertRestore:
+			err = v.Write(p.Path, p.Data)
+		case vault.RevertDelete:
+			err = v.Delete(p.Path, vault.DeleteOpts{})
+		}
+		if err != nil {
+			return fmt.Errorf("Could not revert `%s': %w", p.Path, err)
+		}
+		if !opt.Quiet {
+			fmt.Fprintf(os.Stderr, "reverted @C{%s}\n", p.Path)
+		}
+	}
+
+	return nil
+}
+
 func recursively(cmd string, args ...string) bool {
 	y := prompt.Normal("Recursively @R{%s} @C{%s} @Y{(y/n)} ", cmd, strings.Join(args, " "))
 	y = strings.TrimSpace(y)
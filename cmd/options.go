@@ -8,6 +8,14 @@ type Options struct {
 	SkipIfExists bool
 	Quiet        bool `cli:"--quiet"`
 
+	// How many requests to make at once when walking trees of secrets, or
+	// "auto".  Reported back to main through $SAFE_WORKERS.
+	Workers string `cli:"--workers" env:"SAFE_WORKERS"`
+	// Print how long each command took, and how many requests it made.
+	Timing bool `cli:"--timing"`
+	// Print what read-only commands find as json or yaml, rather than text.
+	Output string `cli:"--output"`
+
 	// Behavour of -T must chain through -- separated commands.  There is code
 	// that relies on this.  Will default to $SAFE_TARGET if it exists, or
 	// the current safe target otherwise.
@@ -41,8 +49,16 @@ type Options struct {
 	Logout struct{} `cli:"logout"`
 	Renew  struct{} `cli:"renew"`
 	Ask    struct{} `cli:"ask"`
-	Set    struct{} `cli:"set, write"`
-	Paste  struct{} `cli:"paste"`
+	Set    struct {
+		FromDotenv string `cli:"--from-dotenv"`
+		CAS        string `cli:"--cas"`
+		Patch      bool   `cli:"--patch"`
+	} `cli:"set, write"`
+	Paste struct {
+		CAS   string `cli:"--cas"`
+		Patch bool   `cli:"--patch"`
+	} `cli:"paste"`
+	Edit   struct{} `cli:"edit"`
 	Exists struct{} `cli:"exists, check"`
 
 	Local struct {
@@ -70,8 +86,11 @@ type Options struct {
 	} `cli:"rekey"`
 
 	Get struct {
-		KeysOnly bool `cli:"--keys"`
-		Yaml     bool `cli:"--yaml"`
+		KeysOnly bool   `cli:"--keys"`
+		Yaml     bool   `cli:"--yaml"`
+		Format   string `cli:"--format"`
+		Recurse  bool   `cli:"-R, -r, --recurse"`
+		Resolve  bool   `cli:"--resolve"`
 	} `cli:"get, read, cat"`
 
 	Versions struct{} `cli:"versions,revisions"`
@@ -79,19 +98,35 @@ type Options struct {
 	List struct {
 		Single bool `cli:"-1"`
 		Quick  bool `cli:"-q, --quick"`
+		Long   bool `cli:"-l, --long"`
 	} `cli:"ls"`
 
 	Paths struct {
 		ShowKeys bool `cli:"--keys"`
 		Quick    bool `cli:"-q, --quick"`
+		Long     bool `cli:"-l, --long"`
 	} `cli:"paths"`
 
 	Tree struct {
 		ShowKeys   bool `cli:"--keys"`
 		HideLeaves bool `cli:"-d, --hide-leaves"`
 		Quick      bool `cli:"-q, --quick"`
+		Long       bool `cli:"-l, --long"`
 	} `cli:"tree"`
 
+	Find struct {
+		Path        string `cli:"--path"`
+		Key         string `cli:"--key"`
+		Value       string `cli:"--value"`
+		ValueSHA256 string `cli:"--value-sha256"`
+		Show        bool   `cli:"--show"`
+		Versions    bool   `cli:"--versions"`
+	} `cli:"find"`
+
+	Diff struct {
+		ShowValues bool `cli:"--show-values"`
+	} `cli:"diff"`
+
 	Target struct {
 		JSON        bool     `cli:"--json"`
 		Interactive bool     `cli:"-i, --interactive"`
@@ -110,27 +145,54 @@ type Options struct {
 	} `cli:"delete, rm"`
 
 	Undelete struct {
-		All bool `cli:"-a, --all"`
+		All   bool `cli:"-a, --all"`
+		Force bool `cli:"-f, --force"`
 	} `cli:"undelete, unrm, urm"`
 
 	Revert struct {
-		Deleted bool `cli:"-d, --deleted"`
+		Deleted bool   `cli:"-d, --deleted"`
+		Recurse bool   `cli:"-R, -r, --recurse"`
+		Force   bool   `cli:"-f, --force"`
+		AsOf    string `cli:"--as-of"`
 	} `cli:"revert"`
 
 	Export struct {
-		All     bool `cli:"-a, --all"`
-		Deleted bool `cli:"-d, --deleted"`
+		All      bool `cli:"-a, --all"`
+		Deleted  bool `cli:"-d, --deleted"`
+		Metadata bool `cli:"--metadata, --no-metadata"`
+
+		Format     string `cli:"--format"`
+		Namespace  string `cli:"-n, --namespace"`
+		NameStyle  string `cli:"--name-style"`
+		NamePrefix string `cli:"--name-prefix"`
+
+		Since       string `cli:"--since"`
+		SinceExport string `cli:"--since-export"`
+
+		Manifest       string `cli:"--manifest"`
+		SigningKey     string `cli:"--signing-key"`
+		SigningKeyPath string `cli:"--signing-key-path"`
 		//These do nothing but are kept for backwards-compat
 		OnlyAlive bool `cli:"-o, --only-alive"`
 		Shallow   bool `cli:"-s, --shallow"`
 	} `cli:"export"`
 
 	Import struct {
-		IgnoreDestroyed bool `cli:"-I, --ignore-destroyed"`
-		IgnoreDeleted   bool `cli:"-i, --ignore-deleted"`
-		Shallow         bool `cli:"-s, --shallow"`
+		IgnoreDestroyed bool   `cli:"-I, --ignore-destroyed"`
+		IgnoreDeleted   bool   `cli:"-i, --ignore-deleted"`
+		Shallow         bool   `cli:"-s, --shallow"`
+		Metadata        bool   `cli:"--metadata, --no-metadata"`
+		Format          string `cli:"--format"`
+		Resume          string `cli:"--resume"`
 	} `cli:"import"`
 
+	VerifyExport struct {
+		Live          bool   `cli:"--live"`
+		PublicKey     string `cli:"--public-key"`
+		PublicKeyPath string `cli:"--public-key-path"`
+		JSON          bool   `cli:"--json"`
+	} `cli:"verify-export"`
+
 	Move struct {
 		Recurse bool `cli:"-R, -r, --recurse"`
 		Force   bool `cli:"-f, --force"`
@@ -138,14 +200,17 @@ type Options struct {
 	} `cli:"move, rename, mv"`
 
 	Copy struct {
-		Recurse bool `cli:"-R, -r, --recurse"`
-		Force   bool `cli:"-f, --force"`
-		Deep    bool `cli:"-d, --deep"`
+		Recurse bool   `cli:"-R, -r, --recurse"`
+		Force   bool   `cli:"-f, --force"`
+		Deep    bool   `cli:"-d, --deep"`
+		Resume  string `cli:"--resume"`
 	} `cli:"copy, cp"`
 
 	Gen struct {
 		Policy string `cli:"-p, --policy"`
 		Length int    `cli:"-l, --length"`
+		CAS    string `cli:"--cas"`
+		Patch  bool   `cli:"--patch"`
 	} `cli:"gen, auto, generate"`
 
 	SSH     struct{} `cli:"ssh"`
@@ -153,7 +218,10 @@ type Options struct {
 	DHParam struct{} `cli:"dhparam, dhparams, dh"`
 	Prompt  struct{} `cli:"prompt"`
 	Vault   struct{} `cli:"vault!"`
-	Fmt     struct{} `cli:"fmt"`
+	Fmt     struct {
+		CAS   string `cli:"--cas"`
+		Patch bool   `cli:"--patch"`
+	} `cli:"fmt"`
 
 	Curl struct {
 		DataOnly bool `cli:"--data-only"`
@@ -168,6 +236,48 @@ type Options struct {
 		Apply struct{} `cli:"apply"`
 	} `cli:"sync"`
 
+	Render struct {
+		Out   string `cli:"-o, --out"`
+		Mode  string `cli:"-m, --mode"`
+		Check bool   `cli:"--check"`
+	} `cli:"render"`
+
+	Exec struct{} `cli:"exec!"`
+
+	Refs struct {
+		Scope string `cli:"-s, --scope"`
+	} `cli:"refs"`
+
+	Du struct {
+		Depth int    `cli:"-d, --depth"`
+		Quick bool   `cli:"-q, --quick"`
+		Sort  string `cli:"-s, --sort"`
+	} `cli:"du"`
+
+	Watch struct {
+		Interval string   `cli:"-i, --interval"`
+		Exec     string   `cli:"-x, --exec"`
+		Render   []string `cli:"-r, --render"`
+		Mode     string   `cli:"-m, --mode"`
+	} `cli:"watch"`
+
+	Meta struct {
+		Get struct {
+			Recurse bool `cli:"-R, -r, --recurse"`
+			JSON    bool `cli:"--json"`
+		} `cli:"get"`
+		Set struct {
+			Recurse bool `cli:"-R, -r, --recurse"`
+			Force   bool `cli:"-f, --force"`
+		} `cli:"set"`
+		Mount struct {
+			Get struct {
+				JSON bool `cli:"--json"`
+			} `cli:"get"`
+			Set struct{} `cli:"set"`
+		} `cli:"mount"`
+	} `cli:"meta"`
+
 	X509 struct {
 		Validate struct {
 			CA         bool     `cli:"-A, --ca"`
@@ -230,6 +340,12 @@ func NewOptions() *Options {
 	opt.X509.Issue.Bits = 4096
 	opt.Init.Persist = true
 	opt.Rekey.Persist = true
+	opt.Export.Metadata = true
+	opt.Import.Metadata = true
 	opt.Target.Strongbox = true
+	opt.Render.Mode = "0600"
+	opt.Watch.Interval = "30s"
+	opt.Watch.Mode = "0600"
+	opt.Du.Depth = -1
 	return opt
 }
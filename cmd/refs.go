@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/app"
+	"github.com/SomeBlackMagic/vault-cli-manager/rc"
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	fmt "github.com/jhunt/go-ansi"
+)
+
+func registerRefsCommands(r *app.Runner, opt *Options) {
+	r.Dispatch("refs", &app.Help{
+		Summary: "List the references made by and to secrets",
+		Usage:   "safe refs [-s SCOPE] PATH",
+		Type:    app.NonDestructiveCommand,
+		Description: `
+A value can refer to a key of another secret, rather than holding a copy
+of it, by being set to a reference to that path:key (optionally with a
+^version):
+
+    safe set secret/app/db password='((ref secret/shared/db:password))'
+
+'safe get --resolve', 'safe render' and 'safe exec' all follow references,
+and any references in what they refer to, to the values at the end of them.
+A reference to a key that does not exist is an error, as are references
+that refer back to themselves, or that go more than 16 deep.
+
+'safe refs' lists the references made by secrets beneath PATH (outbound),
+and the references made to secrets beneath PATH (inbound) by secrets beneath
+SCOPE, which is the mount that PATH is in unless -s says otherwise.  Only
+references made by the latest version of each secret are listed.
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) != 1 {
+			r.ExitWithUsage("refs")
+		}
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
+
+		v := app.Connect(true)
+		path := vault.Canonicalize(args[0])
+		scope := opt.Refs.Scope
+		if scope == "" {
+			mount, err := v.Client().MountPath(path)
+			if err != nil {
+				return err
+			}
+			scope = strings.Trim(mount, "/")
+		}
+
+		refs, err := findRefs(v, scope)
+		if err != nil {
+			return err
+		}
+		if !vault.PathIsBeneath(path, scope) {
+			more, err := findRefs(v, path)
+			if err != nil {
+				return err
+			}
+			refs = append(refs, more...)
+		}
+
+		doc := app.OutputRefs{Path: path, Outbound: []app.OutputRef{}, Inbound: []app.OutputRef{}}
+		for _, ref := range refs {
+			if vault.PathIsBeneath(ref.From, path) {
+				doc.Outbound = append(doc.Outbound, app.OutputRef{From: ref.From, To: ref.To})
+			}
+			if vault.PathIsBeneath(ref.To, path) {
+				doc.Inbound = append(doc.Inbound, app.OutputRef{From: ref.From, To: ref.To})
+			}
+		}
+
+		return out.Print(doc, func() error {
+			for _, list := range []struct {
+				title string
+				refs  []app.OutputRef
+			}{{"references from", doc.Outbound}, {"references to", doc.Inbound}} {
+				fmt.Printf("%s @C{%s}:\n", list.title, path)
+				if len(list.refs) == 0 {
+					fmt.Printf("  (none)\n")
+				}
+				for _, ref := range list.refs {
+					fmt.Printf("  @C{%s} -> @G{%s}\n", ref.From, ref.To)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// findRefs returns the references made by every secret beneath prefix.
+func findRefs(v *vault.Vault, prefix string) ([]vault.Ref, error) {
+	secrets, err := v.ConstructSecrets(prefix, vault.TreeOpts{FetchKeys: true})
+	if err != nil && !vault.IsNotFound(err) {
+		return nil, err
+	}
+	return vault.FindRefs(secrets), nil
+}
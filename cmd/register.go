@@ -15,4 +15,10 @@ func RegisterAll(r *app.Runner, opt *Options, version string) {
 	registerX509Commands(r, opt)
 	registerAdminCommands(r, opt)
 	registerSyncCommands(r, opt)
+	registerMetaCommands(r, opt)
+	registerRenderCommands(r, opt)
+	registerExecCommands(r, opt)
+	registerWatchCommands(r, opt)
+	registerRefsCommands(r, opt)
+	registerDuCommands(r, opt)
 }
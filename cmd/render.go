@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/app"
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
+	"github.com/SomeBlackMagic/vault-cli-manager/rc"
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	fmt "github.com/jhunt/go-ansi"
+)
+
+func registerRenderCommands(r *app.Runner, opt *Options) {
+	r.Dispatch("render", &app.Help{
+		Summary: "Render a configuration file template with secrets from Vault",
+		Usage:   "safe render [--check] [-o FILE [-m MODE]] TEMPLATE",
+		Type:    app.NonDestructiveCommand,
+		Description: `
+Renders TEMPLATE (or standard input, if TEMPLATE is '-'), a Go text/template,
+to standard output, or to FILE if -o is given.  Templates can look up secrets
+with these functions:
+
+    secret "path:key"     The value of one key.  Older versions can be
+                          looked up as "path:key^version".
+    secretMap "path"      Every key of a secret, as a map.
+    tree "prefix"         Every secret beneath prefix, as a map of maps,
+                          keyed by path.
+    b64enc VALUE          VALUE, base64-encoded.
+    toJSON VALUE          VALUE, as JSON.
+
+For example:
+
+    url: postgres://{{ secret "secret/db:user" }}:{{ secret "secret/db:password" }}@db
+    {{- range $path, $creds := tree "secret/users" }}
+    {{ $path }}: {{ $creds | toJSON }}
+    {{- end }}
+
+Every secret and tree named in the template is fetched at once, before it
+is rendered.  A secret or key that does not exist is an error.
+
+-o writes FILE in one go, by renaming a temporary file over it, so that it is
+never seen half-written.  It is given -m MODE (0600 by default).
+
+--check renders the template without writing it anywhere, lists every secret
+and key that it refers to that does not exist, and fails if there are any.
+It never prints any secrets.
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) != 1 {
+			r.ExitWithUsage("render")
+		}
+		mode, err := fileMode(opt.Render.Mode)
+		if err != nil {
+			return err
+		}
+		t, err := readTemplate(args[0])
+		if err != nil {
+			return err
+		}
+
+		v := app.Connect(true)
+		if opt.Render.Out != "" && !opt.Render.Check {
+			return renderFile(v, t, opt.Render.Out, mode)
+		}
+		paths, prefixes := t.References()
+		src, err := newSecretSource(v, paths, prefixes)
+		if err != nil {
+			return err
+		}
+
+		if opt.Render.Check {
+			missing, err := t.Check(src)
+			if err != nil {
+				return err
+			}
+			for _, ref := range missing {
+				fmt.Fprintf(os.Stderr, "@R{missing:} @C{%s}\n", ref)
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("%d secrets or keys that %s refers to do not exist", len(missing), args[0])
+			}
+			return nil
+		}
+
+		return t.Render(os.Stdout, src)
+	})
+}
+
+// fileMode parses the octal file mode given to -m.
+func fileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid file mode `%s' (expected octal, like 0644)", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// readTemplate reads and parses the template in file, or on standard input
+// if file is '-'.
+func readTemplate(file string) (*formats.Template, error) {
+	var text []byte
+	var err error
+	if file == "-" {
+		text, err = io.ReadAll(os.Stdin)
+	} else {
+		text, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return formats.ParseTemplate(file, string(text))
+}
+
+// renderFile renders t to out in one go, as render -o does.
+func renderFile(v *vault.Vault, t *formats.Template, out string, mode os.FileMode) error {
+	paths, prefixes := t.References()
+	src, err := newSecretSource(v, paths, prefixes)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err = t.Render(&buf, src); err != nil {
+		return err
+	}
+	return app.WriteFileAtomic(out, buf.Bytes(), mode)
+}
+
+// secretSource looks up the secrets that safe render and safe exec need in
+// Vault, following any references in them.
+type secretSource struct {
+	v *vault.Vault
+	//secrets holds the keys of each secret looked up, or nil for those that
+	// were not there
+	secrets map[string]map[string]string
+	trees   map[string][]formats.Secret
+}
+
+// newSecretSource fetches all the secrets and trees given, all at once.  Any
+// others are fetched when they are first looked up.
+func newSecretSource(v *vault.Vault, paths, prefixes []string) (*secretSource, error) {
+	src := &secretSource{
+		v:       v,
+		secrets: map[string]map[string]string{},
+		trees:   map[string][]formats.Secret{},
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var firstErr error
+	for _, prefix := range prefixes {
+		wg.Add(1)
+		go func(prefix string) {
+			defer wg.Done()
+			tree, err := fetchTree(v, prefix)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			src.trees[prefix] = tree
+		}(prefix)
+	}
+
+	secrets, err := v.ReadAll(paths)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	for _, path := range paths {
+		src.secrets[path] = secretData(secrets[path])
+	}
+	return src, nil
+}
+
+func (src *secretSource) Secret(path string) (map[string]string, error) {
+	data, err := src.raw(path)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return src.resolve(path, data)
+}
+
+func (src *secretSource) Tree(prefix string) ([]formats.Secret, error) {
+	tree, found := src.trees[prefix]
+	if !found {
+		var err error
+		if tree, err = fetchTree(src.v, prefix); err != nil {
+			return nil, err
+		}
+		src.trees[prefix] = tree
+	}
+
+	resolved := make([]formats.Secret, len(tree))
+	for i, s := range tree {
+		data, err := src.resolve(s.Path, s.Data)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = formats.Secret{Path: s.Path, Data: data}
+	}
+	return resolved, nil
+}
+
+// raw looks up the keys of the secret at path as they are in Vault, with any
+// references left as they are.
+func (src *secretSource) raw(path string) (map[string]string, error) {
+	if data, found := src.secrets[path]; found {
+		return data, nil
+	}
+	s, err := src.v.Read(path)
+	if err != nil && !vault.IsNotFound(err) {
+		return nil, err
+	}
+	src.secrets[path] = secretData(s)
+	return src.secrets[path], nil
+}
+
+// resolve returns a copy of data, the keys of the secret at path, with every
+// reference in it followed.
+func (src *secretSource) resolve(path string, data map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(data))
+	for key, value := range data {
+		value, err := vault.ResolveRef(value, src.raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%s: %s", path, key, err)
+		}
+		resolved[key] = value
+	}
+	return resolved, nil
+}
+
+// fetchTree fetches the latest version of every secret beneath prefix.
+func fetchTree(v *vault.Vault, prefix string) ([]formats.Secret, error) {
+	secrets, err := v.ConstructSecrets(prefix, vault.TreeOpts{FetchKeys: true})
+	if err != nil && !vault.IsNotFound(err) {
+		return nil, err
+	}
+	return formats.Latest(secrets), nil
+}
+
+// secretData returns the keys of s, or nil if there is no s.
+func secretData(s *vault.Secret) map[string]string {
+	if s == nil {
+		return nil
+	}
+	data := make(map[string]string, len(s.Keys()))
+	for _, key := range s.Keys() {
+		data[key] = s.Get(key)
+	}
+	return data
+}
@@ -2,24 +2,33 @@ package cmd
 
 import (
 	"errors"
+	"io"
 	"os"
+	"os/exec"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/SomeBlackMagic/vault-cli-manager/app"
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
 	fmt "github.com/jhunt/go-ansi"
 	"github.com/SomeBlackMagic/vault-cli-manager/rc"
 	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	"github.com/SomeBlackMagic/vault-cli-manager/vaultsync"
 	"gopkg.in/yaml.v2"
 )
 
 func registerSecretCommands(r *app.Runner, opt *Options) {
-	writeHelper := func(prompt bool, insecure bool, command string, args ...string) error {
+	writeHelper := func(prompt bool, insecure bool, command string, cas string, patch bool, args ...string) error {
 		rc.Apply(opt.UseTarget)
 		if len(args) < 2 {
 			r.ExitWithUsage(command)
 		}
+		wopts, err := writeOpts(cas)
+		if err != nil {
+			return err
+		}
 		v := app.Connect(true)
 		path, args := args[0], args[1:]
 		s, err := v.Read(path)
@@ -28,6 +37,7 @@ func registerSecretCommands(r *app.Runner, opt *Options) {
 		}
 		exists := (err == nil)
 		clobberKeys := []string{}
+		keys, values := []string{}, map[string]string{}
 		for _, arg := range args {
 			k, val, missing, err := app.ParseKeyVal(arg, opt.Quiet)
 			if err != nil {
@@ -47,19 +57,76 @@ func registerSecretCommands(r *app.Runner, opt *Options) {
 			if err != nil {
 				return err
 			}
-			err = s.Set(k, val, opt.SkipIfExists)
-			if err != nil {
-				return err
+			if _, found := values[k]; !found {
+				keys = append(keys, k)
+			}
+			values[k] = val
+		}
+		if len(clobberKeys) > 0 {
+			if !opt.Quiet {
+				fmt.Fprintf(os.Stderr, "@R{Cowardly refusing to update} @C{%s}@R{, as the following keys would be clobbered:} @C{%s}\n",
+					path, strings.Join(clobberKeys, ", "))
+			}
+			return nil
+		}
+		return writeChanges(v, path, s, func(s *vault.Secret) error {
+			for _, k := range keys {
+				if err := s.Set(k, values[k], opt.SkipIfExists); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, patch, wopts)
+	}
+
+	dotenvHelper := func(path, file string) error {
+		rc.Apply(opt.UseTarget)
+		wopts, err := writeOpts(opt.Set.CAS)
+		if err != nil {
+			return err
+		}
+		var b []byte
+		if file == "-" {
+			b, err = io.ReadAll(os.Stdin)
+		} else {
+			b, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return err
+		}
+		vars, err := formats.UnmarshalDotenv(b)
+		if err != nil {
+			return fmt.Errorf("Could not parse `%s': %s", file, err)
+		}
+
+		v := app.Connect(true)
+		s, err := v.Read(path)
+		if err != nil && !vault.IsNotFound(err) {
+			return err
+		}
+		exists := (err == nil)
+		clobberKeys := []string{}
+		for k := range vars {
+			if opt.SkipIfExists && exists && s.Has(k) {
+				clobberKeys = append(clobberKeys, k)
 			}
 		}
 		if len(clobberKeys) > 0 {
+			sort.Strings(clobberKeys)
 			if !opt.Quiet {
 				fmt.Fprintf(os.Stderr, "@R{Cowardly refusing to update} @C{%s}@R{, as the following keys would be clobbered:} @C{%s}\n",
 					path, strings.Join(clobberKeys, ", "))
 			}
 			return nil
 		}
-		return v.Write(path, s)
+		return writeChanges(v, path, s, func(s *vault.Secret) error {
+			for k, val := range vars {
+				if err := s.Set(k, val, opt.SkipIfExists); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, opt.Set.Patch, wopts)
 	}
 
 	r.Dispatch("ask", &app.Help{
@@ -76,12 +143,12 @@ are omitted. Unlike the 'safe set' and 'safe paste' commands, data entry
 is NOT obscured.
 `,
 	}, func(command string, args ...string) error {
-		return writeHelper(false, false, "ask", args...)
+		return writeHelper(false, false, "ask", "", false, args...)
 	})
 
 	r.Dispatch("set", &app.Help{
 		Summary: "Create or update a secret",
-		Usage:   "safe set PATH NAME=[VALUE] [NAME ...]",
+		Usage:   "safe set [--cas VERSION] [--patch] PATH NAME=[VALUE] [NAME ...]\n       safe set [--cas VERSION] [--patch] PATH --from-dotenv FILE",
 		Type:    app.DestructiveCommand,
 		Description: `
 Update a single path in the Vault with new or updated named attributes.
@@ -108,14 +175,44 @@ the '@' notation:
 
 This causes safe to read the file 'path/to/file', relative to the current
 working directory, and insert the contents into the Vault.
+
+To load every variable of a .env file as a key of the secret, use:
+
+    safe set secret/path --from-dotenv path/to/.env
+
+Variable names are used as-is for the key names.  Comments, 'export'
+prefixes and single- or double-quoted (possibly multi-line) values are
+understood.  Use '-' as the FILE to read from standard input.
+
+safe reads the secret, updates it, and writes the whole thing back, so if
+someone else changes another key of it at the same time, one of the two
+changes can be lost.  To guard against that:
+
+    safe set --patch secret/path key=value
+
+only sends the keys being set.  On KV v2 mounts of Vault 1.9 or newer, they
+are sent as a JSON merge patch; otherwise safe writes the secret back only if
+nobody else wrote it since it was read, and tries again if they did.  KV v1
+has no way to do either.
+
+    safe set --cas 3 secret/path key=value
+
+only writes the secret if it is still at version 3 (or, with --cas 0, if it
+does not exist yet), and fails otherwise.  This needs KV v2.
 `,
 	}, func(command string, args ...string) error {
-		return writeHelper(true, true, "set", args...)
+		if opt.Set.FromDotenv != "" {
+			if len(args) != 1 {
+				r.ExitWithUsage("set")
+			}
+			return dotenvHelper(args[0], opt.Set.FromDotenv)
+		}
+		return writeHelper(true, true, "set", opt.Set.CAS, opt.Set.Patch, args...)
 	})
 
 	r.Dispatch("paste", &app.Help{
 		Summary: "Create or update a secret",
-		Usage:   "safe paste PATH NAME=[VALUE] [NAME ...]",
+		Usage:   "safe paste [--cas VERSION] [--patch] PATH NAME=[VALUE] [NAME ...]",
 		Type:    app.DestructiveCommand,
 		Description: `
 Works just like 'safe set', updating a single path in the Vault with new or
@@ -126,10 +223,48 @@ You will be prompted to provide any values that are omitted, but unlike the
 'safe set' command, you will not be asked to confirm those values.  This makes
 sense when you are pasting in credentials from an external password manager
 like 1password or Lastpass.
+
+--cas and --patch work just like they do for 'safe set'.
 `,
 	}, func(command string, args ...string) error {
 		//Dispatch call.
-		return writeHelper(false, true, "paste", args...)
+		return writeHelper(false, true, "paste", opt.Paste.CAS, opt.Paste.Patch, args...)
+	})
+
+	r.Dispatch("edit", &app.Help{
+		Summary: "Edit a secret in your editor",
+		Usage:   "safe edit PATH",
+		Type:    app.DestructiveCommand,
+		Description: `
+Opens the keys of the secret at PATH in your editor ($VISUAL or $EDITOR,
+or vi if neither is set), as YAML, so that several keys can be changed at
+once, and multi-line values edited in place.  If there is no secret at
+PATH, you start from nothing, and it is created.
+
+When you save and quit, safe checks what you saved, shows which keys you
+added, removed or changed, and writes it back as a new version.  If it is
+not valid, you are sent back into the editor to fix it.  Save a file with no
+keys in it, or do not change anything, to leave the secret alone.
+
+If someone else changes the secret while you are editing it, safe will not
+overwrite their changes.  It sends you back into the editor, with what is in
+Vault now shown beneath your changes, to merge the two.  This relies on the
+check-and-set support of KV v2; for KV v1 secrets, safe instead compares
+what is there just before writing.
+
+The file being edited is only readable by you, and is overwritten before it
+is removed.
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) != 1 {
+			r.ExitWithUsage("edit")
+		}
+		path := args[0]
+		if vault.PathHasKey(path) || vault.PathHasVersion(path) {
+			return fmt.Errorf("Cannot edit `%s'; edit the whole secret, at its latest version", path)
+		}
+		return editSecret(app.Connect(true), vault.Canonicalize(path))
 	})
 
 	r.Dispatch("exists", &app.Help{
@@ -167,7 +302,7 @@ certificate validation failure, etc. occur, they will be printed as well.
 
 	r.Dispatch("get", &app.Help{
 		Summary: "Retrieve the key/value pairs (or just keys) of one or more paths",
-		Usage:   "safe get [--keys] [--yaml] PATH [PATH ...]",
+		Usage:   "safe get [--keys] [--yaml] [--resolve] [--format dotenv [-R]] PATH [PATH ...]",
 		Description: `
 Allows you to retrieve one or more values stored in the given secret, or just the
 valid keys.  It operates in the following modes:
@@ -199,6 +334,19 @@ If an invalid key or path is requested, an error will be output and nothing else
 unless the --keys option is specified.  In that case, the error will be displayed
 as a warning, but the output will be provided with an empty array for missing
 paths/keys.
+
+With --format dotenv, the requested keys are printed as a .env file instead,
+one NAME=value line per key, quoted as needed.  Variable names are the key
+names, upper-cased, with anything other than letters, digits and underscores
+replaced by an underscore.  Given -R, each PATH is treated as a subtree and
+every secret beneath it is included, with the variable names prefixed by the
+path of the secret relative to PATH; secret/app/db:password, fetched with
+'safe get --format dotenv -R secret/app', becomes DB_PASSWORD.  It is an
+error for two keys to map to the same variable name.
+
+Values can refer to a key of another secret, as ((ref secret/shared/db:password))
+(see 'safe help refs').  They are printed as they are, unless --resolve is given,
+in which case the value that they refer to is printed instead.
 `,
 		Type: app.NonDestructiveCommand,
 	}, func(command string, args ...string) error {
@@ -207,11 +355,44 @@ paths/keys.
 			r.ExitWithUsage("get")
 		}
 
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
+		if out.Structured() && opt.Get.Recurse {
+			return fmt.Errorf("-R is only supported with --format dotenv, not --output %s", out.Format)
+		}
 		v := app.Connect(true)
+		args, _, err = expandGlobs(v, args, opt.Get.Recurse)
+		if err != nil {
+			return err
+		}
+		var lookup vault.RefLookup
+		if opt.Get.Resolve {
+			lookup = v.RefLookup()
+		}
+
+		if out.Structured() {
+			if opt.Get.Format != "" || opt.Get.Yaml {
+				return fmt.Errorf("Cannot specify --format or --yaml with --output %s", out.Format)
+			}
+			return getOutput(v, lookup, out, args, opt.Get.KeysOnly)
+		}
+
+		switch opt.Get.Format {
+		case "":
+			if opt.Get.Recurse {
+				return fmt.Errorf("-R is only supported with --format dotenv")
+			}
+		case "dotenv":
+			return getDotenv(v, lookup, args, opt.Get.Recurse)
+		default:
+			return fmt.Errorf("Unsupported format `%s'; only `dotenv' is supported", opt.Get.Format)
+		}
 
 		// Recessive case of one path
 		if len(args) == 1 && !opt.Get.Yaml {
-			s, err := v.Read(args[0])
+			s, err := readResolved(v, lookup, args[0])
 			if err != nil {
 				return err
 			}
@@ -239,7 +420,7 @@ paths/keys.
 		missingKeys := make(map[string][]string)
 		for _, path := range args {
 			p, k, _ := vault.ParsePath(path)
-			s, err := v.Read(path)
+			s, err := readResolved(v, lookup, path)
 
 			// Check if the desired path[:key] is found
 			if err != nil {
@@ -262,7 +443,6 @@ paths/keys.
 		}
 
 		// Handle any errors encountered.  Warn for key request, return error otherwise
-		var err error
 		numErrs := len(errs)
 		if numErrs == 1 {
 			err = errs[0]
@@ -313,3 +493,328 @@ paths/keys.
 		return nil
 	})
 }
+
+// readResolved reads the secret at path, and follows any references in it if
+// there is a lookup to follow them with.
+func readResolved(v *vault.Vault, lookup vault.RefLookup, path string) (*vault.Secret, error) {
+	s, err := v.Read(path)
+	if err != nil || lookup == nil {
+		return s, err
+	}
+	p, _, _ := vault.ParsePath(path)
+	return s, vault.ResolveRefs(p, s, lookup)
+}
+
+// getOutput prints the secrets at the given paths as a list, with the keys
+// asked for of each and their values (or just the keys, if keysOnly is set).
+// As with text output, secrets and keys that are not there are only warned
+// about when just the keys were asked for.
+func getOutput(v *vault.Vault, lookup vault.RefLookup, out *app.Output, paths []string, keysOnly bool) error {
+	secrets := []app.OutputSecret{}
+	index := map[string]int{}
+	for _, path := range paths {
+		p, _, version := vault.ParsePath(path)
+		p = vault.EncodePath(p, "", version)
+		i, found := index[p]
+		if !found {
+			i = len(secrets)
+			index[p] = i
+			secrets = append(secrets, app.OutputSecret{Path: p, Data: map[string]string{}})
+		}
+
+		s, err := readResolved(v, lookup, path)
+		if err != nil {
+			if !keysOnly {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "@y{WARNING:} %s\n", err)
+			continue
+		}
+		for _, key := range s.Keys() {
+			secrets[i].Data[key] = s.Get(key)
+		}
+	}
+
+	if keysOnly {
+		for i := range secrets {
+			for key := range secrets[i].Data {
+				secrets[i].Keys = append(secrets[i].Keys, key)
+			}
+			sort.Strings(secrets[i].Keys)
+			secrets[i].Data = nil
+		}
+	}
+	return out.Print(secrets, nil)
+}
+
+// getDotenv prints the secrets at the given paths (or beneath them, if
+// recurse is set) as a single .env file
+func getDotenv(v *vault.Vault, lookup vault.RefLookup, paths []string, recurse bool) error {
+	vars := map[string]string{}
+	origins := map[string]string{}
+	add := func(more map[string]string, origin string) error {
+		for name, value := range more {
+			if other, found := origins[name]; found {
+				return fmt.Errorf("Both `%s' and `%s' map to the variable %s", other, origin, name)
+			}
+			origins[name] = origin
+			vars[name] = value
+		}
+		return nil
+	}
+
+	for _, path := range paths {
+		if recurse {
+			if vault.PathHasKey(path) {
+				return fmt.Errorf("Cannot recurse into `%s'; it names a key", path)
+			}
+			secrets, err := v.ConstructSecrets(path, vault.TreeOpts{FetchKeys: true})
+			if err != nil {
+				return err
+			}
+			latest := formats.Latest(secrets)
+			if lookup != nil {
+				for _, secret := range latest {
+					for key, value := range secret.Data {
+						if secret.Data[key], err = vault.ResolveRef(value, lookup); err != nil {
+							return fmt.Errorf("%s:%s: %s", secret.Path, key, err)
+						}
+					}
+				}
+			}
+			more, err := formats.DotenvVars(latest, path)
+			if err != nil {
+				return err
+			}
+			if err = add(more, path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, err := readResolved(v, lookup, path)
+		if err != nil {
+			return err
+		}
+		p, _, _ := vault.ParsePath(path)
+		data := map[string]string{}
+		for _, key := range s.Keys() {
+			data[key] = s.Get(key)
+		}
+		more, err := formats.DotenvVars([]formats.Secret{{Path: p, Data: data}}, p)
+		if err != nil {
+			return err
+		}
+		if err = add(more, path); err != nil {
+			return err
+		}
+	}
+
+	_, err := os.Stdout.Write(formats.MarshalDotenv(vars))
+	return err
+}
+
+// editSecret runs an editor on the secret at path until what is saved is
+// valid and can be written without overwriting changes made by anyone else.
+func editSecret(v *vault.Vault, path string) error {
+	mountVersion, err := v.MountVersion(path)
+	if err != nil {
+		return err
+	}
+	base, version, err := readForEdit(v, path, mountVersion)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "safe-edit-*.yml")
+	if err != nil {
+		return fmt.Errorf("Could not create a file to edit the secret in: %s", err)
+	}
+	rc.AddCleanup(f.Name())
+	defer rc.Shred(f.Name())
+	f.Close()
+
+	header := fmt.Sprintf("Editing %s", path)
+	if version != 0 {
+		header += fmt.Sprintf(" (version %d)", version)
+	}
+	header += ".\nSave without any keys, or without changes, to leave it alone.\n"
+
+	body, err := formats.MarshalEditable(base)
+	if err != nil {
+		return err
+	}
+	content := append(formats.Comment(header), body...)
+
+	for {
+		if err := os.WriteFile(f.Name(), content, 0600); err != nil {
+			return err
+		}
+		if err := runEditor(f.Name()); err != nil {
+			return err
+		}
+		saved, err := os.ReadFile(f.Name())
+		if err != nil {
+			return err
+		}
+
+		edited, err := formats.UnmarshalEditable(saved)
+		if err != nil {
+			content = append(formats.Comment(header+"\n"+"!! "+err.Error()+"\n"), formats.Uncommented(saved)...)
+			continue
+		}
+		if len(edited) == 0 || reflect.DeepEqual(edited, base) {
+			fmt.Fprintf(os.Stderr, "@Y{No changes made to} @C{%s}\n", path)
+			return nil
+		}
+
+		change := vaultsync.Change{
+			Type:       vaultsync.ChangeModify,
+			Path:       path,
+			LocalData:  vaultsync.ExpandMap(edited),
+			RemoteData: vaultsync.ExpandMap(base),
+		}
+		if len(base) == 0 {
+			change.Type = vaultsync.ChangeAdd
+		}
+		fmt.Printf("%s", vaultsync.FormatDiffRedacted(change, fmt.ShouldColorize(os.Stdout)))
+
+		s := vault.NewSecret()
+		for key, value := range edited {
+			s.Set(key, value, false)
+		}
+
+		written, err := writeForEdit(v, path, mountVersion, s, base, version)
+		if err == nil {
+			if written != 0 {
+				fmt.Fprintf(os.Stderr, "@G{Wrote} @C{%s} @G{as version %d}\n", path, written)
+			} else {
+				fmt.Fprintf(os.Stderr, "@G{Wrote} @C{%s}\n", path)
+			}
+			return nil
+		}
+		if !vault.IsCASMismatch(err) {
+			return err
+		}
+
+		base, version, err = readForEdit(v, path, mountVersion)
+		if err != nil {
+			return err
+		}
+		theirs, err := formats.MarshalEditable(base)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "@Y{%s changed while you were editing it; merge your changes with theirs}\n", path)
+
+		header = fmt.Sprintf("Editing %s, which someone else changed while you were editing it", path)
+		if version != 0 {
+			header += fmt.Sprintf(" (it is now at version %d)", version)
+		}
+		header += ".\nYour changes are below, with what is in Vault now at the bottom.\n" +
+			"Merge them, and save to write the result; save without any keys to give up.\n"
+		body, err = formats.MarshalEditable(edited)
+		if err != nil {
+			return err
+		}
+		content = append(formats.Comment(header), body...)
+		content = append(content, '\n')
+		content = append(content, formats.Comment("In Vault now:\n\n"+string(theirs))...)
+	}
+}
+
+// readForEdit reads the keys of the secret at path, and the version to check
+// and set when writing them back, which is 0 for KV v1 or a secret that was
+// never written. A secret that does not exist has no keys.
+func readForEdit(v *vault.Vault, path string, mountVersion uint) (map[string]string, uint, error) {
+	s, version, err := v.ReadVersion(path)
+	if vault.IsSecretNotFound(err) {
+		version = 0
+		if mountVersion == 2 {
+			//The latest version may have been deleted, but it still counts
+			versions, err := v.Versions(path)
+			if err != nil && !vault.IsNotFound(err) {
+				return nil, 0, err
+			}
+			if len(versions) > 0 {
+				version = versions[len(versions)-1].Version
+			}
+		}
+		return map[string]string{}, version, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data := map[string]string{}
+	for _, key := range s.Keys() {
+		data[key] = s.Get(key)
+	}
+	return data, version, nil
+}
+
+// writeForEdit writes an edited secret back, unless it was changed since its
+// keys were read as base at the given version. It returns the version that
+// it wrote, or 0 for KV v1.
+func writeForEdit(v *vault.Vault, path string, mountVersion uint, s *vault.Secret, base map[string]string, version uint) (uint, error) {
+	if mountVersion == 2 {
+		return v.WriteCAS(path, s, version)
+	}
+
+	now, _, err := readForEdit(v, path, mountVersion)
+	if err != nil {
+		return 0, err
+	}
+	if !reflect.DeepEqual(now, base) {
+		return 0, vault.NewEditConflictError(path)
+	}
+	return 0, v.Write(path, s)
+}
+
+// runEditor runs the editor of the user on the given file, and waits for them
+// to be done with it.
+func runEditor(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	//Run through the shell, since editors are often set with arguments
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Editor `%s' failed: %s", editor, err)
+	}
+	return nil
+}
+
+// writeOpts parses the --cas option of a command that writes secrets.
+func writeOpts(cas string) (vault.WriteOpts, error) {
+	if cas == "" {
+		return vault.WriteOpts{}, nil
+	}
+	version, err := strconv.ParseUint(cas, 10, 0)
+	if err != nil {
+		return vault.WriteOpts{}, fmt.Errorf("Invalid --cas version `%s'; expected a version number, or 0 for a secret that must not exist yet", cas)
+	}
+	v := uint(version)
+	return vault.WriteOpts{CAS: &v}, nil
+}
+
+// writeChanges writes the changes that update makes to the secret at path,
+// which was read as s: as a patch of just the keys it changes, if patch is
+// set, or else by writing s back in full.
+func writeChanges(v *vault.Vault, path string, s *vault.Secret, update func(*vault.Secret) error, patch bool, opts vault.WriteOpts) error {
+	if patch {
+		return v.Patch(path, update, opts)
+	}
+	if err := update(s); err != nil {
+		return err
+	}
+	return v.Write(path, s, opts)
+}
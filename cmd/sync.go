@@ -3,6 +3,7 @@ package cmd
 import (
 	"github.com/SomeBlackMagic/vault-cli-manager/app"
 	"github.com/SomeBlackMagic/vault-cli-manager/rc"
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
 	"github.com/SomeBlackMagic/vault-cli-manager/vaultsync"
 )
 
@@ -61,7 +62,7 @@ Conflict handling:
 			r.ExitWithUsage("sync pull")
 		}
 		v := app.Connect(true)
-		return vaultsync.Pull(v, args[0], args[1])
+		return vaultsync.Pull(syncVault{v}, args[0], args[1])
 	})
 
 	r.Dispatch("sync plan", &app.Help{
@@ -90,7 +91,7 @@ objects display granular field changes instead of the full blob.
 			r.ExitWithUsage("sync plan")
 		}
 		v := app.Connect(true)
-		_, err := vaultsync.Plan(v, args[0], args[1])
+		_, err := vaultsync.Plan(syncVault{v}, args[0], args[1])
 		return err
 	})
 
@@ -116,7 +117,17 @@ strings before writing, so Vault always receives flat key-value pairs.
 			r.ExitWithUsage("sync apply")
 		}
 		v := app.Connect(true)
-		return vaultsync.Apply(v, args[0], args[1])
+		return vaultsync.Apply(syncVault{v}, args[0], args[1])
 	})
 
 }
+
+// syncVault walks trees for vaultsync with a progress indicator, stopping
+// cleanly if interrupted.
+type syncVault struct {
+	*vault.Vault
+}
+
+func (v syncVault) ConstructSecrets(path string, opts vault.TreeOpts) (vault.Secrets, error) {
+	return app.ConstructSecrets(v.Vault, path, opts)
+}
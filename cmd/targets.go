@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"encoding/pem"
 	"crypto/x509"
 
@@ -32,67 +31,60 @@ func registerTargetCommands(r *app.Runner, opt *Options) {
 		}
 
 		cfg := rc.Apply(opt.UseTarget)
-		if opt.Targets.JSON {
-			type vault struct {
-				Name      string `json:"name"`
-				URL       string `json:"url"`
-				Verify    bool   `json:"verify"`
-				Namespace string `json:"namespace,omitempty"`
-				Strongbox bool   `json:"strongbox"`
-			}
-			vaults := make([]vault, 0)
-
-			for name, details := range cfg.Vaults {
-				vaults = append(vaults, vault{
-					Name:      name,
-					URL:       details.URL,
-					Verify:    !details.SkipVerify,
-					Namespace: details.Namespace,
-					Strongbox: !details.NoStrongbox,
-				})
-			}
-			b, err := json.MarshalIndent(vaults, "", "  ")
-			if err != nil {
-				return err
-			}
-			fmt.Printf("%s\n", string(b))
-			return nil
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
 		}
-
-		wide := 0
-		keys := make([]string, 0)
-		for name := range cfg.Vaults {
-			keys = append(keys, name)
-			if len(name) > wide {
-				wide = len(name)
-			}
+		if opt.Targets.JSON {
+			out.Format = app.JSONOutput
 		}
-
-		currentFmt := fmt.Sprintf("(*) @G{%%-%ds}\t@R{%%s} @Y{%%s}\n", wide)
-		otherFmt := fmt.Sprintf("    %%-%ds\t@R{%%s} %%s\n", wide)
-		hasCurrent := ""
-		if cfg.Current != "" {
-			hasCurrent = " - current target indicated with a (*)"
+		vaults := make([]app.OutputTarget, 0)
+		for name, details := range cfg.Vaults {
+			vaults = append(vaults, app.OutputTarget{
+				Name:      name,
+				URL:       details.URL,
+				Verify:    !details.SkipVerify,
+				Namespace: details.Namespace,
+				Strongbox: !details.NoStrongbox,
+			})
 		}
+		sort.Slice(vaults, func(i, j int) bool { return vaults[i].Name < vaults[j].Name })
+		return out.Print(vaults, func() error {
+			wide := 0
+			keys := make([]string, 0)
+			for name := range cfg.Vaults {
+				keys = append(keys, name)
+				if len(name) > wide {
+					wide = len(name)
+				}
+			}
 
-		fmt.Fprintf(os.Stderr, "\nKnown Vault targets%s:\n", hasCurrent)
-		sort.Strings(keys)
-		for _, name := range keys {
-			t := cfg.Vaults[name]
-			skip := "           "
-			if t.SkipVerify {
-				skip = " (noverify)"
-			} else if strings.HasPrefix(t.URL, "http:") {
-				skip = " (insecure)"
+			currentFmt := fmt.Sprintf("(*) @G{%%-%ds}\t@R{%%s} @Y{%%s}\n", wide)
+			otherFmt := fmt.Sprintf("    %%-%ds\t@R{%%s} %%s\n", wide)
+			hasCurrent := ""
+			if cfg.Current != "" {
+				hasCurrent = " - current target indicated with a (*)"
 			}
-			format := otherFmt
-			if name == cfg.Current {
-				format = currentFmt
+
+			fmt.Fprintf(os.Stderr, "\nKnown Vault targets%s:\n", hasCurrent)
+			sort.Strings(keys)
+			for _, name := range keys {
+				t := cfg.Vaults[name]
+				skip := "           "
+				if t.SkipVerify {
+					skip = " (noverify)"
+				} else if strings.HasPrefix(t.URL, "http:") {
+					skip = " (insecure)"
+				}
+				format := otherFmt
+				if name == cfg.Current {
+					format = currentFmt
+				}
+				fmt.Fprintf(os.Stderr, format, name, skip, t.URL)
 			}
-			fmt.Fprintf(os.Stderr, format, name, skip, t.URL)
-		}
-		fmt.Fprintf(os.Stderr, "\n")
-		return nil
+			fmt.Fprintf(os.Stderr, "\n")
+			return nil
+		})
 	})
 
 	r.Dispatch("target", &app.Help{
@@ -192,32 +184,31 @@ provided multiple times to provide multiple CA certificates.
 		}
 		if len(args) == 0 {
 			if !opt.Quiet {
+				out, err := app.NewOutput(opt.Output)
+				if err != nil {
+					return err
+				}
 				if opt.Target.JSON {
-					var out struct {
-						Name      string `json:"name"`
-						URL       string `json:"url"`
-						Verify    bool   `json:"verify"`
-						Strongbox bool   `json:"strongbox"`
-					}
-					if cfg.Current != "" {
-						out.Name = cfg.Current
-						out.URL = cfg.URL()
-						out.Verify = cfg.Verified()
-						out.Strongbox = cfg.HasStrongbox()
+					out.Format = app.JSONOutput
+				}
+				var current app.OutputTarget
+				if cfg.Current != "" {
+					current = app.OutputTarget{
+						Name:      cfg.Current,
+						URL:       cfg.URL(),
+						Verify:    cfg.Verified(),
+						Namespace: cfg.Namespace(),
+						Strongbox: cfg.HasStrongbox(),
 					}
-					b, err := json.MarshalIndent(&out, "", "  ")
-					if err != nil {
-						return err
+				}
+				return out.Print(current, func() error {
+					if cfg.Current == "" {
+						fmt.Fprintf(os.Stderr, "@R{No Vault currently targeted}\n")
+					} else {
+						printTarget()
 					}
-					fmt.Printf("%s\n", string(b))
 					return nil
-				}
-
-				if cfg.Current == "" {
-					fmt.Fprintf(os.Stderr, "@R{No Vault currently targeted}\n")
-				} else {
-					printTarget()
-				}
+				})
 			}
 			return nil
 		}
@@ -315,10 +306,11 @@ Print the environment variables representing the current target.
 
  --fish   Format the environment variables to be used by fish.
 
- --json   Format the environment variables in json format.
+ --json   Format the environment variables in json format (the same as
+          --output json).
 
-Please note that if you specify --json, --bash or --fish then the output will be
-written to STDOUT instead of STDERR to make it easier to consume.
+Please note that if you specify --json (or --output), --bash or --fish then the
+output will be written to STDOUT instead of STDERR to make it easier to consume.
 		`,
 		Type: app.AdministrativeCommand,
 	}, func(command string, args ...string) error {
@@ -335,7 +327,22 @@ written to STDOUT instead of STDERR to make it easier to consume.
 			"VAULT_NAMESPACE":   os.Getenv("VAULT_NAMESPACE"),
 		}
 
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
+		if opt.Env.ForJSON {
+			out.Format = app.JSONOutput
+		}
+
 		switch {
+		case out.Structured():
+			return out.Print(app.OutputEnv{
+				Addr:      vars["VAULT_ADDR"],
+				Token:     vars["VAULT_TOKEN"],
+				Skip:      vars["VAULT_SKIP_VERIFY"],
+				Namespace: vars["VAULT_NAMESPACE"],
+			}, nil)
 		case opt.Env.ForBash:
 			for name, value := range vars {
 				if value != "" {
@@ -352,25 +359,6 @@ written to STDOUT instead of STDERR to make it easier to consume.
 					fmt.Fprintf(os.Stdout, "set -x %s %s;\n", name, value)
 				}
 			}
-		case opt.Env.ForJSON:
-			jsonEnv := &struct {
-				Addr  string `json:"VAULT_ADDR"`
-				Token string `json:"VAULT_TOKEN,omitempty"`
-				Skip  string `json:"VAULT_SKIP_VERIFY,omitempty"`
-				NS    string `json:"VAULT_NAMESPACE,omitempty"`
-			}{
-				Addr:  vars["VAULT_ADDR"],
-				Token: vars["VAULT_TOKEN"],
-				Skip:  vars["VAULT_SKIP_VERIFY"],
-				NS:    vars["VAULT_NAMESPACE"],
-			}
-			b, err := json.Marshal(jsonEnv)
-			if err != nil {
-				return err
-			}
-			fmt.Printf("%s\n", string(b))
-			return nil
-
 		default:
 			for name, value := range vars {
 				if value != "" {
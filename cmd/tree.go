@@ -4,6 +4,7 @@ import (
 	"os"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	fmt "github.com/jhunt/go-ansi"
 	"github.com/SomeBlackMagic/vault-cli-manager/rc"
 	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	"github.com/SomeBlackMagic/vault-cli-manager/vaultsync"
 )
 
 func registerTreeCommands(r *app.Runner, opt *Options) {
@@ -21,12 +23,21 @@ func registerTreeCommands(r *app.Runner, opt *Options) {
 		Type:    app.NonDestructiveCommand,
 	}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
 		v := app.Connect(true)
 
 		if len(args) == 0 {
 			return fmt.Errorf("No paths given")
 		}
+		args, _, err = expandGlobs(v, args, false)
+		if err != nil {
+			return err
+		}
 
+		secrets := make([]app.OutputSecret, 0, len(args))
 		for i := range args {
 			_, _, version := vault.ParsePath(args[i])
 			if version > 0 {
@@ -40,75 +51,92 @@ func registerTreeCommands(r *app.Runner, opt *Options) {
 				return err
 			}
 
-			if len(args) > 1 {
-				fmt.Printf("@B{%s}:\n", args[i])
+			s := app.OutputSecret{Path: args[i]}
+			for _, version := range versions {
+				//Destroyed needs to be first because things can come back as both deleted _and_ destroyed.
+				// destroyed is objectively more interesting.
+				state := vault.SecretStateAlive
+				if version.Destroyed {
+					state = vault.SecretStateDestroyed
+				} else if version.Deleted {
+					state = vault.SecretStateDeleted
+				}
+				s.Versions = append(s.Versions, app.NewOutputVersion(version.Version, state, version.CreatedAt, time.Time{}))
 			}
+			secrets = append(secrets, s)
+		}
 
-			tbl := app.Table{}
+		return out.Print(secrets, func() error {
+			for i, s := range secrets {
+				if len(secrets) > 1 {
+					fmt.Printf("@B{%s}:\n", s.Path)
+				}
 
-			tbl.SetHeader("version", "status", "created at")
+				tbl := app.Table{}
 
-			for j := range versions {
-				//Destroyed needs to be first because things can come back as both deleted _and_ destroyed.
-				// destroyed is objectively more interesting.
-				statusString := "@G{alive}"
-				if versions[j].Destroyed {
-					statusString = "@R{destroyed}"
-				} else if versions[j].Deleted {
-					statusString = "@Y{deleted}"
-				}
+				tbl.SetHeader("version", "status", "created at")
 
-				createdAtString := "unknown"
+				for _, version := range s.Versions {
+					statusString := map[string]string{
+						"alive":     "@G{alive}",
+						"deleted":   "@Y{deleted}",
+						"destroyed": "@R{destroyed}",
+					}[version.State]
 
-				if !versions[j].CreatedAt.IsZero() {
-					createdAtString = versions[j].CreatedAt.Local().Format(time.RFC822)
-				}
+					createdAtString := "unknown"
 
-				tbl.AddRow(
-					fmt.Sprintf("%d", versions[j].Version),
-					fmt.Sprintf(statusString),
-					createdAtString,
-				)
-			}
+					if version.CreatedAt != nil {
+						createdAtString = version.CreatedAt.Local().Format(time.RFC822)
+					}
 
-			tbl.Print()
+					tbl.AddRow(
+						fmt.Sprintf("%d", version.Version),
+						fmt.Sprintf(statusString),
+						createdAtString,
+					)
+				}
 
-			if len(args) > 1 && i != len(args)-1 {
-				fmt.Printf("\n")
-			}
-		}
+				tbl.Print()
 
-		return nil
+				if len(secrets) > 1 && i != len(secrets)-1 {
+					fmt.Printf("\n")
+				}
+			}
+			return nil
+		})
 	})
 
 	r.Dispatch("ls", &app.Help{
 		Summary: "Print the keys and sub-directories at one or more paths",
-		Usage:   "safe ls [-1|-q] [PATH ...]",
+		Usage:   "safe ls [-1|-q|-l] [PATH ...]",
 		Type:    app.NonDestructiveCommand,
 		Description: `
 	Specifying the -1 flag will print one result per line.
 	Specifying the -q flag will show secrets which have been marked as deleted.
+	Specifying the -l flag will print a long listing of each secret (see
+	'safe help tree').
 `,
 	}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
 		v := app.Connect(true)
-		display := func(paths []string) {
-			if opt.List.Single {
-				for _, s := range paths {
-					if strings.HasSuffix(s, "/") {
-						fmt.Printf("@B{%s}\n", s)
-					} else {
-						fmt.Printf("@G{%s}\n", s)
-					}
+		display := func(entries []app.OutputListedEntry) {
+			for _, e := range entries {
+				if e.Type == "folder" {
+					fmt.Printf("@B{%s/}", e.Name)
+				} else {
+					fmt.Printf("@G{%s}", e.Name)
 				}
-			} else {
-				for _, s := range paths {
-					if strings.HasSuffix(s, "/") {
-						fmt.Printf("@B{%s}  ", s)
-					} else {
-						fmt.Printf("@G{%s}  ", s)
-					}
+				if opt.List.Single {
+					fmt.Printf("\n")
+				} else {
+					fmt.Printf("  ")
 				}
+			}
+			if !opt.List.Single {
 				fmt.Printf("\n")
 			}
 		}
@@ -117,6 +145,7 @@ func registerTreeCommands(r *app.Runner, opt *Options) {
 			args = []string{"/"}
 		}
 
+		listings := make([]app.OutputListing, 0, len(args))
 		for _, path := range args {
 			var paths []string
 			if path == "" || path == "/" {
@@ -139,7 +168,10 @@ func registerTreeCommands(r *app.Runner, opt *Options) {
 			}
 
 			filteredPaths := []string{}
-			if !opt.List.Quick {
+			if opt.List.Long {
+				//The summaries say which secrets are deleted
+				filteredPaths = paths
+			} else if !opt.List.Quick {
 				for i := range paths {
 					if !strings.HasSuffix(paths[i], "/") {
 						fullpath := path + "/" + vault.EscapePathSegment(paths[i])
@@ -167,20 +199,68 @@ func registerTreeCommands(r *app.Runner, opt *Options) {
 
 			sort.Strings(filteredPaths)
 
-			if len(args) != 1 {
-				fmt.Printf("@C{%s}:\n", path)
+			//ls -l sums up the secrets listed from a single walk of the path,
+			// which goes no deeper
+			summaries := map[string]vault.SecretSummary{}
+			if opt.List.Long && path != "" && path != "/" {
+				secrets, err := app.ConstructSecrets(v, path, longTreeOpts(true, 1))
+				if err != nil && !vault.IsNotFound(err) {
+					return err
+				}
+				for _, secret := range secrets {
+					summaries[secret.Path] = secret.Summary()
+				}
 			}
-			display(filteredPaths)
-			if len(args) != 1 {
-				fmt.Printf("\n")
+
+			listing := app.OutputListing{Path: path, Entries: []app.OutputListedEntry{}}
+			for _, p := range filteredPaths {
+				if strings.HasSuffix(p, "/") {
+					listing.Entries = append(listing.Entries, app.OutputListedEntry{Name: strings.TrimSuffix(p, "/"), Type: "folder"})
+					continue
+				}
+
+				entry := app.OutputListedEntry{Name: p, Type: "secret"}
+				if opt.List.Long {
+					summary, found := summaries[vault.Canonicalize(path+"/"+vault.EscapePathSegment(p))]
+					if !found || (!opt.List.Quick && summary.State != vault.SecretStateAlive) {
+						continue
+					}
+					entry.Summary = app.NewOutputSummary(summary)
+				}
+				listing.Entries = append(listing.Entries, entry)
 			}
+			listings = append(listings, listing)
 		}
-		return nil
+
+		return out.Print(listings, func() error {
+			for _, listing := range listings {
+				if len(listings) != 1 {
+					fmt.Printf("@C{%s}:\n", listing.Path)
+				}
+				if opt.List.Long {
+					names := make([]string, len(listing.Entries))
+					summaries := make([]*app.OutputSummary, len(listing.Entries))
+					for i, e := range listing.Entries {
+						names[i], summaries[i] = e.Name, e.Summary
+						if e.Type == "folder" {
+							names[i] = fmt.Sprintf("@B{%s/}", e.Name)
+						}
+					}
+					printSummaries("name", names, summaries)
+				} else {
+					display(listing.Entries)
+				}
+				if len(listings) != 1 {
+					fmt.Printf("\n")
+				}
+			}
+			return nil
+		})
 	})
 
 	r.Dispatch("tree", &app.Help{
 		Summary: "Print a tree listing of one or more paths",
-		Usage:   "safe tree [-d|-q|--keys] [PATH ...]",
+		Usage:   "safe tree [-d|-q|-l|--keys] [PATH ...]",
 		Type:    app.NonDestructiveCommand,
 		Description: `
 Walks the hierarchy of secrets stored underneath a given path, listing all
@@ -191,47 +271,98 @@ will not inspect each key in a v1 v2 mount backend to see if it has been marked
 as deleted. This may cause keys which would 404 in an attempt to read them to
 appear in the tree, but is often considerably quicker for larger vaults. This
 flag does nothing for kv v1 mounts.
+
+If '-l' (--long) is given, safe prints a table of the secrets in the tree
+instead, for auditing, with the following for each of them:
+
+    versions    How many versions of it there are (1 for kv v1 secrets).
+    state       Whether its latest version is alive, deleted or destroyed.
+    created     When its oldest version was written (kv v2 only).
+    updated     When its latest version was written (kv v2 only).
+    keys        How many keys its latest version has.
+    size        How many bytes the values of those keys come to.
+
+'safe ls -l' and 'safe paths -l' print the same.
 `,
 	}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
 		if opt.Tree.HideLeaves && opt.Tree.ShowKeys {
 			return fmt.Errorf("Cannot specify both -d and --keys at the same time")
 		}
+		if opt.Tree.Long && (opt.Tree.HideLeaves || opt.Tree.ShowKeys) {
+			return fmt.Errorf("Cannot specify -d or --keys with -l")
+		}
+		if opt.Tree.HideLeaves && out.Structured() {
+			return fmt.Errorf("Cannot specify -d with --output %s", out.Format)
+		}
 		if len(args) == 0 {
 			args = append(args, "secret")
 		}
 		r1, _ := regexp.Compile("^ ")
 		r2, _ := regexp.Compile("^└")
 		v := app.Connect(true)
-		for i, path := range args {
-			secrets, err := v.ConstructSecrets(path, vault.TreeOpts{
+		trees := make([]vault.Secrets, 0, len(args))
+		found := []app.OutputSecret{}
+		for _, path := range args {
+			treeOpts := vault.TreeOpts{
 				FetchKeys:           opt.Tree.ShowKeys,
 				AllowDeletedSecrets: opt.Tree.Quick,
+			}
+			if opt.Tree.Long {
+				treeOpts = longTreeOpts(opt.Tree.Quick, 0)
+			}
+			//The tree can only be drawn, or the table laid out, once every
+			// secret in it is known
+			secrets := vault.Secrets{}
+			err := app.WalkSecrets(v, path, treeOpts, func(secret vault.SecretEntry) error {
+				secrets.Append(secret)
+				return nil
 			})
-
 			if err != nil {
 				return err
 			}
-			lines := strings.Split(secrets.Draw(path, fmt.CanColorize(os.Stdout), !opt.Tree.HideLeaves), "\n")
-			if i > 0 {
-				lines = lines[1:] // Drop root '.' from subsequent paths
+			secrets.Sort()
+			trees = append(trees, secrets)
+			for _, secret := range secrets {
+				s := app.NewOutputSecret(secret, opt.Tree.ShowKeys)
+				if opt.Tree.Long {
+					s.Summary = app.NewOutputSummary(secret.Summary())
+				}
+				found = append(found, s)
 			}
-			if i < len(args)-1 {
-				lines = lines[:len(lines)-1]
+		}
+
+		return out.Print(found, func() error {
+			if opt.Tree.Long {
+				printLong(found)
+				return nil
 			}
-			for _, line := range lines {
+			for i, path := range args {
+				lines := strings.Split(trees[i].Draw(path, fmt.CanColorize(os.Stdout), !opt.Tree.HideLeaves), "\n")
+				if i > 0 {
+					lines = lines[1:] // Drop root '.' from subsequent paths
+				}
 				if i < len(args)-1 {
-					line = r1.ReplaceAllString(r2.ReplaceAllString(line, "├"), "│")
+					lines = lines[:len(lines)-1]
+				}
+				for _, line := range lines {
+					if i < len(args)-1 {
+						line = r1.ReplaceAllString(r2.ReplaceAllString(line, "├"), "│")
+					}
+					fmt.Printf("%s\n", line)
 				}
-				fmt.Printf("%s\n", line)
 			}
-		}
-		return nil
+			return nil
+		})
 	})
 
 	r.Dispatch("paths", &app.Help{
 		Summary: "Print all of the known paths, one per line",
-		Usage:   "safe paths [-q|--keys] PATH [PATH ...]",
+		Usage:   "safe paths [-q|-l|--keys] PATH [PATH ...]",
 		Type:    app.NonDestructiveCommand,
 		Description: `
 Walks the hierarchy of secrets stored underneath a given path, listing all
@@ -240,25 +371,379 @@ safe will not inspect each key in a v1 v2 mount backend to see if it has been
 marked as deleted. This may cause keys which would 404 in an attempt to read
 them to appear in the tree, but is often considerably quicker for larger
 vaults. This flag does nothing for kv v1 mounts.
+
+Paths are printed as soon as they are found, so they come out in no
+particular order; pipe them through sort if that matters.
+
+If '-l' (--long) is given, safe prints a table of the secrets instead, as
+'safe tree -l' does.
 `}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
+		if opt.Paths.Long && opt.Paths.ShowKeys {
+			return fmt.Errorf("Cannot specify both --keys and -l")
+		}
 		if len(args) < 1 {
 			args = append(args, "secret")
 		}
 		v := app.Connect(true)
+		args, _, err = expandGlobs(v, args, true)
+		if err != nil {
+			return err
+		}
+		//Plain paths are printed as they are found, rather than once the whole
+		// tree has been walked; documents and tables need every secret first
+		stream := !opt.Paths.Long && !out.Structured()
+		found := []app.OutputSecret{}
 		for _, path := range args {
-			secrets, err := v.ConstructSecrets(path, vault.TreeOpts{
+			treeOpts := vault.TreeOpts{
 				FetchKeys:           opt.Paths.ShowKeys,
 				AllowDeletedSecrets: opt.Paths.Quick,
-				SkipVersionInfo:     !opt.Paths.ShowKeys,
+				SkipVersionInfo:     !opt.Paths.ShowKeys && !out.Structured(),
+			}
+			if opt.Paths.Long {
+				treeOpts = longTreeOpts(opt.Paths.Quick, 0)
+			}
+			secrets := vault.Secrets{}
+			err := app.WalkSecrets(v, path, treeOpts, func(secret vault.SecretEntry) error {
+				if !stream {
+					secrets.Append(secret)
+					return nil
+				}
+				for _, path := range (vault.Secrets{secret}).Paths() {
+					fmt.Printf("%s\n", path)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			secrets.Sort()
+			for _, secret := range secrets {
+				s := app.NewOutputSecret(secret, opt.Paths.ShowKeys)
+				if opt.Paths.Long {
+					s.Summary = app.NewOutputSummary(secret.Summary())
+				}
+				found = append(found, s)
+			}
+		}
+		if stream {
+			return nil
+		}
+
+		return out.Print(found, func() error {
+			printLong(found)
+			return nil
+		})
+	})
+
+	r.Dispatch("find", &app.Help{
+		Summary: "Find the keys of secrets by path, name or value",
+		Usage:   "safe find [--path REGEX] [--key REGEX] [--value REGEX|--value-sha256 HASH] [--show] [--versions] ROOT [ROOT ...]",
+		Type:    app.NonDestructiveCommand,
+		Description: `
+Walks the hierarchy of secrets stored underneath each ROOT, and prints the
+path:key of every key that matches all of the filters given:
+
+  --path REGEX           the path of the secret matches REGEX
+  --key REGEX            the name of the key matches REGEX
+  --value REGEX          the value matches REGEX
+  --value-sha256 HASH    the hex-encoded SHA-256 digest of the value is HASH,
+                         to find a value without putting it on the command
+                         line (try: printf %s "$VALUE" | sha256sum)
+
+Regular expressions match anywhere in what they are matched against, unless
+anchored with ^ and $. Values are not printed unless --show is given.
+
+--versions searches every version of each secret that still has data, not
+just the latest one, and prints the version that matched as path:key^N.
+
+Exits 1 if nothing matched, like grep.
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) == 0 {
+			r.ExitWithUsage("find")
+		}
+		if opt.Find.Value != "" && opt.Find.ValueSHA256 != "" {
+			return fmt.Errorf("Cannot specify both --value and --value-sha256")
+		}
+
+		findOpts := vault.FindOpts{
+			ValueSHA256: opt.Find.ValueSHA256,
+			AllVersions: opt.Find.Versions,
+		}
+		for _, filter := range []struct {
+			flag  string
+			regex string
+			into  **regexp.Regexp
+		}{
+			{"--path", opt.Find.Path, &findOpts.Path},
+			{"--key", opt.Find.Key, &findOpts.Key},
+			{"--value", opt.Find.Value, &findOpts.Value},
+		} {
+			if filter.regex == "" {
+				continue
+			}
+			re, err := regexp.Compile(filter.regex)
+			if err != nil {
+				return fmt.Errorf("Invalid regular expression for %s: %s", filter.flag, err)
+			}
+			*filter.into = re
+		}
+
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
+		v := app.Connect(true)
+		var matches []vault.FindMatch
+		for _, path := range args {
+			secrets, err := app.ConstructSecrets(v, path, vault.TreeOpts{
+				FetchKeys:           true,
+				FetchAllVersions:    opt.Find.Versions,
+				AllowDeletedSecrets: opt.Find.Versions,
 			})
 			if err != nil {
 				return err
 			}
 
-			fmt.Printf(strings.Join(secrets.Paths(), "\n"))
-			fmt.Printf("\n")
+			for _, secret := range secrets {
+				matches = append(matches, secret.Find(findOpts)...)
+			}
+		}
+
+		found := make([]app.OutputMatch, 0, len(matches))
+		for _, match := range matches {
+			m := app.OutputMatch{Path: match.Path, Key: match.Key}
+			if opt.Find.Versions {
+				m.Version = match.Version
+			}
+			if opt.Find.Show {
+				m.Value = match.Value
+			}
+			found = append(found, m)
+		}
+
+		err = out.Print(found, func() error {
+			for _, match := range matches {
+				if opt.Find.Show {
+					fmt.Printf("@G{%s}=%s\n", match.String(opt.Find.Versions), match.Value)
+				} else {
+					fmt.Printf("@G{%s}\n", match.String(opt.Find.Versions))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			os.Exit(1)
+		}
+		return nil
+	})
+
+	r.Dispatch("diff", &app.Help{
+		Summary: "Compare two secrets, versions or subtrees, key by key",
+		Usage:   "safe diff [--show-values] A B",
+		Type:    app.NonDestructiveCommand,
+		Description: `
+Compares A with B, and prints the keys that differ between them: + for keys
+only in B, - for keys only in A, and ~ for keys whose values differ. Values
+that are JSON objects or arrays are compared field by field.
+
+Each side can be a secret (path), a version of one (path^N), a single key of
+either (path:key), or a subtree of secrets, which is compared with the other
+side secret by secret, by their paths relative to each subtree. A path that
+is both a secret and has secrets beneath it is taken to be a secret, unless
+it ends in a /. Either side can be read from another target by prefixing it
+with the target's alias and a colon, as in TARGET:path (or @TARGET:path, for
+a target named after a mount of the current target).
+
+Values are not printed unless --show-values is given, with --output json or
+yaml as much as without.
+
+Exits 0 if the sides are the same, 1 if they differ, and 2 if they could
+not be compared.
+`,
+	}, func(command string, args ...string) error {
+		c := rc.Apply(opt.UseTarget)
+		if len(args) != 2 {
+			r.ExitWithUsage("diff")
+		}
+
+		//Anything that keeps the sides from being compared exits 2, since 1
+		// means they differ
+		fail := func(err error) {
+			fmt.Fprintf(os.Stderr, "@R{!! %s}\n", err)
+			os.Exit(2)
+		}
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			fail(err)
+		}
+
+		changes, err := diffSides(c, args[0], args[1])
+		if err != nil {
+			fail(err)
+		}
+		found := []app.OutputChange{}
+		for _, change := range changes {
+			found = append(found, app.NewOutputChange(change, opt.Diff.ShowValues))
+		}
+		err = out.Print(found, func() error {
+			format := vaultsync.FormatDiffRedacted
+			if opt.Diff.ShowValues {
+				format = vaultsync.FormatDiff
+			}
+			for _, change := range changes {
+				fmt.Printf("%s", format(change, fmt.ShouldColorize(os.Stdout)))
+			}
+			return nil
+		})
+		if err != nil {
+			fail(err)
+		}
+		if len(changes) > 0 {
+			os.Exit(1)
 		}
 		return nil
 	})
 }
+
+// diffSides works out how side b differs from side a, returning a change for
+// each secret that does.
+func diffSides(c rc.Config, a, b string) ([]vaultsync.Change, error) {
+	src, srcPath, dst, dstPath, err := connectPair(c, a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	before, isTree, err := diffSide(src, srcPath)
+	if err != nil {
+		return nil, err
+	}
+	after, afterIsTree, err := diffSide(dst, dstPath)
+	if err != nil {
+		return nil, err
+	}
+	if isTree != afterIsTree {
+		return nil, fmt.Errorf("Cannot compare a secret with a subtree of secrets")
+	}
+
+	local := make([]vaultsync.LocalSecret, 0, len(after))
+	for path, data := range after {
+		local = append(local, vaultsync.LocalSecret{Path: path, Data: data})
+	}
+
+	var changes []vaultsync.Change
+	for _, change := range vaultsync.ComputeChanges(local, before).Changes {
+		if change.Type == vaultsync.ChangeNone {
+			continue
+		}
+		if !isTree {
+			change.Path = a
+			if a != b {
+				change.Path = a + " => " + b
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// diffSide reads one side of a diff: the secret at path, which is keyed by
+// the empty string, or else every secret in the subtree under it, keyed by
+// their paths relative to it.
+func diffSide(v *vault.Vault, path string) (map[string]map[string]interface{}, bool, error) {
+	expand := func(s *vault.Secret) map[string]interface{} {
+		data := make(map[string]string)
+		for _, key := range s.Keys() {
+			data[key] = s.Get(key)
+		}
+		return vaultsync.ExpandMap(data)
+	}
+
+	if !strings.HasSuffix(path, "/") {
+		s, err := v.Read(path)
+		if err == nil {
+			return map[string]map[string]interface{}{"": expand(s)}, false, nil
+		}
+		if !vault.IsSecretNotFound(err) || vault.PathHasKey(path) || vault.PathHasVersion(path) {
+			return nil, false, err
+		}
+	}
+
+	secrets, err := app.ConstructSecrets(v, path, vault.TreeOpts{FetchKeys: true})
+	if err != nil {
+		return nil, true, err
+	}
+	if len(secrets) == 0 {
+		return nil, true, vault.NewSecretNotFoundError(path)
+	}
+
+	root := vault.Canonicalize(path) + "/"
+	tree := make(map[string]map[string]interface{}, len(secrets))
+	for _, secret := range secrets {
+		tree[strings.TrimPrefix(secret.Path, root)] = expand(secret.Versions[len(secret.Versions)-1].Data)
+	}
+	return tree, true, nil
+}
+
+// longTreeOpts are the options for walking a tree for a long listing, which
+// needs the version info of each secret, and the keys of its latest version.
+// depth, if above 0, is how many folders deep to walk (see vault.TreeOpts).
+func longTreeOpts(allowDeleted bool, depth int) vault.TreeOpts {
+	return vault.TreeOpts{
+		FetchKeys:           true,
+		FetchAllVersions:    true,
+		FetchLatestKeysOnly: true,
+		AllowDeletedSecrets: allowDeleted,
+		MaxDepth:            depth,
+	}
+}
+
+// printLong prints a long listing of the given secrets.
+func printLong(secrets []app.OutputSecret) {
+	names := make([]string, len(secrets))
+	summaries := make([]*app.OutputSummary, len(secrets))
+	for i, s := range secrets {
+		names[i], summaries[i] = fmt.Sprintf("@G{%s}", s.Path), s.Summary
+	}
+	printSummaries("path", names, summaries)
+}
+
+// printSummaries prints a table of the given names (headed by title), with the
+// summary of the secret each one names, or nothing much for folders, whose
+// summaries are nil.
+func printSummaries(title string, names []string, summaries []*app.OutputSummary) {
+	when := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Local().Format(time.RFC822)
+	}
+
+	tbl := app.Table{}
+	tbl.SetHeader(title, "versions", "state", "created", "updated", "keys", "size")
+	for i, name := range names {
+		s := summaries[i]
+		if s == nil {
+			tbl.AddRow(name, "-", "-", "-", "-", "-", "-")
+			continue
+		}
+		state := map[string]string{
+			"alive":     "@G{alive}",
+			"deleted":   "@Y{deleted}",
+			"destroyed": "@R{destroyed}",
+		}[s.State]
+		tbl.AddRow(name, strconv.Itoa(s.Versions), fmt.Sprintf(state), when(s.CreatedAt), when(s.UpdatedAt),
+			strconv.Itoa(s.Keys), strconv.Itoa(s.Size))
+	}
+	tbl.Print()
+}
@@ -17,7 +17,7 @@ import (
 func registerUtilsCommands(r *app.Runner, opt *Options) {
 	r.Dispatch("fmt", &app.Help{
 		Summary: "Reformat an existing name/value pair, into a new name",
-		Usage:   "safe fmt FORMAT PATH OLD-NAME NEW-NAME",
+		Usage:   "safe fmt [--cas VERSION] [--patch] FORMAT PATH OLD-NAME NEW-NAME",
 		Type:    app.DestructiveCommand,
 		Description: `
 Take the value stored at PATH/OLD-NAME, format it a different way, and
@@ -33,6 +33,9 @@ Supported formats:
     crypt-sha256    Salt and hash the value, using SHA-256, in crypt format.
     crypt-sha512    Salt and hash the value, using SHA-512, in crypt format.
 
+--cas VERSION only writes the new key if the secret is still at VERSION, and
+--patch only sends the new key, so that other keys changed at the same time
+are not lost.  See 'safe help set' for more on both.
 `,
 	}, func(command string, args ...string) error {
 		rc.Apply(opt.UseTarget)
@@ -46,6 +49,11 @@ Supported formats:
 		oldKey := args[2]
 		newKey := args[3]
 
+		wopts, err := writeOpts(opt.Fmt.CAS)
+		if err != nil {
+			return err
+		}
+
 		v := app.Connect(true)
 		s, err := v.Read(path)
 		if err != nil {
@@ -57,14 +65,15 @@ Supported formats:
 			}
 			return nil
 		}
-		if err = s.Format(oldKey, newKey, fmtType, opt.SkipIfExists); err != nil {
-			if vault.IsNotFound(err) {
-				return fmt.Errorf("%s:%s does not exist, cannot create %s encoded copy at %s:%s", path, oldKey, fmtType, path, newKey)
+		return writeChanges(v, path, s, func(s *vault.Secret) error {
+			if err := s.Format(oldKey, newKey, fmtType, opt.SkipIfExists); err != nil {
+				if vault.IsNotFound(err) {
+					return fmt.Errorf("%s:%s does not exist, cannot create %s encoded copy at %s:%s", path, oldKey, fmtType, path, newKey)
+				}
+				return fmt.Errorf("Error encoding %s:%s as %s: %w", path, oldKey, fmtType, err)
 			}
-			return fmt.Errorf("Error encoding %s:%s as %s: %w", path, oldKey, fmtType, err)
-		}
-
-		return v.Write(path, s)
+			return nil
+		}, opt.Fmt.Patch, wopts)
 	})
 
 	r.Dispatch("prompt", &app.Help{
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/app"
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
+	"github.com/SomeBlackMagic/vault-cli-manager/rc"
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	fmt "github.com/jhunt/go-ansi"
+)
+
+// watchedTemplate is a template that safe watch renders to a file.
+type watchedTemplate struct {
+	t   *formats.Template
+	out string
+}
+
+func registerWatchCommands(r *app.Runner, opt *Options) {
+	r.Dispatch("watch", &app.Help{
+		Summary: "Run a command whenever secrets change",
+		Usage:   "safe watch [-i INTERVAL] [-x COMMAND] [-r TEMPLATE:FILE ...] [-m MODE] PATH [PATH ...]",
+		Type:    app.NonDestructiveCommand,
+		Description: `
+Polls the metadata of each KV v2 secret PATH every INTERVAL (30s by default,
+give or take a tenth at random), and whenever the current version of any of
+them changes, runs COMMAND with sh.  The paths that changed are passed to it
+in $SAFE_WATCH_CHANGED, one per line:
+
+    safe watch -x 'systemctl reload app' secret/app/db secret/app/tls
+
+-r renders TEMPLATE (as 'safe render' does) to FILE, with mode MODE (0600 by
+default), when safe watch starts and again before COMMAND is run.  It can be
+given more than once.  COMMAND is not run if a template fails to render;
+instead, the change is retried with backoff, as failed polls are, until
+every template renders.
+
+Failed polls are retried with a backoff of up to five minutes, and the token
+is renewed as it nears expiry.  safe watch runs until it is interrupted; a
+COMMAND that fails is reported, and does not stop it.
+`,
+	}, func(command string, args ...string) error {
+		rc.Apply(opt.UseTarget)
+		if len(args) == 0 {
+			r.ExitWithUsage("watch")
+		}
+		interval, err := time.ParseDuration(opt.Watch.Interval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("Invalid interval `%s' (expected a duration, like 30s or 5m)", opt.Watch.Interval)
+		}
+		mode, err := fileMode(opt.Watch.Mode)
+		if err != nil {
+			return err
+		}
+		if opt.Watch.Exec == "" && len(opt.Watch.Render) == 0 {
+			return fmt.Errorf("Nothing to do when secrets change (see 'safe help watch')")
+		}
+
+		var templates []watchedTemplate
+		for _, spec := range opt.Watch.Render {
+			file, out, ok := strings.Cut(spec, ":")
+			if !ok || file == "" || out == "" {
+				return fmt.Errorf("Invalid template `%s' (expected TEMPLATE:FILE)", spec)
+			}
+			t, err := readTemplate(file)
+			if err != nil {
+				return err
+			}
+			templates = append(templates, watchedTemplate{t: t, out: out})
+		}
+
+		v := app.Connect(true)
+		for _, wt := range templates {
+			if err = renderFile(v, wt.t, wt.out, mode); err != nil {
+				return err
+			}
+		}
+
+		ctx, stop := app.Interruptible()
+		defer stop()
+		return v.Watch(ctx, args, vault.WatchOpts{
+			Interval: interval,
+			OnError: func(err error, wait time.Duration) {
+				fmt.Fprintf(os.Stderr, "@R{!! %s}; trying again in %s\n", err, wait.Round(time.Millisecond))
+			},
+		}, func(changed []string) error {
+			for _, path := range changed {
+				fmt.Fprintf(os.Stderr, "@C{%s} changed\n", path)
+			}
+			for _, wt := range templates {
+				if err := renderFile(v, wt.t, wt.out, mode); err != nil {
+					return fmt.Errorf("failed to render %s: %s", wt.out, err)
+				}
+			}
+			if opt.Watch.Exec != "" {
+				hook := exec.Command("sh", "-c", opt.Watch.Exec)
+				hook.Stdout = os.Stdout
+				hook.Stderr = os.Stderr
+				hook.Env = append(os.Environ(), "SAFE_WATCH_CHANGED="+strings.Join(changed, "\n"))
+				if err := hook.Run(); err != nil {
+					fmt.Fprintf(os.Stderr, "@R{!! %s: %s}\n", opt.Watch.Exec, err)
+				}
+			}
+			return nil
+		})
+	})
+}
@@ -784,184 +784,183 @@ prints out information about a certificate, including:
 		}
 
 		rc.Apply(opt.UseTarget)
+		out, err := app.NewOutput(opt.Output)
+		if err != nil {
+			return err
+		}
 		v := app.Connect(true)
+		args, _, err = expandGlobs(v, args, false)
+		if err != nil {
+			return err
+		}
 
-		for _, path := range args {
-			s, err := v.Read(args[0])
+		certs := make([]*vault.X509, len(args))
+		errs := make([]error, len(args))
+		found := []app.OutputCertificate{}
+		for i, path := range args {
+			s, err := v.Read(path)
 			if err != nil {
 				return err
 			}
+			//Secrets that aren't certificates are reported, not failed on
+			certs[i], errs[i] = s.X509(false)
+			found = append(found, outputCertificate(path, certs[i], errs[i]))
+		}
 
-			fmt.Printf("%s:\n", path)
-			cert, err := s.X509(false)
-			if err != nil {
-				fmt.Printf("  !! %s\n\n", err)
-				continue
-			}
+		return out.Print(found, func() error {
+			for i, path := range args {
+				fmt.Printf("%s:\n", path)
+				cert, err := certs[i], errs[i]
+				if err != nil {
+					fmt.Printf("  !! %s\n\n", err)
+					continue
+				}
 
-			fmt.Printf("  @G{%s}\n\n", cert.Subject())
-			if cert.Subject() != cert.Issuer() {
-				fmt.Printf("  issued by: @C{%s}\n", cert.Issuer())
-				for i := range cert.Intermediaries {
-					fmt.Printf("        via: @C{%s}\n", cert.IntermediarySubject(i))
+				fmt.Printf("  @G{%s}\n\n", cert.Subject())
+				if cert.Subject() != cert.Issuer() {
+					fmt.Printf("  issued by: @C{%s}\n", cert.Issuer())
+					for i := range cert.Intermediaries {
+						fmt.Printf("        via: @C{%s}\n", cert.IntermediarySubject(i))
+					}
+				} else {
+					fmt.Printf("  @C{self-signed}\n")
 				}
-			} else {
-				fmt.Printf("  @C{self-signed}\n")
-			}
-
-			toStart := cert.Certificate.NotBefore.Sub(time.Now())
-			toEnd := cert.Certificate.NotAfter.Sub(time.Now())
-
-			days := int(toStart.Hours() / 24)
-			if days == 1 {
-				fmt.Printf("  @Y{not valid for another day}\n")
-			} else if days > 1 {
-				fmt.Printf("  @Y{not valid for another %d days}\n", days)
-			}
-
-			days = int(toEnd.Hours() / 24)
-			if days < -1 {
-				fmt.Printf("  @R{EXPIRED %d days ago}\n", -1*days)
-			} else if days < 0 {
-				fmt.Printf("  @R{EXPIRED a day ago}\n")
-			} else if days < 1 {
-				fmt.Printf("  @R{EXPIRED}\n")
-			} else if days == 1 {
-				fmt.Printf("  @Y{expires in a day}\n")
-			} else if days < 30 {
-				fmt.Printf("  @Y{expires in %d days}\n", days)
-			} else {
-				fmt.Printf("  expires in @G{%d days}\n", days)
-			}
-			fmt.Printf("  valid from @C{%s} - @C{%s}", cert.Certificate.NotBefore.Format("Jan 2 2006"), cert.Certificate.NotAfter.Format("Jan 2 2006"))
-
-			life := int(cert.Certificate.NotAfter.Sub(cert.Certificate.NotBefore).Hours())
-			if life < 360*24 {
-				fmt.Printf(" (@M{~%d days})\n", life/24)
-			} else {
-				fmt.Printf(" (@M{~%d years})\n", life/365/24)
-			}
-			fmt.Printf("\n")
-
-			n := 0
-			fmt.Printf("  for the following purposes:\n")
-			if cert.KeyUsage&x509.KeyUsageDigitalSignature != 0 {
-				n++
-				fmt.Printf("    - @C{digital-signature}  can be used to verify digital signatures.\n")
-			}
-			if cert.KeyUsage&x509.KeyUsageContentCommitment != 0 {
-				n++
-				fmt.Printf("    - @C{non-repudiation}    can be used for non-repudiation / content commitment.\n")
-			}
-			if cert.KeyUsage&x509.KeyUsageKeyEncipherment != 0 {
-				n++
-				fmt.Printf("    - @C{key-encipherment}   can be used encrypt other keys, for transport.\n")
-			}
-			if cert.KeyUsage&x509.KeyUsageDataEncipherment != 0 {
-				n++
-				fmt.Printf("    - @C{data-encipherment}  can be used to encrypt user data directly.\n")
-			}
-			if cert.KeyUsage&x509.KeyUsageKeyAgreement != 0 {
-				n++
-				fmt.Printf("    - @C{key-agreement}      can be used in key exchange, a la Diffie-Hellman key exchange.\n")
-			}
-			if cert.KeyUsage&x509.KeyUsageCertSign != 0 {
-				n++
-				fmt.Printf("    - @C{key-cert-sign}      can be used to verify digital signatures on public key certificates.\n")
-			}
-			if cert.KeyUsage&x509.KeyUsageCRLSign != 0 {
-				n++
-				fmt.Printf("    - @C{crl-sign}           can be used to verify digital signatures on certificate revocation lists.\n")
-			}
-			if cert.KeyUsage&x509.KeyUsageEncipherOnly != 0 {
-				n++
-				if cert.KeyUsage&x509.KeyUsageKeyAgreement != 0 {
-					fmt.Printf("    - @C{encipher-only}      can only be used to encrypt data in a key exchange.\n")
+
+				toStart := cert.Certificate.NotBefore.Sub(time.Now())
+				toEnd := cert.Certificate.NotAfter.Sub(time.Now())
+
+				days := int(toStart.Hours() / 24)
+				if days == 1 {
+					fmt.Printf("  @Y{not valid for another day}\n")
+				} else if days > 1 {
+					fmt.Printf("  @Y{not valid for another %d days}\n", days)
+				}
+
+				days = int(toEnd.Hours() / 24)
+				if days < -1 {
+					fmt.Printf("  @R{EXPIRED %d days ago}\n", -1*days)
+				} else if days < 0 {
+					fmt.Printf("  @R{EXPIRED a day ago}\n")
+				} else if days < 1 {
+					fmt.Printf("  @R{EXPIRED}\n")
+				} else if days == 1 {
+					fmt.Printf("  @Y{expires in a day}\n")
+				} else if days < 30 {
+					fmt.Printf("  @Y{expires in %d days}\n", days)
 				} else {
-					fmt.Printf("    - @C{encipher-only}      this key-usage is undefined if key-agreement is not set (which it isn't).\n")
+					fmt.Printf("  expires in @G{%d days}\n", days)
 				}
-			}
-			if cert.KeyUsage&x509.KeyUsageDecipherOnly != 0 {
-				n++
-				if cert.KeyUsage&x509.KeyUsageKeyAgreement != 0 {
-					fmt.Printf("    - @C{decipher-only}      can only be used to decrypt data in a key exchange.\n")
+				fmt.Printf("  valid from @C{%s} - @C{%s}", cert.Certificate.NotBefore.Format("Jan 2 2006"), cert.Certificate.NotAfter.Format("Jan 2 2006"))
+
+				life := int(cert.Certificate.NotAfter.Sub(cert.Certificate.NotBefore).Hours())
+				if life < 360*24 {
+					fmt.Printf(" (@M{~%d days})\n", life/24)
 				} else {
-					fmt.Printf("    - @C{decipher-only}      this key-usage is undefined if key-agreement is not set (which it isn't).\n")
+					fmt.Printf(" (@M{~%d years})\n", life/365/24)
 				}
-			}
-			for _, ku := range cert.ExtKeyUsage {
-				n++
-				switch ku {
-				default:
-					n--
-				case x509.ExtKeyUsageClientAuth:
-					fmt.Printf("    - @C{client-auth}*       can be used by a TLS client for authentication.\n")
-				case x509.ExtKeyUsageServerAuth:
-					fmt.Printf("    - @C{server-auth}*       can be used by a TLS server for authentication.\n")
-				case x509.ExtKeyUsageCodeSigning:
-					fmt.Printf("    - @C{code-signing}*      can be used to sign software packages to prove source.\n")
-				case x509.ExtKeyUsageEmailProtection:
-					fmt.Printf("    - @C{email-protection}*  can be used to protect email (signing, encryption, and key exchange).\n")
-				case x509.ExtKeyUsageTimeStamping:
-					fmt.Printf("    - @C{timestamping}*      can be used to generate trusted timestamps.\n")
+				fmt.Printf("\n")
+
+				n := 0
+				fmt.Printf("  for the following purposes:\n")
+				if cert.KeyUsage&x509.KeyUsageDigitalSignature != 0 {
+					n++
+					fmt.Printf("    - @C{digital-signature}  can be used to verify digital signatures.\n")
 				}
-			}
-			if n == 0 {
-				fmt.Printf("    (no special key usage constraints present)\n")
-			}
-			fmt.Printf("\n")
-
-			fmt.Printf("  signed with the algorithm ")
-			sigView := map[x509.SignatureAlgorithm]string{
-				x509.UnknownSignatureAlgorithm: "Unknown",
-				x509.MD2WithRSA:                "MD2 With RSA",
-				x509.MD5WithRSA:                "MD5 With RSA",
-				x509.SHA1WithRSA:               "SHA1 With RSA",
-				x509.SHA256WithRSA:             "SHA256 With RSA",
-				x509.SHA384WithRSA:             "SHA384 With RSA",
-				x509.SHA512WithRSA:             "SHA512 With RSA",
-				x509.DSAWithSHA1:               "DSA With SHA1",
-				x509.DSAWithSHA256:             "DSA With SHA256",
-				x509.ECDSAWithSHA1:             "ECDSA With SHA1",
-				x509.ECDSAWithSHA256:           "ECDSA With SHA256",
-				x509.ECDSAWithSHA384:           "ECDSA With SHA384",
-				x509.ECDSAWithSHA512:           "ECDSA With SHA512",
-				x509.SHA256WithRSAPSS:          "SHA256 With RSAPSS",
-				x509.SHA384WithRSAPSS:          "SHA384 With RSAPSS",
-				x509.SHA512WithRSAPSS:          "SHA512 With RSAPSS",
-			}
-			sigAlgo := sigView[cert.Certificate.SignatureAlgorithm]
-			fmt.Printf("@G{%s}\n", sigAlgo)
-			fmt.Printf("\n")
-
-			fmt.Printf("  for the following names:\n")
-			for _, s := range cert.Certificate.DNSNames {
-				fmt.Printf("    - @G{%s} (DNS)\n", s)
-			}
-			for _, s := range cert.Certificate.EmailAddresses {
-				fmt.Printf("    - @G{%s} (email)\n", s)
-			}
-			for _, s := range cert.Certificate.IPAddresses {
-				fmt.Printf("    - @G{%s} (IP)\n", s)
-			}
-			fmt.Printf("\n")
-
-			serialString := fmt.Sprintf("@M{%[1]d} (@M{%#[1]x})", cert.Certificate.SerialNumber)
-			if cert.Certificate.SerialNumber.Cmp(big.NewInt(1000)) == 1 {
-				serialString = fmt.Sprintf("@M{%s}", cert.FormatSerial())
-			}
-			fmt.Printf("  serial: %s\n", serialString)
-			fmt.Printf("  ")
-			if cert.IsCA() {
-				fmt.Printf("@G{is}")
-			} else {
-				fmt.Printf("@Y{is not}")
-			}
-			fmt.Printf(" a CA\n")
-			fmt.Printf("\n")
-		}
+				if cert.KeyUsage&x509.KeyUsageContentCommitment != 0 {
+					n++
+					fmt.Printf("    - @C{non-repudiation}    can be used for non-repudiation / content commitment.\n")
+				}
+				if cert.KeyUsage&x509.KeyUsageKeyEncipherment != 0 {
+					n++
+					fmt.Printf("    - @C{key-encipherment}   can be used encrypt other keys, for transport.\n")
+				}
+				if cert.KeyUsage&x509.KeyUsageDataEncipherment != 0 {
+					n++
+					fmt.Printf("    - @C{data-encipherment}  can be used to encrypt user data directly.\n")
+				}
+				if cert.KeyUsage&x509.KeyUsageKeyAgreement != 0 {
+					n++
+					fmt.Printf("    - @C{key-agreement}      can be used in key exchange, a la Diffie-Hellman key exchange.\n")
+				}
+				if cert.KeyUsage&x509.KeyUsageCertSign != 0 {
+					n++
+					fmt.Printf("    - @C{key-cert-sign}      can be used to verify digital signatures on public key certificates.\n")
+				}
+				if cert.KeyUsage&x509.KeyUsageCRLSign != 0 {
+					n++
+					fmt.Printf("    - @C{crl-sign}           can be used to verify digital signatures on certificate revocation lists.\n")
+				}
+				if cert.KeyUsage&x509.KeyUsageEncipherOnly != 0 {
+					n++
+					if cert.KeyUsage&x509.KeyUsageKeyAgreement != 0 {
+						fmt.Printf("    - @C{encipher-only}      can only be used to encrypt data in a key exchange.\n")
+					} else {
+						fmt.Printf("    - @C{encipher-only}      this key-usage is undefined if key-agreement is not set (which it isn't).\n")
+					}
+				}
+				if cert.KeyUsage&x509.KeyUsageDecipherOnly != 0 {
+					n++
+					if cert.KeyUsage&x509.KeyUsageKeyAgreement != 0 {
+						fmt.Printf("    - @C{decipher-only}      can only be used to decrypt data in a key exchange.\n")
+					} else {
+						fmt.Printf("    - @C{decipher-only}      this key-usage is undefined if key-agreement is not set (which it isn't).\n")
+					}
+				}
+				for _, ku := range cert.ExtKeyUsage {
+					n++
+					switch ku {
+					default:
+						n--
+					case x509.ExtKeyUsageClientAuth:
+						fmt.Printf("    - @C{client-auth}*       can be used by a TLS client for authentication.\n")
+					case x509.ExtKeyUsageServerAuth:
+						fmt.Printf("    - @C{server-auth}*       can be used by a TLS server for authentication.\n")
+					case x509.ExtKeyUsageCodeSigning:
+						fmt.Printf("    - @C{code-signing}*      can be used to sign software packages to prove source.\n")
+					case x509.ExtKeyUsageEmailProtection:
+						fmt.Printf("    - @C{email-protection}*  can be used to protect email (signing, encryption, and key exchange).\n")
+					case x509.ExtKeyUsageTimeStamping:
+						fmt.Printf("    - @C{timestamping}*      can be used to generate trusted timestamps.\n")
+					}
+				}
+				if n == 0 {
+					fmt.Printf("    (no special key usage constraints present)\n")
+				}
+				fmt.Printf("\n")
 
-		return nil
+				fmt.Printf("  signed with the algorithm ")
+				sigAlgo := signatureAlgorithms[cert.Certificate.SignatureAlgorithm]
+				fmt.Printf("@G{%s}\n", sigAlgo)
+				fmt.Printf("\n")
+
+				fmt.Printf("  for the following names:\n")
+				for _, s := range cert.Certificate.DNSNames {
+					fmt.Printf("    - @G{%s} (DNS)\n", s)
+				}
+				for _, s := range cert.Certificate.EmailAddresses {
+					fmt.Printf("    - @G{%s} (email)\n", s)
+				}
+				for _, s := range cert.Certificate.IPAddresses {
+					fmt.Printf("    - @G{%s} (IP)\n", s)
+				}
+				fmt.Printf("\n")
+
+				serialString := fmt.Sprintf("@M{%[1]d} (@M{%#[1]x})", cert.Certificate.SerialNumber)
+				if cert.Certificate.SerialNumber.Cmp(big.NewInt(1000)) == 1 {
+					serialString = fmt.Sprintf("@M{%s}", cert.FormatSerial())
+				}
+				fmt.Printf("  serial: %s\n", serialString)
+				fmt.Printf("  ")
+				if cert.IsCA() {
+					fmt.Printf("@G{is}")
+				} else {
+					fmt.Printf("@Y{is not}")
+				}
+				fmt.Printf(" a CA\n")
+				fmt.Printf("\n")
+			}
+			return nil
+		})
 	})
 
 	r.Dispatch("x509 crl", &app.Help{
@@ -1012,3 +1011,88 @@ Currently, only the --renew option is supported, and it is required:
 		return nil
 	})
 }
+
+var signatureAlgorithms = map[x509.SignatureAlgorithm]string{
+	x509.UnknownSignatureAlgorithm: "Unknown",
+	x509.MD2WithRSA:                "MD2 With RSA",
+	x509.MD5WithRSA:                "MD5 With RSA",
+	x509.SHA1WithRSA:               "SHA1 With RSA",
+	x509.SHA256WithRSA:             "SHA256 With RSA",
+	x509.SHA384WithRSA:             "SHA384 With RSA",
+	x509.SHA512WithRSA:             "SHA512 With RSA",
+	x509.DSAWithSHA1:               "DSA With SHA1",
+	x509.DSAWithSHA256:             "DSA With SHA256",
+	x509.ECDSAWithSHA1:             "ECDSA With SHA1",
+	x509.ECDSAWithSHA256:           "ECDSA With SHA256",
+	x509.ECDSAWithSHA384:           "ECDSA With SHA384",
+	x509.ECDSAWithSHA512:           "ECDSA With SHA512",
+	x509.SHA256WithRSAPSS:          "SHA256 With RSAPSS",
+	x509.SHA384WithRSAPSS:          "SHA384 With RSAPSS",
+	x509.SHA512WithRSAPSS:          "SHA512 With RSAPSS",
+}
+
+// keyUsages names the purposes x509 show lists for a certificate, in the order
+// it lists them.
+var keyUsages = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "digital-signature"},
+	{x509.KeyUsageContentCommitment, "non-repudiation"},
+	{x509.KeyUsageKeyEncipherment, "key-encipherment"},
+	{x509.KeyUsageDataEncipherment, "data-encipherment"},
+	{x509.KeyUsageKeyAgreement, "key-agreement"},
+	{x509.KeyUsageCertSign, "key-cert-sign"},
+	{x509.KeyUsageCRLSign, "crl-sign"},
+	{x509.KeyUsageEncipherOnly, "encipher-only"},
+	{x509.KeyUsageDecipherOnly, "decipher-only"},
+}
+
+var extKeyUsages = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageClientAuth:      "client-auth",
+	x509.ExtKeyUsageServerAuth:      "server-auth",
+	x509.ExtKeyUsageCodeSigning:     "code-signing",
+	x509.ExtKeyUsageEmailProtection: "email-protection",
+	x509.ExtKeyUsageTimeStamping:    "timestamping",
+}
+
+// outputCertificate describes the certificate at path for x509 show, or the
+// error met reading it as one.
+func outputCertificate(path string, cert *vault.X509, err error) app.OutputCertificate {
+	o := app.OutputCertificate{Path: path}
+	if err != nil {
+		o.Error = err.Error()
+		return o
+	}
+
+	c := cert.Certificate
+	o.Subject, o.Issuer = cert.Subject(), cert.Issuer()
+	o.SelfSigned = o.Subject == o.Issuer
+	if !o.SelfSigned {
+		for i := range cert.Intermediaries {
+			o.Intermediaries = append(o.Intermediaries, cert.IntermediarySubject(i))
+		}
+	}
+	o.CA = cert.IsCA()
+	o.NotBefore, o.NotAfter = &c.NotBefore, &c.NotAfter
+
+	for _, ku := range keyUsages {
+		if c.KeyUsage&ku.usage != 0 {
+			o.KeyUsage = append(o.KeyUsage, ku.name)
+		}
+	}
+	for _, ku := range c.ExtKeyUsage {
+		if name, ok := extKeyUsages[ku]; ok {
+			o.KeyUsage = append(o.KeyUsage, name)
+		}
+	}
+
+	o.SignatureAlgorithm = signatureAlgorithms[c.SignatureAlgorithm]
+	o.DNSNames = c.DNSNames
+	o.EmailAddresses = c.EmailAddresses
+	for _, ip := range c.IPAddresses {
+		o.IPAddresses = append(o.IPAddresses, ip.String())
+	}
+	o.Serial = cert.FormatSerial()
+	return o
+}
@@ -0,0 +1,60 @@
+package formats
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MarshalBOSH renders the given secrets as a BOSH vars-store file. Each secret
+// becomes a variable named after its path relative to the export roots, with
+// a password, certificate, ssh, rsa or user value as BOSH would generate it.
+// Secrets that do not fit one of those types (values included) are written as
+// a map of their keys.
+func MarshalBOSH(secrets []Secret, opts CredentialOpts) ([]byte, error) {
+	vars := map[string]interface{}{}
+	seen := map[string]string{}
+
+	for _, s := range secrets {
+		name := relativeName(s.Path, opts.Roots)
+		if other, found := seen[name]; found {
+			return nil, fmt.Errorf("Paths `%s' and `%s' would both be named `%s'", other, s.Path, name)
+		}
+		seen[name] = s.Path
+
+		typ, value := toCredential(s.Data)
+		//BOSH has no value type, and a bare string would come back as a
+		// password, so values are kept as a map of their one key
+		if typ == CredentialValue {
+			value = map[string]interface{}{"value": value}
+		}
+		vars[name] = value
+	}
+
+	return yaml.Marshal(vars)
+}
+
+// UnmarshalBOSH reads a BOSH vars-store file, placing each variable at
+// prefix/name. The type of each variable is inferred from its value.
+func UnmarshalBOSH(b []byte, prefix string) ([]Secret, error) {
+	var vars map[string]interface{}
+	err := yaml.Unmarshal(b, &vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []Secret
+	for name, value := range vars {
+		path, err := credentialPath(prefix, name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := fromCredential("", value)
+		if err != nil {
+			return nil, fmt.Errorf("Variable `%s': %s", name, err)
+		}
+		ret = append(ret, Secret{Path: path, Data: data})
+	}
+
+	return sortedSecrets(ret), nil
+}
@@ -0,0 +1,323 @@
+package formats
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+// The credential types shared by BOSH variables and CredHub.
+const (
+	CredentialPassword    = "password"
+	CredentialValue       = "value"
+	CredentialCertificate = "certificate"
+	CredentialSSH         = "ssh"
+	CredentialRSA         = "rsa"
+	CredentialUser        = "user"
+	CredentialJSON        = "json"
+)
+
+// CredentialOpts controls how Vault paths are turned into BOSH or CredHub
+// credential names.
+type CredentialOpts struct {
+	//Roots are the paths the export was rooted at; names are relative to them
+	Roots []string
+}
+
+// The keys safe uses for each credential type, and the names those keys go
+// by in BOSH and CredHub. A secret is only given a type if every one of its
+// keys is listed here; anything else is carried as json, so nothing is lost.
+var credentialKeys = map[string]map[string]string{
+	CredentialCertificate: {
+		"ca":          "ca",
+		"certificate": "certificate",
+		"key":         "private_key",
+		"combined":    "",
+		"serial":      "",
+		"crl":         "",
+	},
+	CredentialSSH: {
+		"private":     "private_key",
+		"public":      "public_key",
+		"fingerprint": "public_key_fingerprint",
+	},
+	CredentialRSA: {
+		"private": "private_key",
+		"public":  "public_key",
+	},
+	CredentialUser: {
+		"username":      "username",
+		"password":      "password",
+		"password_hash": "password_hash",
+	},
+}
+
+// credentialType works out which credential type the given key/value pairs
+// hold, based on the layout safe uses for each of them.
+func credentialType(data map[string]string) string {
+	only := func(typ string, required ...string) bool {
+		for _, key := range required {
+			if _, found := data[key]; !found {
+				return false
+			}
+		}
+		for key := range data {
+			if _, known := credentialKeys[typ][key]; !known {
+				return false
+			}
+		}
+		return true
+	}
+
+	_, hasPassword := data["password"]
+	_, hasValue := data["value"]
+
+	switch {
+	case len(data) == 1 && hasPassword:
+		return CredentialPassword
+	case len(data) == 1 && hasValue:
+		return CredentialValue
+	case only(CredentialCertificate, "certificate", "key"):
+		return CredentialCertificate
+	case only(CredentialSSH, "private", "public", "fingerprint"):
+		return CredentialSSH
+	case only(CredentialRSA, "private", "public"):
+		return CredentialRSA
+	case only(CredentialUser, "username", "password"):
+		return CredentialUser
+	default:
+		return CredentialJSON
+	}
+}
+
+// toCredential converts a secret into a credential type and value, the way
+// BOSH and CredHub represent it.
+func toCredential(data map[string]string) (string, interface{}) {
+	typ := credentialType(data)
+	switch typ {
+	case CredentialPassword:
+		return typ, data["password"]
+	case CredentialValue:
+		return typ, data["value"]
+	case CredentialJSON:
+		value := map[string]interface{}{}
+		for k, v := range data {
+			value[k] = v
+		}
+		return typ, value
+	}
+
+	value := map[string]interface{}{}
+	for key, name := range credentialKeys[typ] {
+		if name != "" && data[key] != "" {
+			value[name] = data[key]
+		}
+	}
+
+	//CAs are their own CA; BOSH and CredHub expect to see that spelled out
+	if typ == CredentialCertificate && data["ca"] == "" {
+		s := vault.NewSecret()
+		s.Set("certificate", data["certificate"], false)
+		if x, err := s.X509(false); err == nil && x.IsCA() && x.Subject() == x.Issuer() {
+			value["ca"] = data["certificate"]
+		}
+	}
+
+	return typ, value
+}
+
+// fromCredential converts a BOSH or CredHub credential value of the given type
+// back into the key layout safe uses. An empty type is inferred from the
+// shape of the value, as BOSH vars-stores do not record it.
+func fromCredential(typ string, value interface{}) (map[string]string, error) {
+	if typ == "" {
+		typ = inferCredentialType(value)
+	}
+
+	switch typ {
+	case CredentialPassword, CredentialValue:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s value is not a string", typ)
+		}
+		return map[string]string{typ: s}, nil
+	}
+
+	fields, err := stringFields(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case CredentialCertificate:
+		return certificateData(fields)
+
+	case CredentialSSH, CredentialRSA, CredentialUser:
+		data := map[string]string{}
+		for key, name := range credentialKeys[typ] {
+			if v, found := fields[name]; found {
+				data[key] = v
+			}
+		}
+		return data, nil
+
+	case CredentialJSON:
+		return fields, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported credential type `%s'", typ)
+	}
+}
+
+func inferCredentialType(value interface{}) string {
+	if _, ok := value.(string); ok {
+		return CredentialPassword
+	}
+
+	fields, err := stringFields(value)
+	if err != nil {
+		return CredentialJSON
+	}
+	has := func(names ...string) bool {
+		for _, name := range names {
+			if _, found := fields[name]; !found {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case has("certificate", "private_key"):
+		return CredentialCertificate
+	case has("private_key", "public_key", "public_key_fingerprint"):
+		return CredentialSSH
+	case has("private_key", "public_key"):
+		return CredentialRSA
+	case has("username", "password"):
+		return CredentialUser
+	default:
+		return CredentialJSON
+	}
+}
+
+// certificateData lays out a certificate the way X509.Secret writes it, with
+// its CA (if any) alongside. Certificates safe cannot parse (i.e. non-RSA
+// keys) are stored in the same layout, as-is.
+func certificateData(fields map[string]string) (map[string]string, error) {
+	if fields["certificate"] == "" {
+		return nil, fmt.Errorf("certificate value has no certificate")
+	}
+
+	s := vault.NewSecret()
+	s.Set("certificate", fields["certificate"], false)
+	s.Set("key", fields["private_key"], false)
+
+	data := map[string]string{
+		"certificate": fields["certificate"],
+		"key":         fields["private_key"],
+		"combined":    fields["certificate"] + fields["private_key"],
+	}
+	if x, err := s.X509(true); err == nil {
+		if layout, err := x.Secret(false); err == nil {
+			data = map[string]string{}
+			for _, key := range layout.Keys() {
+				data[key] = layout.Get(key)
+			}
+		}
+	}
+
+	if fields["ca"] != "" && strings.TrimSpace(fields["ca"]) != strings.TrimSpace(fields["certificate"]) {
+		data["ca"] = fields["ca"]
+	}
+	return data, nil
+}
+
+// stringFields flattens a YAML or JSON map into strings, encoding any
+// non-string values as JSON.
+func stringFields(value interface{}) (map[string]string, error) {
+	fields := map[string]string{}
+
+	switch m := value.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range m {
+			s, err := fieldString(v)
+			if err != nil {
+				return nil, err
+			}
+			fields[fmt.Sprintf("%v", k)] = s
+		}
+	case map[string]interface{}:
+		for k, v := range m {
+			s, err := fieldString(v)
+			if err != nil {
+				return nil, err
+			}
+			fields[k] = s
+		}
+	default:
+		return nil, fmt.Errorf("value is not a map")
+	}
+
+	return fields, nil
+}
+
+func fieldString(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case nil:
+		return "", nil
+	}
+
+	b, err := json.Marshal(jsonCompatible(v))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// jsonCompatible converts the map[interface{}]interface{} values produced by
+// the YAML decoder into something encoding/json can marshal.
+func jsonCompatible(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range t {
+			m[fmt.Sprintf("%v", k)] = jsonCompatible(v)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i := range t {
+			l[i] = jsonCompatible(t[i])
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+// credentialPath places the credential with the given name beneath prefix.
+// Without a prefix, the name itself has to be a full Vault path.
+func credentialPath(prefix, name string) (string, error) {
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return "", fmt.Errorf("credential has no name")
+	}
+	if prefix == "" {
+		if !strings.Contains(name, "/") {
+			return "", fmt.Errorf("credential `%s' is not a full path, and no path prefix was given", name)
+		}
+		return name, nil
+	}
+	return vault.Canonicalize(prefix) + "/" + name, nil
+}
+
+func sortedSecrets(secrets []Secret) []Secret {
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Path < secrets[j].Path })
+	return secrets
+}
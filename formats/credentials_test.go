@@ -0,0 +1,145 @@
+package formats_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("BOSH and CredHub credentials", func() {
+	var ca, cert map[string]string
+
+	BeforeEach(func() {
+		x, err := vault.NewCertificate("cn=ca", nil, []string{"key_cert_sign", "crl_sign"}, "", 1024)
+		Expect(err).ToNot(HaveOccurred())
+		x.MakeCA()
+		Expect(x.Sign(x, time.Hour)).To(Succeed())
+		caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: x.Certificate.Raw}))
+		caKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(x.PrivateKey)}))
+		ca = map[string]string{"certificate": caCert, "key": caKey, "combined": caCert + caKey}
+
+		y, err := vault.NewCertificate("cn=leaf", []string{"leaf.example.com"}, []string{"server_auth"}, "", 1024)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(x.Sign(y, time.Hour)).To(Succeed())
+		s, err := y.Secret(false)
+		Expect(err).ToNot(HaveOccurred())
+		cert = map[string]string{"ca": ca["certificate"]}
+		for _, k := range s.Keys() {
+			cert[k] = s.Get(k)
+		}
+	})
+
+	secrets := func() []formats.Secret {
+		return []formats.Secret{
+			{Path: "secret/dep/admin_password", Data: map[string]string{"password": "hunter2"}},
+			{Path: "secret/dep/ca", Data: ca},
+			{Path: "secret/dep/leaf", Data: cert},
+			{Path: "secret/dep/ssh", Data: map[string]string{"private": "PRIV", "public": "PUB", "fingerprint": "FP"}},
+			{Path: "secret/dep/rsa", Data: map[string]string{"private": "PRIV", "public": "PUB"}},
+			{Path: "secret/dep/user", Data: map[string]string{"username": "admin", "password": "pw"}},
+			{Path: "secret/dep/other", Data: map[string]string{"a": "1", "b": "2"}},
+			{Path: "secret/dep/token", Data: map[string]string{"value": "t0k3n"}},
+		}
+	}
+
+	Describe("BOSH vars-stores", func() {
+		It("writes each credential type the way BOSH does", func() {
+			b, err := formats.MarshalBOSH(secrets(), formats.CredentialOpts{Roots: []string{"secret/dep"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("admin_password: hunter2\n"))
+			Expect(string(b)).To(ContainSubstring("private_key: PRIV"))
+			Expect(string(b)).To(ContainSubstring("public_key_fingerprint: FP"))
+			Expect(string(b)).ToNot(ContainSubstring("combined"))
+		})
+
+		It("round-trips through a vars-store", func() {
+			b, err := formats.MarshalBOSH(secrets(), formats.CredentialOpts{Roots: []string{"secret/dep"}})
+			Expect(err).ToNot(HaveOccurred())
+			back, err := formats.UnmarshalBOSH(b, "secret/dep")
+			Expect(err).ToNot(HaveOccurred())
+
+			byPath := map[string]map[string]string{}
+			for _, s := range back {
+				byPath[s.Path] = s.Data
+			}
+			Expect(byPath["secret/dep/admin_password"]).To(Equal(map[string]string{"password": "hunter2"}))
+			Expect(byPath["secret/dep/ssh"]).To(Equal(map[string]string{"private": "PRIV", "public": "PUB", "fingerprint": "FP"}))
+			Expect(byPath["secret/dep/rsa"]).To(Equal(map[string]string{"private": "PRIV", "public": "PUB"}))
+			Expect(byPath["secret/dep/user"]).To(Equal(map[string]string{"username": "admin", "password": "pw"}))
+			Expect(byPath["secret/dep/other"]).To(Equal(map[string]string{"a": "1", "b": "2"}))
+			Expect(byPath["secret/dep/token"]).To(Equal(map[string]string{"value": "t0k3n"}))
+
+			Expect(byPath["secret/dep/leaf"]["certificate"]).To(Equal(cert["certificate"]))
+			Expect(byPath["secret/dep/leaf"]["key"]).To(Equal(cert["key"]))
+			Expect(byPath["secret/dep/leaf"]["combined"]).To(Equal(cert["certificate"] + cert["key"]))
+			Expect(byPath["secret/dep/leaf"]["ca"]).To(Equal(ca["certificate"]))
+
+			Expect(byPath["secret/dep/ca"]["certificate"]).To(Equal(ca["certificate"]))
+			Expect(byPath["secret/dep/ca"]["key"]).To(Equal(ca["key"]))
+			Expect(byPath["secret/dep/ca"]).ToNot(HaveKey("ca"))
+		})
+
+		It("requires a prefix for bare variable names", func() {
+			_, err := formats.UnmarshalBOSH([]byte("password: x\n"), "")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("CredHub exports", func() {
+		It("records the type of each credential", func() {
+			b, err := formats.MarshalCredHub(secrets(), formats.CredentialOpts{Roots: []string{"secret/dep"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("name: /admin_password\n  type: password\n"))
+			Expect(string(b)).To(ContainSubstring("name: /leaf\n  type: certificate\n"))
+			Expect(string(b)).To(ContainSubstring("name: /ssh\n  type: ssh\n"))
+			Expect(string(b)).To(ContainSubstring("name: /rsa\n  type: rsa\n"))
+			Expect(string(b)).To(ContainSubstring("name: /user\n  type: user\n"))
+			Expect(string(b)).To(ContainSubstring("name: /other\n  type: json\n"))
+		})
+
+		It("refuses to give two secrets the same name", func() {
+			_, err := formats.MarshalCredHub([]formats.Secret{
+				{Path: "secret/a/db", Data: map[string]string{"value": "1"}},
+				{Path: "secret/b/db", Data: map[string]string{"value": "2"}},
+			}, formats.CredentialOpts{Roots: []string{"secret/a", "secret/b"}})
+			Expect(err).To(MatchError("Paths `secret/a/db' and `secret/b/db' would both be named `/db'"))
+		})
+
+		It("reads credhub export files", func() {
+			back, err := formats.UnmarshalCredHub([]byte(`
+credentials:
+- name: /secret/bosh/dep/db
+  type: user
+  value:
+    username: admin
+    password: pw
+    password_hash: hash
+- name: /secret/bosh/dep/settings
+  type: json
+  value:
+    port: 5432
+    tags: [a, b]
+- name: /secret/bosh/dep/token
+  type: value
+  value: abc
+`), "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(back).To(Equal([]formats.Secret{
+				{Path: "secret/bosh/dep/db", Data: map[string]string{"username": "admin", "password": "pw", "password_hash": "hash"}},
+				{Path: "secret/bosh/dep/settings", Data: map[string]string{"port": "5432", "tags": `["a","b"]`}},
+				{Path: "secret/bosh/dep/token", Data: map[string]string{"value": "abc"}},
+			}))
+		})
+
+		It("rejects unknown credential types", func() {
+			_, err := formats.UnmarshalCredHub([]byte("credentials:\n- name: /a/b\n  type: bogus\n  value: {}\n"), "")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
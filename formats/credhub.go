@@ -0,0 +1,68 @@
+package formats
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+type credhubFile struct {
+	Credentials []credhubCredential `yaml:"credentials"`
+}
+
+type credhubCredential struct {
+	Name  string      `yaml:"name"`
+	Type  string      `yaml:"type"`
+	Value interface{} `yaml:"value"`
+}
+
+// MarshalCredHub renders the given secrets in the format written by
+// `credhub export` and read by `credhub import`. Each secret becomes a
+// credential named /<path relative to the export roots>.
+func MarshalCredHub(secrets []Secret, opts CredentialOpts) ([]byte, error) {
+	file := credhubFile{Credentials: []credhubCredential{}}
+	seen := map[string]string{}
+
+	for _, s := range secrets {
+		name := "/" + relativeName(s.Path, opts.Roots)
+		if other, found := seen[name]; found {
+			return nil, fmt.Errorf("Paths `%s' and `%s' would both be named `%s'", other, s.Path, name)
+		}
+		seen[name] = s.Path
+
+		typ, value := toCredential(s.Data)
+		file.Credentials = append(file.Credentials, credhubCredential{
+			Name:  name,
+			Type:  typ,
+			Value: value,
+		})
+	}
+
+	return yaml.Marshal(file)
+}
+
+// UnmarshalCredHub reads a `credhub export` file, placing each credential at
+// prefix/name. Without a prefix, credential names are taken to be full Vault
+// paths.
+func UnmarshalCredHub(b []byte, prefix string) ([]Secret, error) {
+	var file credhubFile
+	err := yaml.Unmarshal(b, &file)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []Secret
+	for _, cred := range file.Credentials {
+		path, err := credentialPath(prefix, cred.Name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := fromCredential(cred.Type, cred.Value)
+		if err != nil {
+			return nil, fmt.Errorf("Credential `%s': %s", cred.Name, err)
+		}
+		ret = append(ret, Secret{Path: path, Data: data})
+	}
+
+	return sortedSecrets(ret), nil
+}
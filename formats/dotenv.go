@@ -0,0 +1,286 @@
+package formats
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var envInvalidChars = regexp.MustCompile(`[^A-Z0-9_]+`)
+var envValidName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+var dotenvBareValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// EnvName joins the given path segments and key into an environment variable
+// name: upper-cased, with anything that is not a letter, digit or underscore
+// turned into an underscore.
+func EnvName(parts ...string) string {
+	name := strings.ToUpper(strings.Join(parts, "_"))
+	name = envInvalidChars.ReplaceAllString(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// DotenvVars names every key of every secret after its path relative to root
+// and the key itself, so that secret/app/db:password under secret/app becomes
+// DB_PASSWORD. Two keys that end up with the same name are an error.
+func DotenvVars(secrets []Secret, root string) (map[string]string, error) {
+	root = vault.Canonicalize(root)
+	vars := map[string]string{}
+	origins := map[string]string{}
+
+	for _, s := range secrets {
+		rel := strings.TrimPrefix(strings.TrimPrefix(vault.Canonicalize(s.Path), root), "/")
+		var segments []string
+		if rel != "" {
+			segments = strings.Split(rel, "/")
+		}
+
+		for _, key := range sortedKeys(s.Data) {
+			name := EnvName(append(segments, key)...)
+			origin := vault.EncodePath(s.Path, key, 0)
+			if other, found := origins[name]; found {
+				return nil, fmt.Errorf("Both `%s' and `%s' map to the variable %s", other, origin, name)
+			}
+			origins[name] = origin
+			vars[name] = s.Data[key]
+		}
+	}
+
+	return vars, nil
+}
+
+// MarshalDotenv renders the given variables as a .env file, sorted by name.
+// Values that cannot be written bare are single-quoted where possible, and
+// double-quoted with backslash escapes otherwise (multi-line values, or values
+// containing single quotes).
+func MarshalDotenv(vars map[string]string) []byte {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&out, "%s=%s\n", name, quoteDotenv(vars[name]))
+	}
+	return out.Bytes()
+}
+
+func quoteDotenv(value string) string {
+	if dotenvBareValue.MatchString(value) {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"$", `\$`,
+		"\n", `\n`,
+		"\r", `\r`,
+	)
+	return `"` + r.Replace(value) + `"`
+}
+
+// UnmarshalDotenv parses a .env file. It understands blank lines, # comments,
+// an optional `export` prefix, bare values (with trailing comments), literal
+// single-quoted values and double-quoted values with backslash escapes. Both
+// kinds of quoted value may span multiple lines.
+func UnmarshalDotenv(b []byte) (map[string]string, error) {
+	p := dotenvParser{src: strings.ReplaceAll(string(b), "\r\n", "\n"), line: 1}
+	vars := map[string]string{}
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			break
+		}
+
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+type dotenvParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) advance() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *dotenvParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// skipBlank skips whitespace, empty lines and comment lines
+func (p *dotenvParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\n':
+			p.advance()
+		case '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.advance() != '\n' {
+	}
+}
+
+func (p *dotenvParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.advance()
+	}
+}
+
+func (p *dotenvParser) name() (string, error) {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune("= \t\n", rune(p.peek())) {
+		p.advance()
+	}
+	name := p.src[start:p.pos]
+
+	//export is only a prefix when another name follows it; on its own, it is
+	// the name of a variable like any other
+	if name == "export" && !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		end := p.pos
+		p.skipSpaces()
+		if !p.eof() && !strings.ContainsRune("=#\n", rune(p.peek())) {
+			return p.name()
+		}
+		p.pos = end
+	}
+	if !envValidName.MatchString(name) {
+		return "", p.errorf("invalid variable name `%s'", name)
+	}
+
+	p.skipSpaces()
+	if p.eof() || p.peek() != '=' {
+		return "", p.errorf("expected `=' after %s", name)
+	}
+	p.advance()
+	p.skipSpaces()
+	return name, nil
+}
+
+func (p *dotenvParser) value() (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+
+	var value string
+	switch p.peek() {
+	case '\'':
+		p.advance()
+		start := p.pos
+		for !p.eof() && p.peek() != '\'' {
+			p.advance()
+		}
+		if p.eof() {
+			return "", p.errorf("unterminated single-quoted value")
+		}
+		value = p.src[start:p.pos]
+		p.advance()
+
+	case '"':
+		p.advance()
+		var sb strings.Builder
+		for {
+			if p.eof() {
+				return "", p.errorf("unterminated double-quoted value")
+			}
+			c := p.advance()
+			if c == '"' {
+				break
+			}
+			if c == '\\' && !p.eof() {
+				escaped := p.advance()
+				switch escaped {
+				case 'n':
+					sb.WriteByte('\n')
+				case 'r':
+					sb.WriteByte('\r')
+				case 't':
+					sb.WriteByte('\t')
+				case '\\', '"', '$', '\'':
+					sb.WriteByte(escaped)
+				default:
+					sb.WriteByte('\\')
+					sb.WriteByte(escaped)
+				}
+				continue
+			}
+			sb.WriteByte(c)
+		}
+		value = sb.String()
+
+	default:
+		start := p.pos
+		for !p.eof() && p.peek() != '\n' {
+			//A # only starts a comment if it follows whitespace, which may be
+			// the whitespace between the = and an empty value
+			if p.peek() == '#' && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+				break
+			}
+			p.advance()
+		}
+		return strings.TrimSpace(p.src[start:p.pos]), p.endOfLine()
+	}
+
+	return value, p.endOfLine()
+}
+
+// endOfLine allows trailing whitespace and a comment after a value
+func (p *dotenvParser) endOfLine() error {
+	p.skipSpaces()
+	if p.eof() {
+		return nil
+	}
+	switch p.peek() {
+	case '\n':
+		p.advance()
+	case '#':
+		p.skipLine()
+	default:
+		return p.errorf("unexpected `%c' after value", p.peek())
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package formats_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
+)
+
+var _ = Describe("dotenv", func() {
+	Describe("EnvName", func() {
+		It("upper-cases and joins with underscores", func() {
+			Expect(formats.EnvName("db", "password")).To(Equal("DB_PASSWORD"))
+		})
+
+		It("replaces characters that are not allowed in variable names", func() {
+			Expect(formats.EnvName("my-app", "api.key")).To(Equal("MY_APP_API_KEY"))
+		})
+
+		It("does not start with a digit", func() {
+			Expect(formats.EnvName("1password")).To(Equal("_1PASSWORD"))
+		})
+	})
+
+	Describe("DotenvVars", func() {
+		It("prefixes keys with the path below the root", func() {
+			vars, err := formats.DotenvVars([]formats.Secret{
+				{Path: "secret/app/db", Data: map[string]string{"password": "p"}},
+				{Path: "secret/app", Data: map[string]string{"name": "n"}},
+			}, "secret/app")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vars).To(Equal(map[string]string{"DB_PASSWORD": "p", "NAME": "n"}))
+		})
+
+		It("errors when two keys map to the same name", func() {
+			_, err := formats.DotenvVars([]formats.Secret{
+				{Path: "secret/app/db", Data: map[string]string{"password": "p"}},
+				{Path: "secret/app", Data: map[string]string{"db_password": "q"}},
+			}, "secret/app")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("MarshalDotenv", func() {
+		It("quotes only when needed", func() {
+			out := formats.MarshalDotenv(map[string]string{
+				"BARE":      "abc-123",
+				"SPACES":    "hello world",
+				"MULTILINE": "line1\nline2 \"quoted\" $HOME",
+				"QUOTE":     "it's",
+			})
+			Expect(string(out)).To(Equal(
+				"BARE=abc-123\n" +
+					`MULTILINE="line1\nline2 \"quoted\" \$HOME"` + "\n" +
+					`QUOTE="it's"` + "\n" +
+					"SPACES='hello world'\n"))
+		})
+	})
+
+	Describe("UnmarshalDotenv", func() {
+		It("parses comments, export prefixes and every quoting style", func() {
+			vars, err := formats.UnmarshalDotenv([]byte(`
+# a comment
+export USER=admin
+PASSWORD = 's3cr3t # not a comment'
+URL=http://example.com#anchor # a trailing comment
+CERT="-----BEGIN-----\nabc\n-----END-----"
+MULTI='first
+second'
+ESCAPED="say \"hi\" \\ \$PATH"
+EMPTY=
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vars).To(Equal(map[string]string{
+				"USER":     "admin",
+				"PASSWORD": "s3cr3t # not a comment",
+				"URL":      "http://example.com#anchor",
+				"CERT":     "-----BEGIN-----\nabc\n-----END-----",
+				"MULTI":    "first\nsecond",
+				"ESCAPED":  `say "hi" \ $PATH`,
+				"EMPTY":    "",
+			}))
+		})
+
+		It("reads a variable named export, and comments after empty values", func() {
+			vars, err := formats.UnmarshalDotenv([]byte("export=1\nexport export=2\nexport NAME = 3\nKEY= #a comment\nHASH=#not a comment\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vars).To(Equal(map[string]string{
+				"export": "2",
+				"NAME":   "3",
+				"KEY":    "",
+				"HASH":   "#not a comment",
+			}))
+
+			vars, err = formats.UnmarshalDotenv([]byte("export = 1"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vars).To(Equal(map[string]string{"export": "1"}))
+		})
+
+		It("round-trips what MarshalDotenv writes", func() {
+			vars := map[string]string{
+				"A": "plain",
+				"B": "with space",
+				"C": "multi\nline\r\nvalue with 'quotes' and \"doubles\" and \\ and $",
+				"D": "",
+			}
+			back, err := formats.UnmarshalDotenv(formats.MarshalDotenv(vars))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(back).To(Equal(vars))
+		})
+
+		It("reports unterminated quotes with a line number", func() {
+			_, err := formats.UnmarshalDotenv([]byte("A=1\nB=\"oops\n"))
+			Expect(err).To(MatchError(ContainSubstring("line")))
+		})
+
+		It("rejects invalid variable names", func() {
+			_, err := formats.UnmarshalDotenv([]byte("not valid=1\n"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package formats
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MarshalEditable renders the keys of a secret as a YAML map, for safe edit.
+// Multi-line values come out as literal blocks, so they can be edited as-is.
+func MarshalEditable(data map[string]string) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(data)
+}
+
+// UnmarshalEditable parses keys edited as a YAML map. Every value must be a
+// scalar, which is taken exactly as written, so that 0123 and yes stay that
+// way. Comments are ignored, and a document with no keys yields an empty map.
+func UnmarshalEditable(b []byte) (map[string]string, error) {
+	data := map[string]string{}
+	if err := yaml.UnmarshalStrict(b, &data); err != nil {
+		return nil, err
+	}
+	for key := range data {
+		if strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("keys cannot be empty")
+		}
+	}
+	return data, nil
+}
+
+// Comment turns text into YAML comment lines.
+func Comment(text string) []byte {
+	var b bytes.Buffer
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if line == "" {
+			b.WriteString("#\n")
+		} else {
+			b.WriteString("# " + line + "\n")
+		}
+	}
+	return b.Bytes()
+}
+
+// Uncommented strips the leading comment lines from b, as written by Comment.
+func Uncommented(b []byte) []byte {
+	for len(b) > 0 && b[0] == '#' {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			return nil
+		}
+		b = b[i+1:]
+	}
+	return b
+}
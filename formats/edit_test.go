@@ -0,0 +1,51 @@
+package formats_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
+)
+
+var _ = Describe("Editable secrets", func() {
+	It("round-trips values exactly", func() {
+		data := map[string]string{
+			"cert":   "-----BEGIN-----\nabc\n-----END-----\n",
+			"yes":    "yes",
+			"zip":    "0123",
+			"empty":  "",
+			"spaces": "  padded  ",
+		}
+		b, err := formats.MarshalEditable(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(b)).To(ContainSubstring("cert: |"))
+
+		back, err := formats.UnmarshalEditable(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(back).To(Equal(data))
+	})
+
+	It("takes scalars as written", func() {
+		data, err := formats.UnmarshalEditable([]byte("# a comment\nport: 5432\nratio: 1.10\nflag: on\nnothing:\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(map[string]string{"port": "5432", "ratio": "1.10", "flag": "on", "nothing": ""}))
+	})
+
+	It("yields no keys for a file of comments", func() {
+		data, err := formats.UnmarshalEditable(formats.Comment("Editing\n\nsecret/a"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(BeEmpty())
+	})
+
+	It("rejects nested values and duplicate keys", func() {
+		_, err := formats.UnmarshalEditable([]byte("a:\n  b: c\n"))
+		Expect(err).To(HaveOccurred())
+		_, err = formats.UnmarshalEditable([]byte("a: 1\na: 2\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("strips a leading comment", func() {
+		b := append(formats.Comment("line one\n\nline two"), []byte("a: 1\n# kept\n")...)
+		Expect(string(formats.Uncommented(b))).To(Equal("a: 1\n# kept\n"))
+	})
+})
@@ -0,0 +1,143 @@
+package formats
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var envPrefix = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ExecConfig declares the environment variables that safe exec runs a command
+// with.  Projects can keep one in a .safe-exec.yml file:
+//
+//	map:
+//	  DB_PASSWORD: secret/db:password
+//	paths:
+//	  - secret/app
+//	  - DB_=secret/db
+type ExecConfig struct {
+	//Map names the key that each variable is set to
+	Map map[string]string `yaml:"map"`
+	//Paths are each PATH or PREFIX=PATH: every key of every secret beneath
+	// PATH becomes a variable, named as by DotenvVars, with PREFIX in front
+	Paths []string `yaml:"paths"`
+}
+
+// ParseExecConfig parses and checks a .safe-exec.yml file.
+func ParseExecConfig(b []byte) (ExecConfig, error) {
+	var c ExecConfig
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+// AddMapping adds a NAME=path:key mapping to the config.
+func (c *ExecConfig) AddMapping(mapping string) error {
+	name, ref, found := strings.Cut(mapping, "=")
+	if !found {
+		return fmt.Errorf("Expected a NAME=path:key mapping, not `%s'", mapping)
+	}
+	if c.Map == nil {
+		c.Map = map[string]string{}
+	}
+	c.Map[name] = ref
+	return nil
+}
+
+// Validate checks that every variable name is valid, and that each one in Map
+// is set from a key.
+func (c ExecConfig) Validate() error {
+	for name, ref := range c.Map {
+		if !envValidName.MatchString(name) {
+			return fmt.Errorf("`%s' is not a valid environment variable name", name)
+		}
+		if !vault.PathHasKey(ref) {
+			return fmt.Errorf("%s is mapped to `%s', which has no key (expected path:key)", name, ref)
+		}
+	}
+	for _, entry := range c.Paths {
+		if _, path := SplitExecPath(entry); path == "" {
+			return fmt.Errorf("No path given in `%s'", entry)
+		}
+	}
+	return nil
+}
+
+// References returns the paths of the secrets and trees that the config sets
+// variables from.
+func (c ExecConfig) References() (secrets, trees []string) {
+	seen := map[string]bool{}
+	for _, ref := range c.Map {
+		path, _, version := vault.ParsePath(ref)
+		if path = vault.EncodePath(path, "", version); !seen[path] {
+			seen[path] = true
+			secrets = append(secrets, path)
+		}
+	}
+	sort.Strings(secrets)
+	for _, entry := range c.Paths {
+		_, path := SplitExecPath(entry)
+		trees = append(trees, path)
+	}
+	return secrets, trees
+}
+
+// SplitExecPath splits an entry of ExecConfig.Paths into the prefix of the
+// variables it sets, if there is one, and its path.
+func SplitExecPath(entry string) (prefix, path string) {
+	if prefix, path, found := strings.Cut(entry, "="); found && envPrefix.MatchString(prefix) {
+		return prefix, path
+	}
+	return "", entry
+}
+
+// ExecEnv looks up the variables that the config sets in src.  Variables in
+// Map take precedence over those from Paths, but two from Paths with the same
+// name are an error, as is a key that does not exist.
+func ExecEnv(c ExecConfig, src SecretSource) (map[string]string, error) {
+	env := map[string]string{}
+	origins := map[string]string{}
+	for _, entry := range c.Paths {
+		prefix, path := SplitExecPath(entry)
+		secrets, err := src.Tree(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(secrets) == 0 {
+			return nil, fmt.Errorf("No secrets found beneath `%s'", path)
+		}
+		vars, err := DotenvVars(secrets, path)
+		if err != nil {
+			return nil, err
+		}
+		for name, val := range vars {
+			name = prefix + name
+			if other, found := origins[name]; found {
+				return nil, fmt.Errorf("Both `%s' and `%s' set the variable %s", other, entry, name)
+			}
+			origins[name] = entry
+			env[name] = val
+		}
+	}
+
+	for name, ref := range c.Map {
+		path, key, version := vault.ParsePath(ref)
+		data, err := src.Secret(vault.EncodePath(path, "", version))
+		if err != nil {
+			return nil, err
+		}
+		val, found := data[key]
+		if !found {
+			return nil, fmt.Errorf("`%s' (for %s) not found", ref, name)
+		}
+		env[name] = val
+	}
+	return env, nil
+}
@@ -0,0 +1,93 @@
+package formats_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
+)
+
+var _ = Describe("safe exec configs", func() {
+	src := mapSource{
+		"secret/db":     {"user": "admin", "password": "p4ss"},
+		"secret/db^1":   {"password": "old"},
+		"secret/app/a":  {"key": "1"},
+		"secret/app/b":  {"key": "2"},
+		"secret/other":  {"key": "3"},
+		"secret/more/a": {"key": "4"},
+	}
+
+	It("parses .safe-exec.yml files", func() {
+		c, err := formats.ParseExecConfig([]byte(`
+map:
+  DB_PASSWORD: secret/db:password
+paths:
+  - secret/app
+  - DB_=secret/db
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Map).To(Equal(map[string]string{"DB_PASSWORD": "secret/db:password"}))
+		Expect(c.Paths).To(Equal([]string{"secret/app", "DB_=secret/db"}))
+	})
+
+	It("rejects mistakes", func() {
+		_, err := formats.ParseExecConfig([]byte("mapping: {}\n"))
+		Expect(err).To(HaveOccurred())
+
+		_, err = formats.ParseExecConfig([]byte("map: {DB: secret/db}\n"))
+		Expect(err).To(MatchError(ContainSubstring("has no key")))
+
+		_, err = formats.ParseExecConfig([]byte("map: {'1DB': secret/db:password}\n"))
+		Expect(err).To(MatchError(ContainSubstring("not a valid environment variable name")))
+
+		c := formats.ExecConfig{}
+		Expect(c.AddMapping("DB_PASSWORD")).ToNot(Succeed())
+	})
+
+	It("splits prefixes from paths", func() {
+		prefix, path := formats.SplitExecPath("DB_=secret/db")
+		Expect(prefix).To(Equal("DB_"))
+		Expect(path).To(Equal("secret/db"))
+
+		prefix, path = formats.SplitExecPath("secret/a=b")
+		Expect(prefix).To(Equal(""))
+		Expect(path).To(Equal("secret/a=b"))
+	})
+
+	It("looks up every variable", func() {
+		c := formats.ExecConfig{Paths: []string{"secret/app", "DB_=secret/db"}}
+		Expect(c.AddMapping("PASSWORD=secret/db:password")).To(Succeed())
+		Expect(c.AddMapping("OLD=secret/db:password^1")).To(Succeed())
+		Expect(c.AddMapping("DB_USER=secret/other:key")).To(Succeed())
+
+		secrets, trees := c.References()
+		Expect(secrets).To(Equal([]string{"secret/db", "secret/db^1", "secret/other"}))
+		Expect(trees).To(Equal([]string{"secret/app", "secret/db"}))
+
+		env, err := formats.ExecEnv(c, src)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(env).To(Equal(map[string]string{
+			"A_KEY":       "1",
+			"B_KEY":       "2",
+			"DB_PASSWORD": "p4ss",
+			"DB_USER":     "3",
+			"PASSWORD":    "p4ss",
+			"OLD":         "old",
+		}))
+	})
+
+	It("fails on missing keys and clashing variables", func() {
+		c := formats.ExecConfig{}
+		Expect(c.AddMapping("X=secret/db:nope")).To(Succeed())
+		_, err := formats.ExecEnv(c, src)
+		Expect(err).To(MatchError(ContainSubstring("`secret/db:nope' (for X) not found")))
+
+		c = formats.ExecConfig{Paths: []string{"secret/app", "secret/more"}}
+		_, err = formats.ExecEnv(c, src)
+		Expect(err).To(MatchError(ContainSubstring("set the variable A_KEY")))
+
+		c = formats.ExecConfig{Paths: []string{"secret/nope"}}
+		_, err = formats.ExecEnv(c, src)
+		Expect(err).To(MatchError(ContainSubstring("No secrets found")))
+	})
+})
@@ -0,0 +1,68 @@
+// Package formats converts secrets between their Vault representation and the
+// file formats used by other tools, for safe export and safe import.
+package formats
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+// Secret is a single Vault path and the key/value pairs stored there.
+type Secret struct {
+	Path string
+	Data map[string]string
+}
+
+// Latest returns the latest version of each entry as a Secret, skipping
+// entries for which no version data was fetched.
+func Latest(secrets vault.Secrets) []Secret {
+	ret := make([]Secret, 0, len(secrets))
+	for _, s := range secrets {
+		if len(s.Versions) == 0 {
+			continue
+		}
+
+		data := s.Versions[len(s.Versions)-1].Data
+		thisSecret := Secret{Path: s.Path, Data: map[string]string{}}
+		for _, key := range data.Keys() {
+			thisSecret.Data[key] = data.Get(key)
+		}
+		ret = append(ret, thisSecret)
+	}
+
+	return ret
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// relativeName returns the path relative to the deepest of the given roots
+// that contains it. A path that is itself a root is named after its last
+// segment, and one outside all roots keeps its full path.
+func relativeName(path string, roots []string) string {
+	path = vault.Canonicalize(path)
+	best := ""
+	for _, root := range roots {
+		root = vault.Canonicalize(root)
+		if (path == root || strings.HasPrefix(path, root+"/")) && len(root) > len(best) {
+			best = root
+		}
+	}
+
+	switch best {
+	case "":
+		return path
+	case path:
+		return path[strings.LastIndex(path, "/")+1:]
+	default:
+		return strings.TrimPrefix(path, best+"/")
+	}
+}
@@ -0,0 +1,173 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+	"gopkg.in/yaml.v2"
+)
+
+// K8sPathAnnotation records the Vault path a Secret manifest was exported
+// from, so that importing it puts it back in the same place.
+const K8sPathAnnotation = "safe/vault-path"
+
+const (
+	//K8sNameRelative names manifests after the path relative to the export root
+	K8sNameRelative = "relative"
+	//K8sNameFull names manifests after the full path, mount included
+	K8sNameFull = "full"
+	//K8sNameBasename names manifests after the last path segment only
+	K8sNameBasename = "basename"
+)
+
+// K8sOpts controls how Vault paths are turned into Kubernetes Secret manifests.
+type K8sOpts struct {
+	//Namespace is set on every manifest, if non-empty
+	Namespace string
+	//NameStyle is one of the K8sName* constants. Defaults to K8sNameRelative
+	NameStyle string
+	//NamePrefix is prepended to every generated name
+	NamePrefix string
+	//Roots are the paths the export was rooted at; used by K8sNameRelative
+	Roots []string
+}
+
+type k8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+	StringData map[string]string `yaml:"stringData,omitempty"`
+}
+
+type k8sMetadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+var k8sInvalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+var k8sRepeatedDashes = regexp.MustCompile(`-{2,}`)
+var k8sValidKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// K8sName mangles the given Vault path into a valid Kubernetes object name,
+// according to the given options.
+func K8sName(path string, opts K8sOpts) string {
+	path = vault.Canonicalize(path)
+	name := path
+
+	switch opts.NameStyle {
+	case K8sNameBasename:
+		name = path[strings.LastIndex(path, "/")+1:]
+	case K8sNameFull:
+	default:
+		name = relativeName(path, opts.Roots)
+	}
+
+	name = strings.ToLower(opts.NamePrefix + strings.ReplaceAll(name, "/", "-"))
+	name = k8sInvalidNameChars.ReplaceAllString(name, "-")
+	name = k8sRepeatedDashes.ReplaceAllString(name, "-")
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return strings.Trim(name, "-.")
+}
+
+// MarshalK8s renders one Secret manifest per secret, as a multi-document
+// YAML stream.
+func MarshalK8s(secrets []Secret, opts K8sOpts) ([]byte, error) {
+	var out bytes.Buffer
+	seen := map[string]string{}
+
+	for _, s := range secrets {
+		name := K8sName(s.Path, opts)
+		if name == "" {
+			return nil, fmt.Errorf("Cannot derive a Kubernetes name from path `%s'", s.Path)
+		}
+		if other, found := seen[name]; found {
+			return nil, fmt.Errorf("Paths `%s' and `%s' would both be named `%s'; try a different --name-style", other, s.Path, name)
+		}
+		seen[name] = s.Path
+
+		manifest := k8sSecret{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Type:       "Opaque",
+			Metadata: k8sMetadata{
+				Name:        name,
+				Namespace:   opts.Namespace,
+				Annotations: map[string]string{K8sPathAnnotation: s.Path},
+			},
+			Data: map[string]string{},
+		}
+
+		for _, key := range sortedKeys(s.Data) {
+			if !k8sValidKey.MatchString(key) {
+				return nil, fmt.Errorf("Key `%s' of `%s' is not a valid Kubernetes Secret key", key, s.Path)
+			}
+			manifest.Data[key] = base64.StdEncoding.EncodeToString([]byte(s.Data[key]))
+		}
+
+		b, err := yaml.Marshal(manifest)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString("---\n")
+		out.Write(b)
+	}
+
+	return out.Bytes(), nil
+}
+
+// UnmarshalK8s reads a multi-document YAML stream of Secret manifests. Each
+// manifest is placed at the path recorded in its K8sPathAnnotation, or at
+// prefix/name if it has none. Documents of any other kind are skipped.
+func UnmarshalK8s(b []byte, prefix string) ([]Secret, error) {
+	var ret []Secret
+
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	for i := 1; ; i++ {
+		var manifest k8sSecret
+		err := decoder.Decode(&manifest)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse document #%d: %w", i, err)
+		}
+		if manifest.Kind != "Secret" {
+			continue
+		}
+
+		path := manifest.Metadata.Annotations[K8sPathAnnotation]
+		if path == "" {
+			if prefix == "" {
+				return nil, fmt.Errorf("Secret `%s' has no %s annotation, and no path prefix was given", manifest.Metadata.Name, K8sPathAnnotation)
+			}
+			path = vault.Canonicalize(prefix) + "/" + manifest.Metadata.Name
+		}
+
+		s := Secret{Path: path, Data: map[string]string{}}
+		for key, value := range manifest.Data {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("Key `%s' of Secret `%s' is not valid base64: %w", key, manifest.Metadata.Name, err)
+			}
+			s.Data[key] = string(decoded)
+		}
+		//stringData wins over data, as it does when Kubernetes applies it
+		for key, value := range manifest.StringData {
+			s.Data[key] = value
+		}
+
+		ret = append(ret, s)
+	}
+
+	return ret, nil
+}
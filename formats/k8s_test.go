@@ -0,0 +1,100 @@
+package formats_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
+)
+
+var _ = Describe("Kubernetes Secrets", func() {
+	Describe("K8sName", func() {
+		It("names relative to the deepest export root by default", func() {
+			opts := formats.K8sOpts{Roots: []string{"secret", "secret/app"}}
+			Expect(formats.K8sName("secret/app/db/creds", opts)).To(Equal("db-creds"))
+		})
+
+		It("uses the basename when the path is the root itself", func() {
+			opts := formats.K8sOpts{Roots: []string{"secret/app/db"}}
+			Expect(formats.K8sName("secret/app/db", opts)).To(Equal("db"))
+		})
+
+		It("uses the full path for the full style", func() {
+			opts := formats.K8sOpts{NameStyle: formats.K8sNameFull, Roots: []string{"secret/app"}}
+			Expect(formats.K8sName("secret/app/db", opts)).To(Equal("secret-app-db"))
+		})
+
+		It("uses the last segment for the basename style", func() {
+			opts := formats.K8sOpts{NameStyle: formats.K8sNameBasename}
+			Expect(formats.K8sName("secret/app/db", opts)).To(Equal("db"))
+		})
+
+		It("lowercases, replaces invalid characters and applies the prefix", func() {
+			opts := formats.K8sOpts{NameStyle: formats.K8sNameFull, NamePrefix: "Prod-"}
+			Expect(formats.K8sName("secret/My_App//DB", opts)).To(Equal("prod-secret-my-app-db"))
+		})
+	})
+
+	Describe("MarshalK8s / UnmarshalK8s", func() {
+		secrets := []formats.Secret{
+			{Path: "secret/app/db", Data: map[string]string{"password": "s3cr3t", "cert": "line1\nline2\n"}},
+			{Path: "secret/app/api", Data: map[string]string{"token": "abc"}},
+		}
+
+		It("round-trips through the path annotation", func() {
+			b, err := formats.MarshalK8s(secrets, formats.K8sOpts{Namespace: "apps", Roots: []string{"secret/app"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring("namespace: apps"))
+			Expect(string(b)).To(ContainSubstring("name: db"))
+			Expect(string(b)).To(ContainSubstring("password: czNjcjN0"))
+
+			back, err := formats.UnmarshalK8s(b, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(back).To(ConsistOf(secrets))
+		})
+
+		It("refuses to give two paths the same name", func() {
+			_, err := formats.MarshalK8s([]formats.Secret{
+				{Path: "secret/a/db", Data: map[string]string{"k": "v"}},
+				{Path: "secret/b/db", Data: map[string]string{"k": "v"}},
+			}, formats.K8sOpts{NameStyle: formats.K8sNameBasename})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("refuses keys Kubernetes cannot store", func() {
+			_, err := formats.MarshalK8s([]formats.Secret{
+				{Path: "secret/a", Data: map[string]string{"bad key": "v"}},
+			}, formats.K8sOpts{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("falls back to prefix/name and honors stringData", func() {
+			input := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+data:
+  user: YWRtaW4=
+  password: b2xk
+stringData:
+  password: new
+`
+			back, err := formats.UnmarshalK8s([]byte(input), "secret/imported/")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(back).To(HaveLen(1))
+			Expect(back[0].Path).To(Equal("secret/imported/db"))
+			Expect(back[0].Data).To(Equal(map[string]string{"user": "admin", "password": "new"}))
+		})
+
+		It("requires a prefix when the annotation is missing", func() {
+			_, err := formats.UnmarshalK8s([]byte("kind: Secret\nmetadata:\n  name: db\n"), "")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
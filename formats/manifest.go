@@ -0,0 +1,233 @@
+package formats
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ManifestVersion is the current version of the manifest format.
+const ManifestVersion = 1
+
+// Manifest records what a safe export contains, so that the export can later
+// be checked for completeness and tampering. It is signed with Ed25519.
+type Manifest struct {
+	Version   int       `json:"manifest_version"`
+	CreatedAt time.Time `json:"created_at"`
+	//Roots are the paths that were exported
+	Roots []string `json:"roots"`
+	//AllVersions and Deleted record how the export was taken, so that the
+	// live tree can be read the same way when verifying against it
+	AllVersions bool `json:"all_versions"`
+	Deleted     bool `json:"deleted"`
+	//ExportVersion is the version of the safe export format that was written
+	ExportVersion uint `json:"export_version"`
+	//ExportSHA256 is the checksum of the export file, byte for byte
+	ExportSHA256 string          `json:"export_sha256"`
+	Entries      []ManifestEntry `json:"entries"`
+	PublicKey    string          `json:"public_key,omitempty"`
+	Signature    string          `json:"signature,omitempty"`
+}
+
+// ManifestEntry describes a single exported path.
+type ManifestEntry struct {
+	Path     string `json:"path"`
+	Versions int    `json:"versions"`
+	SHA256   string `json:"sha256"`
+}
+
+// SecretVersion is a single version of a secret, as far as the manifest is
+// concerned. Data is ignored for destroyed versions.
+type SecretVersion struct {
+	Deleted   bool
+	Destroyed bool
+	Data      map[string]string
+}
+
+// ManifestEntries computes the manifest entry of each of the given secrets,
+// keyed by path, sorted by path.
+func ManifestEntries(secrets map[string][]SecretVersion) []ManifestEntry {
+	entries := make([]ManifestEntry, 0, len(secrets))
+	for path, versions := range secrets {
+		entries = append(entries, ManifestEntry{
+			Path:     path,
+			Versions: len(versions),
+			SHA256:   HashVersions(versions),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// HashVersions returns the hex SHA-256 of the canonical form of the given
+// versions: a JSON array holding the state and (sorted) data of each version,
+// oldest first.
+func HashVersions(versions []SecretVersion) string {
+	type canonical struct {
+		State string            `json:"state"`
+		Data  map[string]string `json:"data"`
+	}
+
+	l := make([]canonical, 0, len(versions))
+	for _, v := range versions {
+		c := canonical{State: "alive", Data: v.Data}
+		switch {
+		case v.Destroyed:
+			c = canonical{State: "destroyed"}
+		case v.Deleted:
+			c.State = "deleted"
+		}
+		if c.Data == nil {
+			c.Data = map[string]string{}
+		}
+		l = append(l, c)
+	}
+
+	//encoding/json writes map keys in sorted order, which is all the
+	// canonicalization we need
+	b, _ := json.Marshal(l)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// SHA256 returns the hex SHA-256 of the given bytes.
+func SHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// signedBytes is what the signature covers: the manifest, sans signature.
+func (m Manifest) signedBytes() []byte {
+	m.Signature = ""
+	b, _ := json.Marshal(m)
+	return b
+}
+
+// Sign signs the manifest with the given key, recording its public half in
+// the manifest.
+func (m *Manifest) Sign(key ed25519.PrivateKey) {
+	m.PublicKey = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	m.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, m.signedBytes()))
+}
+
+// VerifySignature checks the signature of the manifest against the given
+// public key. If pub is nil, the key recorded in the manifest is used, which
+// only proves the manifest was not damaged, not who signed it.
+func (m *Manifest) VerifySignature(pub ed25519.PublicKey) error {
+	if m.Signature == "" {
+		return fmt.Errorf("manifest is not signed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil {
+		return fmt.Errorf("manifest signature is malformed: %s", err)
+	}
+
+	if pub == nil {
+		b, err := base64.StdEncoding.DecodeString(m.PublicKey)
+		if err != nil || len(b) != ed25519.PublicKeySize {
+			return fmt.Errorf("manifest public key is malformed")
+		}
+		pub = ed25519.PublicKey(b)
+	}
+
+	if !ed25519.Verify(pub, m.signedBytes(), sig) {
+		return fmt.Errorf("manifest signature does not match")
+	}
+	return nil
+}
+
+// ManifestDiff lists the paths that differ between a manifest and what was
+// actually found.
+type ManifestDiff struct {
+	Missing []string `json:"missing" yaml:"missing"`
+	Extra   []string `json:"extra" yaml:"extra"`
+	Changed []string `json:"changed" yaml:"changed"`
+}
+
+// Empty returns true if nothing differs.
+func (d ManifestDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Changed) == 0
+}
+
+// Compare checks the given entries against those of the manifest. Paths in
+// the manifest but not in actual are missing; the converse are extra.
+func (m *Manifest) Compare(actual []ManifestEntry) ManifestDiff {
+	found := map[string]ManifestEntry{}
+	for _, e := range actual {
+		found[e.Path] = e
+	}
+
+	diff := ManifestDiff{Missing: []string{}, Extra: []string{}, Changed: []string{}}
+	expected := map[string]bool{}
+	for _, e := range m.Entries {
+		expected[e.Path] = true
+		got, ok := found[e.Path]
+		switch {
+		case !ok:
+			diff.Missing = append(diff.Missing, e.Path)
+		case got.Versions != e.Versions || got.SHA256 != e.SHA256:
+			diff.Changed = append(diff.Changed, e.Path)
+		}
+	}
+	for _, e := range actual {
+		if !expected[e.Path] {
+			diff.Extra = append(diff.Extra, e.Path)
+		}
+	}
+
+	sort.Strings(diff.Missing)
+	sort.Strings(diff.Extra)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// ParseEd25519PrivateKey reads a PEM-encoded PKCS#8 Ed25519 private key, as
+// written by `openssl genpkey -algorithm ed25519`.
+func ParseEd25519PrivateKey(b []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("not a PEM-encoded private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an Ed25519 private key")
+	}
+	return priv, nil
+}
+
+// ParseEd25519PublicKey reads a PEM-encoded PKIX Ed25519 public key. The
+// public half of a PEM-encoded private key is accepted as well.
+func ParseEd25519PublicKey(b []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("not a PEM-encoded public key")
+	}
+	if block.Type == "PRIVATE KEY" {
+		priv, err := ParseEd25519PrivateKey(b)
+		if err != nil {
+			return nil, err
+		}
+		return priv.Public().(ed25519.PublicKey), nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("not an Ed25519 public key")
+	}
+	return pub, nil
+}
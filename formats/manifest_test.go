@@ -0,0 +1,134 @@
+package formats_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
+)
+
+var _ = Describe("Manifests", func() {
+	secrets := func() map[string][]formats.SecretVersion {
+		return map[string][]formats.SecretVersion{
+			"secret/b": {{Data: map[string]string{"k": "v"}}},
+			"secret/a": {
+				{Destroyed: true, Data: map[string]string{"ignored": "x"}},
+				{Deleted: true, Data: map[string]string{"k": "old"}},
+				{Data: map[string]string{"k": "new", "z": "1"}},
+			},
+		}
+	}
+
+	Describe("HashVersions", func() {
+		It("is stable regardless of map ordering and destroyed data", func() {
+			a := formats.HashVersions([]formats.SecretVersion{
+				{Destroyed: true},
+				{Data: map[string]string{"a": "1", "b": "2"}},
+			})
+			b := formats.HashVersions([]formats.SecretVersion{
+				{Destroyed: true, Data: map[string]string{"junk": "x"}},
+				{Data: map[string]string{"b": "2", "a": "1"}},
+			})
+			Expect(a).To(Equal(b))
+			Expect(a).To(HaveLen(64))
+		})
+
+		It("changes when the state of a version changes", func() {
+			Expect(formats.HashVersions([]formats.SecretVersion{{Data: map[string]string{"a": "1"}}})).
+				ToNot(Equal(formats.HashVersions([]formats.SecretVersion{{Deleted: true, Data: map[string]string{"a": "1"}}})))
+		})
+	})
+
+	Describe("ManifestEntries", func() {
+		It("lists each path with its version count, sorted", func() {
+			entries := formats.ManifestEntries(secrets())
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].Path).To(Equal("secret/a"))
+			Expect(entries[0].Versions).To(Equal(3))
+			Expect(entries[1].Path).To(Equal("secret/b"))
+			Expect(entries[1].Versions).To(Equal(1))
+		})
+	})
+
+	Describe("signatures", func() {
+		var pub ed25519.PublicKey
+		var priv ed25519.PrivateKey
+		var m *formats.Manifest
+
+		BeforeEach(func() {
+			var err error
+			pub, priv, err = ed25519.GenerateKey(rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			m = &formats.Manifest{Version: formats.ManifestVersion, Roots: []string{"secret"}, Entries: formats.ManifestEntries(secrets())}
+			m.Sign(priv)
+		})
+
+		It("verifies against the signing key and the embedded key", func() {
+			Expect(m.VerifySignature(pub)).To(Succeed())
+			Expect(m.VerifySignature(nil)).To(Succeed())
+		})
+
+		It("detects tampering", func() {
+			m.Entries[0].SHA256 = formats.SHA256([]byte("forged"))
+			Expect(m.VerifySignature(pub)).ToNot(Succeed())
+		})
+
+		It("rejects a manifest re-signed with another key", func() {
+			_, other, _ := ed25519.GenerateKey(rand.Reader)
+			m.Sign(other)
+			Expect(m.VerifySignature(nil)).To(Succeed())
+			Expect(m.VerifySignature(pub)).ToNot(Succeed())
+		})
+	})
+
+	Describe("Compare", func() {
+		It("reports missing, extra and changed paths", func() {
+			m := &formats.Manifest{Entries: formats.ManifestEntries(secrets())}
+			actual := secrets()
+			delete(actual, "secret/b")
+			actual["secret/a"][2].Data["k"] = "tampered"
+			actual["secret/c"] = []formats.SecretVersion{{Data: map[string]string{}}}
+
+			diff := m.Compare(formats.ManifestEntries(actual))
+			Expect(diff.Missing).To(Equal([]string{"secret/b"}))
+			Expect(diff.Extra).To(Equal([]string{"secret/c"}))
+			Expect(diff.Changed).To(Equal([]string{"secret/a"}))
+			Expect(diff.Empty()).To(BeFalse())
+			Expect(m.Compare(m.Entries).Empty()).To(BeTrue())
+		})
+	})
+
+	Describe("key parsing", func() {
+		It("reads PEM-encoded Ed25519 keys", func() {
+			pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+			der, err := x509.MarshalPKCS8PrivateKey(priv)
+			Expect(err).ToNot(HaveOccurred())
+			privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+			der, err = x509.MarshalPKIXPublicKey(pub)
+			Expect(err).ToNot(HaveOccurred())
+			pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+			parsedPriv, err := formats.ParseEd25519PrivateKey(privPEM)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsedPriv).To(Equal(priv))
+
+			parsedPub, err := formats.ParseEd25519PublicKey(pubPEM)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsedPub).To(Equal(pub))
+
+			parsedPub, err = formats.ParseEd25519PublicKey(privPEM)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsedPub).To(Equal(pub))
+		})
+
+		It("rejects garbage", func() {
+			_, err := formats.ParseEd25519PrivateKey([]byte("nope"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package formats_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Formats Suite")
+}
@@ -0,0 +1,222 @@
+package formats
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+// SecretSource is where a Template or ExecEnv looks up the secrets it needs.
+type SecretSource interface {
+	//Secret returns the keys of the secret at path (which may have a
+	// ^version), or nil if there is no secret there
+	Secret(path string) (map[string]string, error)
+	//Tree returns every secret beneath prefix
+	Tree(prefix string) ([]Secret, error)
+}
+
+// Template is a Go text/template that can look secrets up with these
+// functions:
+//
+//	secret "path:key"     the value of one key
+//	secretMap "path"      every key of a secret, as a map
+//	tree "prefix"         every secret beneath prefix, as a map of maps,
+//	                      keyed by path
+//	b64enc VALUE          VALUE, base64-encoded
+//	toJSON VALUE          VALUE, as JSON
+type Template struct {
+	tmpl *template.Template
+}
+
+// ParseTemplate parses the text of a template. Referring to a key that a map
+// does not have is an error when it is rendered, not an empty string.
+func ParseTemplate(name, text string) (*Template, error) {
+	r := &templateRenderer{}
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(r.funcs()).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// References returns the paths of the secrets and trees that the template
+// names outright, so that they can all be fetched before rendering it.
+// Those named by anything other than a string literal are left out, and
+// looked up while rendering instead.
+func (t *Template) References() (secrets, trees []string) {
+	seen := map[string]bool{}
+	add := func(list *[]string, path string) {
+		if !seen[path] {
+			seen[path] = true
+			*list = append(*list, path)
+		}
+	}
+
+	var walk func(parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, child := range n.Nodes {
+					walk(child)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(&n.BranchNode)
+		case *parse.RangeNode:
+			walk(&n.BranchNode)
+		case *parse.WithNode:
+			walk(&n.BranchNode)
+		case *parse.BranchNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, cmd := range n.Cmds {
+					walk(cmd)
+				}
+			}
+		case *parse.CommandNode:
+			if len(n.Args) == 2 {
+				fn, isIdent := n.Args[0].(*parse.IdentifierNode)
+				arg, isString := n.Args[1].(*parse.StringNode)
+				if isIdent && isString {
+					switch fn.Ident {
+					case "secret", "secretMap":
+						path, _, version := vault.ParsePath(arg.Text)
+						add(&secrets, vault.EncodePath(path, "", version))
+					case "tree":
+						add(&trees, arg.Text)
+					}
+				}
+			}
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		}
+	}
+
+	for _, tmpl := range t.tmpl.Templates() {
+		if tmpl.Tree != nil {
+			walk(tmpl.Tree.Root)
+		}
+	}
+	return secrets, trees
+}
+
+// Render renders the template to out, looking secrets up in src. Referring to
+// a secret or key that does not exist is an error.
+func (t *Template) Render(out io.Writer, src SecretSource) error {
+	return t.execute(out, &templateRenderer{src: src})
+}
+
+// Check renders the template without writing it anywhere, and returns every
+// secret and key it refers to that does not exist, rather than stopping at the
+// first of them. Nothing in the error it returns, if any, is a secret.
+func (t *Template) Check(src SecretSource) ([]string, error) {
+	r := &templateRenderer{src: src, check: true}
+	err := t.execute(io.Discard, r)
+	return r.missing, err
+}
+
+func (t *Template) execute(out io.Writer, r *templateRenderer) error {
+	tmpl, err := t.tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	return tmpl.Funcs(r.funcs()).Execute(out, nil)
+}
+
+type templateRenderer struct {
+	src     SecretSource
+	check   bool
+	missing []string
+}
+
+func (r *templateRenderer) funcs() template.FuncMap {
+	return template.FuncMap{
+		"secret":    r.secret,
+		"secretMap": r.secretMap,
+		"tree":      r.tree,
+		"b64enc": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"toJSON": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+}
+
+// notFound is an error for a secret or key that does not exist, unless the
+// template is being checked, when it is noted down instead.
+func (r *templateRenderer) notFound(what string) error {
+	if r.check {
+		r.missing = append(r.missing, what)
+		return nil
+	}
+	return fmt.Errorf("`%s' not found", what)
+}
+
+func (r *templateRenderer) secret(ref string) (string, error) {
+	data, key, err := r.lookup(ref)
+	if err != nil || data == nil {
+		return "", err
+	}
+	if key == "" {
+		return "", fmt.Errorf("secret `%s' has no key; use secretMap for the whole secret", ref)
+	}
+	val, found := data[key]
+	if !found {
+		return "", r.notFound(ref)
+	}
+	return val, nil
+}
+
+func (r *templateRenderer) secretMap(ref string) (map[string]string, error) {
+	data, key, err := r.lookup(ref)
+	if err != nil || data == nil {
+		return map[string]string{}, err
+	}
+	if key != "" {
+		return nil, fmt.Errorf("secretMap `%s' has a key; use secret for just that key", ref)
+	}
+	return data, nil
+}
+
+// lookup returns the keys of the secret that ref refers to, and the key it
+// names, if any. A secret that does not exist is nil.
+func (r *templateRenderer) lookup(ref string) (map[string]string, string, error) {
+	path, key, version := vault.ParsePath(ref)
+	data, err := r.src.Secret(vault.EncodePath(path, "", version))
+	if err == nil && data == nil {
+		err = r.notFound(vault.EncodePath(path, "", version))
+	}
+	return data, key, err
+}
+
+func (r *templateRenderer) tree(prefix string) (map[string]map[string]string, error) {
+	secrets, err := r.src.Tree(prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(secrets) == 0 {
+		return map[string]map[string]string{}, r.notFound(prefix)
+	}
+
+	tree := make(map[string]map[string]string, len(secrets))
+	for _, s := range secrets {
+		tree[s.Path] = s.Data
+	}
+	return tree, nil
+}
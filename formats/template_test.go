@@ -0,0 +1,103 @@
+package formats_test
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/formats"
+)
+
+// mapSource looks secrets up in a map of paths to their keys.
+type mapSource map[string]map[string]string
+
+func (m mapSource) Secret(path string) (map[string]string, error) {
+	return m[path], nil
+}
+
+func (m mapSource) Tree(prefix string) ([]formats.Secret, error) {
+	var secrets []formats.Secret
+	for path, data := range m {
+		if (path == prefix || strings.HasPrefix(path, prefix+"/")) && !strings.Contains(path, "^") {
+			secrets = append(secrets, formats.Secret{Path: path, Data: data})
+		}
+	}
+	return secrets, nil
+}
+
+var _ = Describe("Templates", func() {
+	src := mapSource{
+		"secret/db":        {"user": "admin", "password": "p4ss"},
+		"secret/db^1":      {"user": "admin", "password": "old"},
+		"secret/app/a":     {"k": "1"},
+		"secret/app/b/c":   {"k": "2"},
+		"secret/elsewhere": {"k": "3"},
+	}
+
+	render := func(text string) (string, error) {
+		t, err := formats.ParseTemplate("test", text)
+		Expect(err).ToNot(HaveOccurred())
+		var out bytes.Buffer
+		err = t.Render(&out, src)
+		return out.String(), err
+	}
+
+	It("looks up single keys", func() {
+		out, err := render(`{{ secret "secret/db:user" }}:{{ secret "secret/db:password^1" }}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("admin:old"))
+	})
+
+	It("looks up whole secrets", func() {
+		out, err := render(`{{ range $k, $v := secretMap "secret/db" }}{{ $k }}={{ $v }};{{ end }}{{ (secretMap "secret/db/").user }}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("password=p4ss;user=admin;admin"))
+	})
+
+	It("looks up trees of secrets", func() {
+		out, err := render(`{{ range $path, $data := tree "secret/app" }}{{ $path }}:{{ $data.k }} {{ end }}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("secret/app/a:1 secret/app/b/c:2 "))
+	})
+
+	It("encodes values", func() {
+		out, err := render(`{{ secret "secret/db:user" | b64enc }} {{ secretMap "secret/app/a" | toJSON }}`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(`YWRtaW4= {"k":"1"}`))
+	})
+
+	It("fails on missing secrets and keys", func() {
+		_, err := render(`{{ secret "secret/db:nope" }}`)
+		Expect(err).To(MatchError(ContainSubstring("`secret/db:nope' not found")))
+
+		_, err = render(`{{ secretMap "secret/nope" }}`)
+		Expect(err).To(MatchError(ContainSubstring("`secret/nope' not found")))
+
+		_, err = render(`{{ (secretMap "secret/db").nope }}`)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("finds the secrets and trees it names", func() {
+		t, err := formats.ParseTemplate("test", `
+{{ define "inner" }}{{ secretMap "secret/x/" }}{{ end }}
+{{ if secret "secret/db:user" }}{{ range tree "secret/app" }}{{ end }}{{ else }}{{ secret "secret/db:password^1" }}{{ end }}
+{{ with $p := "secret/dynamic" }}{{ secretMap $p }}{{ end }}
+{{ template "inner" }}{{ printf "%s" (secret "secret/db:password") }}`)
+		Expect(err).ToNot(HaveOccurred())
+
+		secrets, trees := t.References()
+		Expect(secrets).To(ConsistOf("secret/db", "secret/db^1", "secret/x"))
+		Expect(trees).To(ConsistOf("secret/app"))
+	})
+
+	It("lists everything missing when checking", func() {
+		t, err := formats.ParseTemplate("test", `{{ secret "secret/db:nope" }}{{ secret "secret/db:user" }}{{ secretMap "secret/nope" }}{{ tree "secret/none" }}`)
+		Expect(err).ToNot(HaveOccurred())
+
+		missing, err := t.Check(src)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing).To(Equal([]string{"secret/db:nope", "secret/nope", "secret/none"}))
+	})
+})
@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"os"
+	"time"
 
 	fmt "github.com/jhunt/go-ansi"
 	"github.com/jhunt/go-cli"
@@ -10,6 +11,7 @@ import (
 	"github.com/SomeBlackMagic/vault-cli-manager/app"
 	"github.com/SomeBlackMagic/vault-cli-manager/cmd"
 	"github.com/SomeBlackMagic/vault-cli-manager/rc"
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
 )
 
 // Version is set at build time via -ldflags "-X main.Version=...".
@@ -67,8 +69,24 @@ func main() {
 			os.Setenv("SAFE_SKIP_VERIFY", "1")
 		}
 
+		os.Unsetenv("SAFE_WORKERS")
+		if opt.Workers != "" {
+			os.Setenv("SAFE_WORKERS", opt.Workers)
+		}
+
 		defer rc.Cleanup()
+		start := time.Now()
+		requests, throttled := vault.RequestMetrics().Requests(), vault.RequestMetrics().Throttled()
 		err = r.Execute(p.Command, p.Args...)
+		if opt.Timing {
+			metrics := vault.RequestMetrics()
+			fmt.Fprintf(os.Stderr, "@C{safe %s} took @G{%s} and made @G{%d} requests to Vault",
+				p.Command, time.Since(start).Round(time.Millisecond), metrics.Requests()-requests)
+			if n := metrics.Throttled() - throttled; n > 0 {
+				fmt.Fprintf(os.Stderr, " (@Y{%d} throttled)", n)
+			}
+			fmt.Fprintf(os.Stderr, "\n")
+		}
 		if err != nil {
 			var usageErr *app.UsageError
 			if errors.As(err, &usageErr) {
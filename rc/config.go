@@ -24,16 +24,43 @@ func (cm *CleanupManager) Add(path string) {
 	cm.files = append(cm.files, path)
 }
 
-// Cleanup removes all registered temporary files.
+// Cleanup shreds all registered temporary files.
 func (cm *CleanupManager) Cleanup() {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 	for _, f := range cm.files {
-		_ = os.Remove(f)
+		shred(f)
 	}
 	cm.files = nil
 }
 
+// Shred shreds a registered temporary file now, rather than waiting for
+// Cleanup, and stops tracking it.
+func (cm *CleanupManager) Shred(path string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	for i, f := range cm.files {
+		if f == path {
+			cm.files = append(cm.files[:i], cm.files[i+1:]...)
+			break
+		}
+	}
+	shred(path)
+}
+
+// shred overwrites the file at path with zeros before removing it, so that
+// what it held is not left behind on disk.
+func shred(path string) {
+	if f, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+		if info, err := f.Stat(); err == nil {
+			_, _ = f.Write(make([]byte, info.Size()))
+			_ = f.Sync()
+		}
+		f.Close()
+	}
+	_ = os.Remove(path)
+}
+
 var defaultCleanupManager = &CleanupManager{}
 
 type Config struct {
@@ -54,6 +81,16 @@ type Vault struct {
 	SkipVerify  bool     `yaml:"skip_verify,omitempty"`
 	NoStrongbox bool     `yaml:"no_strongbox,omitempty"`
 	Namespace   string   `yaml:"namespace,omitempty"`
+	Retry       *Retry   `yaml:"retry,omitempty"`
+	Workers     string   `yaml:"workers,omitempty"`
+}
+
+// Retry configures how requests to a target that fail transiently are
+// retried. Anything left out keeps its default.
+type Retry struct {
+	MaxAttempts int    `yaml:"max_attempts,omitempty"`
+	MinBackoff  string `yaml:"min_backoff,omitempty"`
+	MaxBackoff  string `yaml:"max_backoff,omitempty"`
 }
 
 type oldConfig struct {
@@ -226,6 +263,20 @@ func (c *Config) Apply(use string) error {
 		if v.Namespace != "" {
 			os.Setenv("VAULT_NAMESPACE", v.Namespace)
 		}
+		//This stays separate from SAFE_WORKERS, so that --workers wins
+		os.Setenv("SAFE_TARGET_WORKERS", v.Workers)
+		//Retry settings exported by the user win over those of the target
+		if v.Retry != nil {
+			if v.Retry.MaxAttempts != 0 {
+				setenvDefault("SAFE_RETRY_ATTEMPTS", fmt.Sprintf("%d", v.Retry.MaxAttempts))
+			}
+			if v.Retry.MinBackoff != "" {
+				setenvDefault("SAFE_RETRY_MIN_BACKOFF", v.Retry.MinBackoff)
+			}
+			if v.Retry.MaxBackoff != "" {
+				setenvDefault("SAFE_RETRY_MAX_BACKOFF", v.Retry.MaxBackoff)
+			}
+		}
 	} else {
 		if os.Getenv("VAULT_TOKEN") == "" {
 			tokenFile := fmt.Sprintf("%s/.vault-token", os.Getenv("HOME"))
@@ -238,6 +289,14 @@ func (c *Config) Apply(use string) error {
 	return nil
 }
 
+// setenvDefault sets the environment variable name to value, unless it is
+// already set.
+func setenvDefault(name, value string) {
+	if os.Getenv(name) == "" {
+		os.Setenv(name, value)
+	}
+}
+
 func (c *Config) SetCurrent(alias string, reskip bool) error {
 	v, ok, err := c.Find(alias)
 	if err != nil {
@@ -365,3 +424,14 @@ func (c *Config) Vault(which string) (*Vault, error) {
 func Cleanup() {
 	defaultCleanupManager.Cleanup()
 }
+
+// AddCleanup registers a temporary file made outside of the rc package, so
+// that Cleanup shreds it if safe exits before it can be shredded otherwise.
+func AddCleanup(path string) {
+	defaultCleanupManager.Add(path)
+}
+
+// Shred shreds a temporary file registered with AddCleanup.
+func Shred(path string) {
+	defaultCleanupManager.Shred(path)
+}
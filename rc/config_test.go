@@ -14,7 +14,8 @@ var _ = Describe("Config", func() {
 	var savedEnv map[string]string
 	var tmpHome string
 
-	envKeys := []string{"HOME", "VAULT_ADDR", "VAULT_TOKEN", "VAULT_SKIP_VERIFY", "VAULT_NAMESPACE", "VAULT_CACERT"}
+	envKeys := []string{"HOME", "VAULT_ADDR", "VAULT_TOKEN", "VAULT_SKIP_VERIFY", "VAULT_NAMESPACE", "VAULT_CACERT",
+		"SAFE_RETRY_ATTEMPTS", "SAFE_RETRY_MIN_BACKOFF", "SAFE_RETRY_MAX_BACKOFF"}
 
 	BeforeEach(func() {
 		savedEnv = make(map[string]string)
@@ -264,6 +265,20 @@ var _ = Describe("Config", func() {
 			Expect(os.Getenv("VAULT_NAMESPACE")).To(Equal("team1"))
 		})
 
+		It("sets retry settings, without overriding those already exported", func() {
+			os.Unsetenv("SAFE_RETRY_ATTEMPTS")
+			os.Setenv("SAFE_RETRY_MIN_BACKOFF", "1s")
+			os.Unsetenv("SAFE_RETRY_MAX_BACKOFF")
+			c := rc.Config{Version: 1, Current: "v1", Vaults: map[string]*rc.Vault{
+				"v1": {URL: "https://v1.example.com", Token: "t", Retry: &rc.Retry{MaxAttempts: 3, MinBackoff: "5s"}},
+			}}
+			err := c.Apply("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.Getenv("SAFE_RETRY_ATTEMPTS")).To(Equal("3"))
+			Expect(os.Getenv("SAFE_RETRY_MIN_BACKOFF")).To(Equal("1s"))
+			Expect(os.Getenv("SAFE_RETRY_MAX_BACKOFF")).To(Equal(""))
+		})
+
 		It("does not error when no vault is configured", func() {
 			c := rc.Config{Version: 1}
 			err := c.Apply("")
@@ -297,3 +312,37 @@ var _ = Describe("Config", func() {
 		})
 	})
 })
+
+var _ = Describe("CleanupManager", func() {
+	It("shreds the files registered with it", func() {
+		f, err := ioutil.TempFile("", "safe-cleanup")
+		Expect(err).ToNot(HaveOccurred())
+		f.WriteString("s3cr3t")
+		f.Close()
+
+		cm := &rc.CleanupManager{}
+		cm.Add(f.Name())
+		cm.Cleanup()
+
+		_, err = os.Stat(f.Name())
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("shreds a file early, and stops tracking it", func() {
+		f, err := ioutil.TempFile("", "safe-cleanup")
+		Expect(err).ToNot(HaveOccurred())
+		f.Close()
+
+		cm := &rc.CleanupManager{}
+		cm.Add(f.Name())
+		cm.Shred(f.Name())
+		_, err = os.Stat(f.Name())
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		Expect(os.WriteFile(f.Name(), []byte("new"), 0600)).To(Succeed())
+		defer os.Remove(f.Name())
+		cm.Cleanup()
+		_, err = os.Stat(f.Name())
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,196 @@
+package vault_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Check-and-set writes", func() {
+	var server *httptest.Server
+	var v *vault.Vault
+	var lock sync.Mutex
+	var versions []map[string]string
+	var patches bool
+	var patchRefusal int
+	var patched int
+	var beforeWrite func()
+
+	BeforeEach(func() {
+		versions = []map[string]string{{"password": "one"}}
+		patches = true
+		patchRefusal = http.StatusMethodNotAllowed
+		patched = 0
+		beforeWrite = func() {}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case path == "sys/internal/ui/mounts":
+				json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+					"secret": map[string]interface{}{
+						"secret/": map[string]interface{}{"type": "kv", "options": map[string]string{"version": "2"}},
+					},
+				}})
+
+			case path == "secret/data/db" && r.Method == "GET":
+				json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+					"data":     versions[len(versions)-1],
+					"metadata": map[string]interface{}{"version": len(versions)},
+				}})
+
+			case path == "secret/data/db":
+				if r.Method == "PATCH" && (!patches || r.Header.Get("Content-Type") != "application/merge-patch+json") {
+					w.WriteHeader(patchRefusal)
+					w.Write([]byte(`{"errors":[]}`))
+					return
+				}
+				var in struct {
+					Options struct {
+						CAS *int `json:"cas"`
+					} `json:"options"`
+					Data map[string]*string `json:"data"`
+				}
+				json.NewDecoder(r.Body).Decode(&in)
+				beforeWrite()
+				if in.Options.CAS != nil && *in.Options.CAS != len(versions) {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"errors":["check-and-set parameter did not match the current version"]}`))
+					return
+				}
+				next := map[string]string{}
+				if r.Method == "PATCH" {
+					patched++
+					for k, val := range versions[len(versions)-1] {
+						next[k] = val
+					}
+				}
+				for k, val := range in.Data {
+					if val == nil {
+						delete(next, k)
+					} else {
+						next[k] = *val
+					}
+				}
+				versions = append(versions, next)
+				json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"version": len(versions)}})
+
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"errors":[]}`))
+			}
+		}))
+
+		var err error
+		v, err = vault.NewVault(vault.VaultConfig{
+			URL:   server.URL,
+			Token: "token",
+			Retry: vault.RetryPolicy{MaxAttempts: 1},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reads the version of a secret", func() {
+		s, version, err := v.ReadVersion("secret/db")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Get("password")).To(Equal("one"))
+		Expect(version).To(Equal(uint(1)))
+	})
+
+	It("writes when the secret is still at the version given", func() {
+		s := vault.NewSecret()
+		s.Set("password", "two", false)
+		version, err := v.WriteCAS("secret/db", s, 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(uint(2)))
+		Expect(versions).To(HaveLen(2))
+	})
+
+	It("refuses to write when someone else got there first", func() {
+		versions = append(versions, map[string]string{"password": "theirs"})
+
+		s := vault.NewSecret()
+		s.Set("password", "mine", false)
+		_, err := v.WriteCAS("secret/db", s, 1)
+		Expect(vault.IsCASMismatch(err)).To(BeTrue())
+		Expect(versions).To(HaveLen(2))
+	})
+
+	setUser := func(s *vault.Secret) error {
+		return s.Set("user", "admin", false)
+	}
+
+	It("patches just the keys that changed", func() {
+		beforeWrite = func() {
+			beforeWrite = func() {}
+			versions = append(versions, map[string]string{"password": "theirs"})
+		}
+		Expect(v.Patch("secret/db", setUser, vault.WriteOpts{})).To(Succeed())
+		Expect(versions[len(versions)-1]).To(Equal(map[string]string{"password": "theirs", "user": "admin"}))
+		Expect(patched).To(Equal(1))
+	})
+
+	It("falls back to check-and-set, and retries when it loses a race", func() {
+		patches = false
+		beforeWrite = func() {
+			beforeWrite = func() {}
+			versions = append(versions, map[string]string{"password": "theirs"})
+		}
+		Expect(v.Patch("secret/db", setUser, vault.WriteOpts{})).To(Succeed())
+		Expect(versions).To(HaveLen(3))
+		Expect(versions[2]).To(Equal(map[string]string{"password": "theirs", "user": "admin"}))
+	})
+
+	It("falls back to check-and-set when the token may not patch", func() {
+		patches = false
+		patchRefusal = http.StatusForbidden
+		Expect(v.Patch("secret/db", setUser, vault.WriteOpts{})).To(Succeed())
+		Expect(versions).To(HaveLen(2))
+		Expect(versions[1]).To(Equal(map[string]string{"password": "one", "user": "admin"}))
+	})
+
+	It("does not retry a patch with an explicit check-and-set version", func() {
+		cas := uint(1)
+		versions = append(versions, map[string]string{"password": "theirs"})
+		err := v.Patch("secret/db", setUser, vault.WriteOpts{CAS: &cas})
+		Expect(vault.IsCASMismatch(err)).To(BeTrue())
+		Expect(versions).To(HaveLen(2))
+	})
+
+	It("writes with check-and-set when asked to", func() {
+		cas := uint(2)
+		s := vault.NewSecret()
+		s.Set("password", "two", false)
+		err := v.Write("secret/db", s, vault.WriteOpts{CAS: &cas})
+		Expect(vault.IsCASMismatch(err)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Check-and-set writes to KV v1", func() {
+	It("are not supported", func() {
+		fake, v := newFakeVault(map[string]map[string]string{"secret/a": {"k": "1"}})
+		defer fake.Close()
+
+		_, version, err := v.ReadVersion("secret/a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(uint(0)))
+
+		_, err = v.WriteCAS("secret/a", vault.NewSecret(), 0)
+		Expect(err).To(HaveOccurred())
+		Expect(vault.IsCASMismatch(err)).To(BeFalse())
+	})
+})
@@ -0,0 +1,225 @@
+package vault
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Checkpoint records the progress of a long-running import or copy in a file,
+// so that an interrupted run can pick up where it stopped. The file is a log
+// of JSON lines, appended to as paths are started and finished, so that
+// recording progress stays cheap however large the tree is.
+//
+// A nil *Checkpoint records nothing, and reports no progress.
+type Checkpoint struct {
+	file  *os.File
+	name  string
+	step  int
+	paths map[string]*checkpointPath
+	lock  sync.Mutex
+}
+
+type checkpointPath struct {
+	//base is the latest version at the destination before anything was
+	// written to it
+	base uint
+	done bool
+}
+
+type checkpointLine struct {
+	Operation string `json:"operation,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Step      *int   `json:"step,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Base      *uint  `json:"base,omitempty"`
+	Done      bool   `json:"done,omitempty"`
+}
+
+// OpenCheckpoint opens the checkpoint file for the given operation, resuming
+// from it if it exists, and starting it otherwise. source identifies what is
+// being imported or copied; a checkpoint left behind by a different operation
+// or source is refused.
+func OpenCheckpoint(name, operation, source string) (*Checkpoint, error) {
+	c := &Checkpoint{name: name, paths: map[string]*checkpointPath{}}
+
+	b, err := os.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		c.file, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, err
+		}
+		return c, c.append(checkpointLine{Operation: operation, Source: source})
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	first := true
+	for scanner.Scan() {
+		var line checkpointLine
+		//The last line may have been cut short when we were interrupted
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		if first {
+			if line.Operation != operation || line.Source != source {
+				return nil, fmt.Errorf("Checkpoint `%s' was not recorded by this %s; remove it to start over", name, operation)
+			}
+			first = false
+			continue
+		}
+		c.replay(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if first {
+		return nil, fmt.Errorf("Checkpoint `%s' is damaged; remove it to start over", name)
+	}
+
+	c.file, err = os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0600)
+	return c, err
+}
+
+func (c *Checkpoint) replay(line checkpointLine) {
+	switch {
+	case line.Step != nil:
+		c.step = *line.Step
+		c.paths = map[string]*checkpointPath{}
+	case line.Path != "":
+		p, found := c.paths[line.Path]
+		if !found {
+			p = &checkpointPath{}
+			c.paths[line.Path] = p
+		}
+		if line.Base != nil {
+			p.base = *line.Base
+		}
+		if line.Done {
+			p.done = true
+		}
+	}
+}
+
+func (c *Checkpoint) append(line checkpointLine) error {
+	b, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	_, err = c.file.Write(append(b, '\n'))
+	if err != nil {
+		return fmt.Errorf("Could not record progress in checkpoint `%s': %w", c.name, err)
+	}
+	return nil
+}
+
+// Step returns which of several inputs (i.e. a chain of exports) is being
+// worked through.
+func (c *Checkpoint) Step() int {
+	if c == nil {
+		return 0
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.step
+}
+
+// BeginStep moves on to the given input, forgetting the progress made on the
+// previous one.
+func (c *Checkpoint) BeginStep(step int) error {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if step == c.step {
+		return nil
+	}
+	line := checkpointLine{Step: &step}
+	c.replay(line)
+	return c.append(line)
+}
+
+// Done returns true if the given path was finished by an earlier run.
+func (c *Checkpoint) Done(path string) bool {
+	if c == nil {
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	p, found := c.paths[Canonicalize(path)]
+	return found && p.done
+}
+
+// Seen returns true if an earlier run started on the given path.
+func (c *Checkpoint) Seen(path string) bool {
+	if c == nil {
+		return false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, found := c.paths[Canonicalize(path)]
+	return found
+}
+
+// MarkDone records that the given path is finished.
+func (c *Checkpoint) MarkDone(path string) error {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	line := checkpointLine{Path: Canonicalize(path), Done: true}
+	c.replay(line)
+	return c.append(line)
+}
+
+// started returns the base version recorded when writing to the given path
+// began, if it did.
+func (c *Checkpoint) started(path string) (uint, bool) {
+	if c == nil {
+		return 0, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	p, found := c.paths[Canonicalize(path)]
+	if !found {
+		return 0, false
+	}
+	return p.base, true
+}
+
+func (c *Checkpoint) start(path string, base uint) error {
+	if c == nil {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	line := checkpointLine{Path: Canonicalize(path), Base: &base}
+	c.replay(line)
+	return c.append(line)
+}
+
+// Remove deletes the checkpoint file, once the operation has finished.
+func (c *Checkpoint) Remove() error {
+	if c == nil {
+		return nil
+	}
+	c.file.Close()
+	return os.Remove(c.name)
+}
+
+// Close closes the checkpoint file, leaving it in place to resume from.
+func (c *Checkpoint) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.file.Close()
+}
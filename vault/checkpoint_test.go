@@ -0,0 +1,112 @@
+package vault_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Checkpoint", func() {
+	var dir, file string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "safe-checkpoint")
+		Expect(err).ToNot(HaveOccurred())
+		file = filepath.Join(dir, "checkpoint")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("starts with no progress", func() {
+		c, err := vault.OpenCheckpoint(file, "copy", "a -> b")
+		Expect(err).ToNot(HaveOccurred())
+		defer c.Close()
+		Expect(c.Done("secret/x")).To(BeFalse())
+		Expect(c.Seen("secret/x")).To(BeFalse())
+		Expect(c.Step()).To(Equal(0))
+	})
+
+	It("remembers finished paths across runs", func() {
+		c, err := vault.OpenCheckpoint(file, "copy", "a -> b")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.MarkDone("secret/x")).To(Succeed())
+		Expect(c.Close()).To(Succeed())
+
+		c, err = vault.OpenCheckpoint(file, "copy", "a -> b")
+		Expect(err).ToNot(HaveOccurred())
+		defer c.Close()
+		Expect(c.Done("/secret/x/")).To(BeTrue())
+		Expect(c.Seen("secret/x")).To(BeTrue())
+		Expect(c.Done("secret/y")).To(BeFalse())
+	})
+
+	It("forgets the paths of earlier steps", func() {
+		c, err := vault.OpenCheckpoint(file, "import", "json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.MarkDone("secret/x")).To(Succeed())
+		Expect(c.BeginStep(1)).To(Succeed())
+		Expect(c.Done("secret/x")).To(BeFalse())
+		Expect(c.MarkDone("secret/y")).To(Succeed())
+		Expect(c.Close()).To(Succeed())
+
+		c, err = vault.OpenCheckpoint(file, "import", "json")
+		Expect(err).ToNot(HaveOccurred())
+		defer c.Close()
+		Expect(c.Step()).To(Equal(1))
+		Expect(c.Done("secret/x")).To(BeFalse())
+		Expect(c.Done("secret/y")).To(BeTrue())
+	})
+
+	It("ignores a line cut short by an interruption", func() {
+		c, err := vault.OpenCheckpoint(file, "copy", "a -> b")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.MarkDone("secret/x")).To(Succeed())
+		Expect(c.Close()).To(Succeed())
+
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0600)
+		Expect(err).ToNot(HaveOccurred())
+		f.WriteString(`{"path":"secret/y","do`)
+		f.Close()
+
+		c, err = vault.OpenCheckpoint(file, "copy", "a -> b")
+		Expect(err).ToNot(HaveOccurred())
+		defer c.Close()
+		Expect(c.Done("secret/x")).To(BeTrue())
+		Expect(c.Seen("secret/y")).To(BeFalse())
+	})
+
+	It("refuses a checkpoint from a different operation", func() {
+		c, err := vault.OpenCheckpoint(file, "copy", "a -> b")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Close()).To(Succeed())
+
+		_, err = vault.OpenCheckpoint(file, "copy", "a -> c")
+		Expect(err).To(HaveOccurred())
+		_, err = vault.OpenCheckpoint(file, "import", "a -> b")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("removes the file when finished", func() {
+		c, err := vault.OpenCheckpoint(file, "copy", "a -> b")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Remove()).To(Succeed())
+		_, err = os.Stat(file)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("does nothing when nil", func() {
+		var c *vault.Checkpoint
+		Expect(c.Done("secret/x")).To(BeFalse())
+		Expect(c.MarkDone("secret/x")).To(Succeed())
+		Expect(c.BeginStep(2)).To(Succeed())
+		Expect(c.Step()).To(Equal(0))
+		Expect(c.Remove()).To(Succeed())
+	})
+})
@@ -0,0 +1,175 @@
+package vault
+
+import (
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaxAdaptiveWorkers is as far as adaptive concurrency will grow.
+const MaxAdaptiveWorkers = 64
+
+// Concurrency says how many requests are made to Vault at once when walking a
+// tree of secrets.
+type Concurrency struct {
+	//Workers is how many requests are made at once; 0 means one per CPU
+	Workers int
+	//Adaptive starts at Workers, and then makes more requests at once while
+	// Vault keeps answering as quickly, and fewer when it is rate limiting
+	// (429) or overloaded (503)
+	Adaptive bool
+}
+
+func (c Concurrency) workers() int {
+	if c.Workers > 0 {
+		return c.Workers
+	}
+	if n := runtime.NumCPU(); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// AdaptiveLimiter limits how many requests are in flight at once, growing the
+// limit by one each time a full round of requests comes back without slowing
+// down, and halving it whenever Vault pushes back.
+type AdaptiveLimiter struct {
+	c        *sync.Cond
+	limit    int
+	max      int
+	inflight int
+	//average is a moving average of request latency, and baseline the lowest
+	// that average has been
+	average  time.Duration
+	baseline time.Duration
+	round    int
+	lastCut  time.Time
+}
+
+func NewAdaptiveLimiter(start, max int) *AdaptiveLimiter {
+	if max < 1 {
+		max = 1
+	}
+	if start < 1 {
+		start = 1
+	}
+	if start > max {
+		start = max
+	}
+	return &AdaptiveLimiter{
+		c:     sync.NewCond(&sync.Mutex{}),
+		limit: start,
+		max:   max,
+	}
+}
+
+// Limit returns how many requests may currently be in flight at once.
+func (l *AdaptiveLimiter) Limit() int {
+	l.c.L.Lock()
+	defer l.c.L.Unlock()
+	return l.limit
+}
+
+// Acquire waits until another request may be made.
+func (l *AdaptiveLimiter) Acquire() {
+	l.c.L.Lock()
+	for l.inflight >= l.limit {
+		l.c.Wait()
+	}
+	l.inflight++
+	l.c.L.Unlock()
+}
+
+// Release records that a request came back after the given time, and whether
+// Vault pushed back on it.
+func (l *AdaptiveLimiter) Release(latency time.Duration, throttled bool) {
+	l.c.L.Lock()
+	defer l.c.L.Unlock()
+	defer l.c.Broadcast()
+	l.inflight--
+
+	if throttled {
+		//Requests already in flight when we cut back will be throttled too, so
+		// only cut back once for each of them
+		if time.Since(l.lastCut) > l.average {
+			l.limit = (l.limit + 1) / 2
+			l.lastCut = time.Now()
+			l.round = 0
+		}
+		return
+	}
+
+	if l.average == 0 {
+		l.average = latency
+	} else {
+		l.average = (4*l.average + latency) / 5
+	}
+	if l.baseline == 0 || l.average < l.baseline {
+		l.baseline = l.average
+	}
+
+	l.round++
+	if l.round < l.limit {
+		return
+	}
+	l.round = 0
+	switch {
+	case l.average <= l.baseline*3/2 && l.limit < l.max:
+		l.limit++
+	case l.average > l.baseline*2 && l.limit > 1:
+		l.limit--
+	}
+}
+
+// Metrics counts the requests made to Vault.
+type Metrics struct {
+	requests  int64
+	throttled int64
+}
+
+// Requests returns how many requests have been sent, retries included.
+func (m *Metrics) Requests() int64 {
+	return atomic.LoadInt64(&m.requests)
+}
+
+// Throttled returns how many requests were rate limited (429) or turned away
+// by an overloaded Vault (503).
+func (m *Metrics) Throttled() int64 {
+	return atomic.LoadInt64(&m.throttled)
+}
+
+var defaultMetrics = &Metrics{}
+
+// RequestMetrics returns the counts of requests made by every Vault client in
+// this process.
+func RequestMetrics() *Metrics {
+	return defaultMetrics
+}
+
+// meteredTransport counts requests, and holds them back while the limiter,
+// if there is one, says too many are in flight.
+type meteredTransport struct {
+	next    http.RoundTripper
+	limiter *AdaptiveLimiter
+	metrics *Metrics
+}
+
+func (t *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.limiter != nil {
+		t.limiter.Acquire()
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	atomic.AddInt64(&t.metrics.requests, 1)
+	throttled := resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable)
+	if throttled {
+		atomic.AddInt64(&t.metrics.throttled, 1)
+	}
+	if t.limiter != nil {
+		t.limiter.Release(time.Since(start), throttled)
+	}
+	return resp, err
+}
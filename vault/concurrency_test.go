@@ -0,0 +1,96 @@
+package vault_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Concurrency", func() {
+	Describe("AdaptiveLimiter", func() {
+		//round sends a full round of requests through the limiter, all taking
+		// the same time
+		round := func(l *vault.AdaptiveLimiter, latency time.Duration, throttled bool) {
+			n := l.Limit()
+			for i := 0; i < n; i++ {
+				l.Acquire()
+			}
+			for i := 0; i < n; i++ {
+				l.Release(latency, throttled)
+			}
+		}
+
+		It("grows while latency holds steady", func() {
+			l := vault.NewAdaptiveLimiter(2, 5)
+			for i := 0; i < 10; i++ {
+				round(l, 10*time.Millisecond, false)
+			}
+			Expect(l.Limit()).To(Equal(5))
+		})
+
+		It("shrinks when latency climbs", func() {
+			l := vault.NewAdaptiveLimiter(4, 8)
+			round(l, 10*time.Millisecond, false)
+			Expect(l.Limit()).To(Equal(5))
+			for i := 0; i < 5; i++ {
+				round(l, time.Second, false)
+			}
+			Expect(l.Limit()).To(BeNumerically("<", 5))
+		})
+
+		It("halves when Vault pushes back", func() {
+			l := vault.NewAdaptiveLimiter(8, 8)
+			l.Acquire()
+			l.Release(time.Millisecond, true)
+			Expect(l.Limit()).To(Equal(4))
+		})
+
+		It("never stops altogether", func() {
+			l := vault.NewAdaptiveLimiter(1, 8)
+			l.Acquire()
+			l.Release(time.Millisecond, true)
+			Expect(l.Limit()).To(Equal(1))
+		})
+
+		It("holds back requests beyond the limit", func() {
+			l := vault.NewAdaptiveLimiter(1, 1)
+			l.Acquire()
+			acquired := make(chan bool)
+			go func() {
+				l.Acquire()
+				close(acquired)
+			}()
+			Consistently(acquired, 50*time.Millisecond).ShouldNot(BeClosed())
+			l.Release(time.Millisecond, false)
+			Eventually(acquired).Should(BeClosed())
+		})
+	})
+
+	Describe("RequestMetrics", func() {
+		It("counts requests and throttling", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer server.Close()
+
+			v, err := vault.NewVault(vault.VaultConfig{
+				URL:         server.URL,
+				Token:       "token",
+				Retry:       vault.RetryPolicy{MaxAttempts: 1},
+				Concurrency: vault.Concurrency{Adaptive: true},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			requests, throttled := vault.RequestMetrics().Requests(), vault.RequestMetrics().Throttled()
+			_, err = v.Curl("GET", "secret/x", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vault.RequestMetrics().Requests() - requests).To(Equal(int64(1)))
+			Expect(vault.RequestMetrics().Throttled() - throttled).To(Equal(int64(1)))
+		})
+	})
+})
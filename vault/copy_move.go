@@ -17,6 +17,9 @@ type MoveCopyOpts struct {
 	// It also puts in dummy destroyed keys to dest to match destroyed keys from src
 	//Makes no sense without Deep
 	DeletedVersions bool
+	//Checkpoint records which paths have been copied, so that an interrupted
+	// copy can be resumed
+	Checkpoint *Checkpoint
 }
 
 // Copy copies secrets from one path to another.
@@ -25,6 +28,13 @@ type MoveCopyOpts struct {
 // no-key -> key is bad. That makes no sense and the user should feel bad.
 // Returns KeyNotFoundError if there is no such specified key in the secret at oldpath
 func (v *Vault) Copy(oldpath, newpath string, opts MoveCopyOpts) error {
+	return v.CopyTo(v, oldpath, newpath, opts)
+}
+
+// CopyTo copies secrets from a path in this Vault to a path in the dst Vault,
+// which may be this one. It otherwise behaves like Copy. If the destination is
+// on a KV v1 mount, only the latest living version of a deep copy is written.
+func (v *Vault) CopyTo(dst *Vault, oldpath, newpath string, opts MoveCopyOpts) error {
 	oldpath = Canonicalize(oldpath)
 	newpath = Canonicalize(newpath)
 
@@ -37,6 +47,10 @@ func (v *Vault) Copy(oldpath, newpath string, opts MoveCopyOpts) error {
 		reqState = verifyStateAliveOrDeleted
 	}
 
+	if opts.Checkpoint.Done(newpath) {
+		return nil
+	}
+
 	err = v.verifySecretState(oldpath, verifyOpts{
 		State:      reqState,
 		AnyVersion: opts.Deep,
@@ -45,8 +59,8 @@ func (v *Vault) Copy(oldpath, newpath string, opts MoveCopyOpts) error {
 		return err
 	}
 
-	if opts.SkipIfExists {
-		if _, err := v.Read(newpath); err == nil {
+	if opts.SkipIfExists && !opts.Checkpoint.Seen(newpath) {
+		if _, err := dst.Read(newpath); err == nil {
 			if !opts.Quiet {
 				ansi.Fprintf(os.Stderr, "@R{Cowardly refusing to copy/move data into} @C{%s}@R{, as that would clobber existing data}\n", newpath)
 			}
@@ -85,7 +99,7 @@ func (v *Vault) Copy(oldpath, newpath string, opts MoveCopyOpts) error {
 			dstKey = srcKey
 		}
 
-		dstOrig, err := v.Read(dstPath)
+		dstOrig, err := dst.Read(dstPath)
 		if err != nil && !IsSecretNotFound(err) {
 			return err
 		}
@@ -127,19 +141,49 @@ func (v *Vault) Copy(oldpath, newpath string, opts MoveCopyOpts) error {
 			}
 		}
 
-		err = t[0].Copy(v, dstPath, TreeCopyOpts{Clear: opts.Deep, Pad: opts.Deep})
+		dstMountVersion, err := dst.MountVersion(dstPath)
+		if err != nil {
+			return err
+		}
+		deep := opts.Deep
+		if dstMountVersion == 1 {
+			//A v1 mount keeps one version, and destroying that would delete the
+			// secret outright, so only the latest living version can go there
+			t[0].Versions = latestAlive(t[0].Versions)
+			if len(t[0].Versions) == 0 {
+				return NewSecretNotFoundError(srcPath)
+			}
+			deep = false
+		}
+
+		err = t[0].Copy(dst, dstPath, TreeCopyOpts{
+			Clear:      deep,
+			Pad:        deep,
+			Checkpoint: opts.Checkpoint,
+		})
 		if err != nil {
 			return err
 		}
 	}
 
 	for i := range toWrite {
-		err := v.Write(dstPath, toWrite[i])
+		err := dst.Write(dstPath, toWrite[i])
 		if err != nil {
 			return err
 		}
 	}
 
+	return opts.Checkpoint.MarkDone(newpath)
+}
+
+// latestAlive returns the latest of the given versions which is neither
+// deleted nor destroyed, if there is one.
+func latestAlive(versions []SecretVersion) []SecretVersion {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].State == SecretStateAlive {
+			return versions[i : i+1]
+		}
+	}
 	return nil
 }
 
@@ -147,6 +191,13 @@ func (v *Vault) Copy(oldpath, newpath string, opts MoveCopyOpts) error {
 // This function will get confused about 'secret:key' syntax, so don't let those
 // get routed here - they don't make sense for a recursion anyway.
 func (v *Vault) MoveCopyTree(oldRoot, newRoot string, f func(string, string, MoveCopyOpts) error, opts MoveCopyOpts) error {
+	return v.MoveCopyTreeTo(v, oldRoot, newRoot, f, opts)
+}
+
+// MoveCopyTreeTo is MoveCopyTree for a destination in the dst Vault, which may
+// be this one. f is given each path in this Vault, and the path in dst that it
+// goes to.
+func (v *Vault) MoveCopyTreeTo(dst *Vault, oldRoot, newRoot string, f func(string, string, MoveCopyOpts) error, opts MoveCopyOpts) error {
 	oldRoot = Canonicalize(oldRoot)
 	newRoot = Canonicalize(newRoot)
 
@@ -156,7 +207,7 @@ func (v *Vault) MoveCopyTree(oldRoot, newRoot string, f func(string, string, Mov
 	}
 	if opts.SkipIfExists {
 		//Writing one secret over a deleted secret isn't clobbering. Completely overwriting a set of deleted secrets would be
-		newTree, err := v.ConstructSecrets(newRoot, TreeOpts{FetchKeys: false, AllowDeletedSecrets: !opts.Deep, SkipVersionInfo: true})
+		newTree, err := dst.ConstructSecrets(newRoot, TreeOpts{FetchKeys: false, AllowDeletedSecrets: !opts.Deep, SkipVersionInfo: true})
 		if err != nil && !IsNotFound(err) {
 			return err
 		}
@@ -167,7 +218,8 @@ func (v *Vault) MoveCopyTree(oldRoot, newRoot string, f func(string, string, Mov
 		existingPaths := []string{}
 		for _, path := range tree.Paths() {
 			newPath := strings.Replace(path, oldRoot, newRoot, 1)
-			if existing[newPath] {
+			//Paths we wrote to before being interrupted aren't being clobbered
+			if existing[newPath] && !opts.Checkpoint.Seen(newPath) {
 				existingPaths = append(existingPaths, newPath)
 			}
 		}
@@ -199,6 +251,12 @@ func (v *Vault) MoveCopyTree(oldRoot, newRoot string, f func(string, string, Mov
 // A move is semantically a copy and then a deletion of the original item. For
 // more information on the behavior of Move pertaining to keys, look at Copy.
 func (v *Vault) Move(oldpath, newpath string, opts MoveCopyOpts) error {
+	return v.MoveTo(v, oldpath, newpath, opts)
+}
+
+// MoveTo moves secrets from a path in this Vault to a path in the dst Vault,
+// which may be this one. It otherwise behaves like Move.
+func (v *Vault) MoveTo(dst *Vault, oldpath, newpath string, opts MoveCopyOpts) error {
 	oldpath = Canonicalize(oldpath)
 	newpath = Canonicalize(newpath)
 
@@ -210,7 +268,7 @@ func (v *Vault) Move(oldpath, newpath string, opts MoveCopyOpts) error {
 		return err
 	}
 
-	err = v.Copy(oldpath, newpath, opts)
+	err = v.CopyTo(dst, oldpath, newpath, opts)
 	if err != nil {
 		return err
 	}
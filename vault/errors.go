@@ -1,6 +1,10 @@
 package vault
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-community/vaultkv"
+)
 
 type secretNotFound struct {
 	message string
@@ -52,3 +56,61 @@ func IsKeyNotFound(err error) bool {
 	_, is := err.(keyNotFound)
 	return is
 }
+
+type casMismatch struct {
+	secret  string
+	version uint
+	//unversioned is set for KV v1 secrets, which have no versions to check
+	unversioned bool
+}
+
+func (e casMismatch) Error() string {
+	if e.unversioned {
+		return fmt.Sprintf("secret `%s` was changed by someone else while you were editing it", e.secret)
+	}
+	if e.version == 0 {
+		return fmt.Sprintf("secret `%s` was created by someone else", e.secret)
+	}
+	return fmt.Sprintf("secret `%s` is no longer at version %d; someone else changed it", e.secret, e.version)
+}
+
+//NewCASMismatchError returns an error describing a check-and-set write that
+// was refused because the secret was no longer at the version given.
+func NewCASMismatchError(path string, version uint) error {
+	return casMismatch{secret: path, version: version}
+}
+
+//NewEditConflictError returns an error describing a write of a KV v1 secret
+// that was refused because the secret changed while it was being edited. Like
+// those from NewCASMismatchError, IsCASMismatch is true for it.
+func NewEditConflictError(path string) error {
+	return casMismatch{secret: path, unversioned: true}
+}
+
+//IsCASMismatch returns true if the given error was created with
+// NewCASMismatchError(). False otherwise.
+func IsCASMismatch(err error) bool {
+	_, is := err.(casMismatch)
+	return is
+}
+
+type apiError struct {
+	code int
+	err  error
+}
+
+func (e apiError) Error() string {
+	return e.err.Error()
+}
+
+func isStatus(err error, code int) bool {
+	e, is := err.(apiError)
+	return is && e.code == code
+}
+
+//IsForbidden returns true if Vault refused the request that produced the
+// given error with a 403, whether the request went through vaultkv or was made
+// directly against the API. False otherwise.
+func IsForbidden(err error) bool {
+	return vaultkv.IsForbidden(err) || isStatus(err, 403)
+}
@@ -36,6 +36,14 @@ var _ = Describe("Errors", func() {
 		})
 	})
 
+	Describe("NewEditConflictError", func() {
+		It("says the secret changed while it was being edited", func() {
+			err := vault.NewEditConflictError("secret/path")
+			Expect(err.Error()).To(Equal("secret `secret/path` was changed by someone else while you were editing it"))
+			Expect(vault.IsCASMismatch(err)).To(BeTrue())
+		})
+	})
+
 	Describe("IsSecretNotFound", func() {
 		It("returns true for a secretNotFound error", func() {
 			err := vault.NewSecretNotFoundError("p")
@@ -0,0 +1,313 @@
+package vault_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+// fakeVault serves a KV v1 mount called secret/ from memory, with just enough
+// of the Vault API for reading and walking trees of secrets.  Made with
+// newFakeVaultV2, secret/ is a KV v2 mount instead, with versions, metadata
+// and mount configuration.
+type fakeVault struct {
+	server  *httptest.Server
+	lock    sync.Mutex
+	secrets map[string]map[string]string
+	//versions are those of each secret in a KV v2 mount, oldest first
+	versions map[string][]fakeVersion
+	//mounts are the KV mounts there are, and their versions
+	mounts map[string]int
+	//config is the KV v2 configuration of secret/, and tuning its settings
+	// from sys/mounts; what is written to either is recorded in configured
+	// and tuned
+	config     map[string]interface{}
+	tuning     map[string]interface{}
+	configured []map[string]interface{}
+	tuned      []map[string]interface{}
+	//ttl is how long the token has left, in seconds, and renewals how many
+	// times it has been renewed (back to an hour)
+	ttl      int
+	renewals int
+	//failing makes everything but mounts and tokens fail
+	failing bool
+	//reads are the KV v2 secrets that have been read, with ^N for those read
+	// at a given version
+	reads []string
+	//metadata holds the settings of KV v2 secrets that have any, and
+	// forbidden the secrets whose metadata the token may not read
+	metadata  map[string]vault.SecretMetadata
+	forbidden map[string]bool
+}
+
+// fakeVersion is a version of a secret in a KV v2 mount.
+type fakeVersion struct {
+	Data      map[string]string
+	CreatedAt time.Time
+	DeletedAt time.Time
+	Destroyed bool
+}
+
+func newFakeVault(secrets map[string]map[string]string) (*fakeVault, *vault.Vault) {
+	return serveFakeVault(&fakeVault{secrets: secrets, mounts: map[string]int{"secret": 1}})
+}
+
+func newFakeVaultV2(versions map[string][]fakeVersion) (*fakeVault, *vault.Vault) {
+	return serveFakeVault(&fakeVault{
+		versions: versions,
+		mounts:   map[string]int{"secret": 2},
+		config:   map[string]interface{}{"max_versions": 0, "cas_required": false, "delete_version_after": "0s"},
+		tuning:   map[string]interface{}{"description": "", "default_lease_ttl": 2764800, "max_lease_ttl": 2764800},
+	})
+}
+
+// aliveVersions returns n versions that have not been deleted, each with a
+// key holding its number.
+func aliveVersions(n int) []fakeVersion {
+	versions := make([]fakeVersion, n)
+	for i := range versions {
+		versions[i] = fakeVersion{Data: map[string]string{"key": strconv.Itoa(i + 1)}}
+	}
+	return versions
+}
+
+func serveFakeVault(f *fakeVault) (*fakeVault, *vault.Vault) {
+	f.ttl = 3600
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+
+	v, err := vault.NewVault(vault.VaultConfig{
+		URL:   f.server.URL,
+		Token: "token",
+		Retry: vault.RetryPolicy{MaxAttempts: 1},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	return f, v
+}
+
+func (f *fakeVault) Close() {
+	f.server.Close()
+}
+
+// Update changes the fake, while it isn't serving anything.
+func (f *fakeVault) Update(fn func()) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	fn()
+}
+
+func (f *fakeVault) serve(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+	respond := func(data interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[]}`))
+	}
+	var in map[string]interface{}
+	if r.Method != "GET" {
+		json.NewDecoder(r.Body).Decode(&in)
+	}
+
+	kv2 := f.mounts["secret"] == 2
+
+	switch {
+	case path == "sys/internal/ui/mounts":
+		mounts := map[string]interface{}{}
+		for mount, version := range f.mounts {
+			mounts[mount+"/"] = map[string]interface{}{"type": "kv", "options": map[string]string{"version": strconv.Itoa(version)}}
+		}
+		respond(map[string]interface{}{"secret": mounts})
+
+	case path == "auth/token/lookup-self":
+		respond(map[string]interface{}{
+			"renewable":    true,
+			"creation_ttl": 3600,
+			"ttl":          f.ttl,
+			"expire_time":  time.Now().Add(time.Duration(f.ttl) * time.Second).Format(time.RFC3339Nano),
+		})
+
+	case path == "auth/token/renew-self":
+		f.renewals++
+		f.ttl = 3600
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{}})
+
+	case f.failing:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"errors":["down for maintenance"]}`))
+
+	case path == "sys/mounts":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"secret/": map[string]interface{}{"type": "kv", "description": "", "config": map[string]interface{}{}},
+		})
+
+	case path == "sys/mounts/secret/tune" && r.Method == "GET":
+		respond(f.tuning)
+
+	case path == "sys/mounts/secret/tune":
+		f.tuned = append(f.tuned, in)
+		w.WriteHeader(http.StatusNoContent)
+
+	case kv2 && path == "secret/config" && r.Method == "GET":
+		respond(f.config)
+
+	case kv2 && path == "secret/config":
+		f.configured = append(f.configured, in)
+		w.WriteHeader(http.StatusNoContent)
+
+	case kv2 && strings.HasPrefix(path, "secret/metadata") && r.URL.Query().Get("list") == "true":
+		var paths []string
+		for p := range f.versions {
+			paths = append(paths, p)
+		}
+		keys := listBeneath(paths, strings.TrimSuffix("secret/"+strings.Trim(strings.TrimPrefix(path, "secret/metadata"), "/"), "/"))
+		if len(keys) == 0 {
+			notFound()
+			return
+		}
+		respond(map[string]interface{}{"keys": keys})
+
+	case kv2 && strings.HasPrefix(path, "secret/metadata/") && r.Method == "DELETE":
+		delete(f.versions, "secret/"+strings.TrimPrefix(path, "secret/metadata/"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case kv2 && strings.HasPrefix(path, "secret/data/") && (r.Method == "PUT" || r.Method == "POST"):
+		secret := "secret/" + strings.TrimPrefix(path, "secret/data/")
+		data := map[string]string{}
+		if values, ok := in["data"].(map[string]interface{}); ok {
+			for k, v := range values {
+				data[k], _ = v.(string)
+			}
+		}
+		f.versions[secret] = append(f.versions[secret], fakeVersion{Data: data, CreatedAt: time.Now()})
+		respond(map[string]interface{}{"version": len(f.versions[secret])})
+
+	case kv2 && r.Method == "POST" && (strings.HasPrefix(path, "secret/delete/") || strings.HasPrefix(path, "secret/destroy/")):
+		op, rest, _ := strings.Cut(strings.TrimPrefix(path, "secret/"), "/")
+		versions := f.versions["secret/"+rest]
+		numbers, _ := in["versions"].([]interface{})
+		for _, n := range numbers {
+			if i := int(n.(float64)) - 1; i >= 0 && i < len(versions) {
+				if op == "destroy" {
+					versions[i].Destroyed = true
+				} else {
+					versions[i].DeletedAt = time.Now()
+				}
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case kv2 && strings.HasPrefix(path, "secret/metadata/"):
+		secret := "secret/" + strings.TrimPrefix(path, "secret/metadata/")
+		if f.forbidden[secret] {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		versions, found := f.versions[secret]
+		if !found {
+			notFound()
+			return
+		}
+		meta := map[string]interface{}{}
+		for i, version := range versions {
+			deleted := ""
+			if !version.DeletedAt.IsZero() {
+				deleted = version.DeletedAt.Format(time.RFC3339Nano)
+			}
+			meta[strconv.Itoa(i+1)] = map[string]interface{}{
+				"created_time":  version.CreatedAt.Format(time.RFC3339Nano),
+				"deletion_time": deleted,
+				"destroyed":     version.Destroyed,
+			}
+		}
+		settings := f.metadata[secret]
+		respond(map[string]interface{}{
+			"current_version":      len(versions),
+			"versions":             meta,
+			"max_versions":         settings.MaxVersions,
+			"cas_required":         settings.CASRequired,
+			"delete_version_after": settings.DeleteVersionAfter,
+			"custom_metadata":      settings.CustomMetadata,
+		})
+
+	case kv2 && strings.HasPrefix(path, "secret/data/"):
+		secret := "secret/" + strings.TrimPrefix(path, "secret/data/")
+		versions := f.versions[secret]
+		number := len(versions)
+		if q := r.URL.Query().Get("version"); q != "" {
+			number, _ = strconv.Atoi(q)
+			secret += "^" + q
+		}
+		f.reads = append(f.reads, secret)
+		if number < 1 || number > len(versions) {
+			notFound()
+			return
+		}
+		version := versions[number-1]
+		if version.Destroyed || !version.DeletedAt.IsZero() {
+			notFound()
+			return
+		}
+		respond(map[string]interface{}{"data": version.Data, "metadata": map[string]interface{}{"version": number}})
+
+	case r.Method == "GET" && r.URL.Query().Get("list") == "true":
+		var paths []string
+		for p := range f.secrets {
+			paths = append(paths, p)
+		}
+		keys := listBeneath(paths, path)
+		if len(keys) == 0 {
+			notFound()
+			return
+		}
+		respond(map[string]interface{}{"keys": keys})
+
+	case r.Method == "GET":
+		s, found := f.secrets[path]
+		if !found {
+			notFound()
+			return
+		}
+		respond(s)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// listBeneath returns what is directly beneath path, of the given paths of
+// secrets.
+func listBeneath(paths []string, path string) []string {
+	keys := map[string]bool{}
+	for _, p := range paths {
+		if rest := strings.TrimPrefix(p, path+"/"); rest != p {
+			if i := strings.Index(rest, "/"); i >= 0 {
+				keys[rest[:i+1]] = true
+			} else {
+				keys[rest] = true
+			}
+		}
+	}
+	ret := []string{}
+	for k := range keys {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
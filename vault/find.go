@@ -0,0 +1,84 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// FindOpts says which keys of which secrets Find is looking for. A key must
+// match every filter that is set.
+type FindOpts struct {
+	//Path, Key and Value are matched against the path of the secret, the
+	// name of the key, and its value
+	Path  *regexp.Regexp
+	Key   *regexp.Regexp
+	Value *regexp.Regexp
+	//ValueSHA256 is the hex-encoded SHA-256 digest of the value, for finding
+	// a value without having to give it
+	ValueSHA256 string
+	//AllVersions searches every version of the secret that has data, not
+	// just the latest one
+	AllVersions bool
+}
+
+// FindMatch is a key that Find found.
+type FindMatch struct {
+	Path    string
+	Key     string
+	Value   string
+	Version uint
+}
+
+// Find returns the keys of the secret that match the given filters, in the
+// order of its versions and then of its keys.
+func (s SecretEntry) Find(opts FindOpts) []FindMatch {
+	if len(s.Versions) == 0 || (opts.Path != nil && !opts.Path.MatchString(s.Path)) {
+		return nil
+	}
+
+	versions := s.Versions[len(s.Versions)-1:]
+	if opts.AllVersions {
+		versions = s.Versions
+	}
+
+	var ret []FindMatch
+	for _, version := range versions {
+		if version.Data == nil || version.State == SecretStateDestroyed {
+			continue
+		}
+		for _, key := range version.Data.Keys() {
+			value := version.Data.Get(key)
+			if opts.Key != nil && !opts.Key.MatchString(key) {
+				continue
+			}
+			if opts.Value != nil && !opts.Value.MatchString(value) {
+				continue
+			}
+			if opts.ValueSHA256 != "" {
+				sum := sha256.Sum256([]byte(value))
+				if !strings.EqualFold(hex.EncodeToString(sum[:]), opts.ValueSHA256) {
+					continue
+				}
+			}
+			ret = append(ret, FindMatch{
+				Path:    s.Path,
+				Key:     key,
+				Value:   value,
+				Version: version.Number,
+			})
+		}
+	}
+	return ret
+}
+
+// String returns the path of the matching key, as given to safe, including
+// the version if withVersion is set.
+func (m FindMatch) String(withVersion bool) string {
+	var version uint64
+	if withVersion {
+		version = uint64(m.Version)
+	}
+	return EncodePath(m.Path, m.Key, version)
+}
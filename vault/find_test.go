@@ -0,0 +1,76 @@
+package vault_test
+
+import (
+	"regexp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Finding keys", func() {
+	secretWith := func(data map[string]string) *vault.Secret {
+		s := vault.NewSecret()
+		for k, v := range data {
+			s.Set(k, v, false)
+		}
+		return s
+	}
+
+	entry := vault.SecretEntry{
+		Path: "secret/db",
+		Versions: []vault.SecretVersion{
+			{Number: 1, Data: secretWith(map[string]string{"password": "old", "user": "admin"})},
+			{Number: 2, State: vault.SecretStateDestroyed},
+			{Number: 3, Data: secretWith(map[string]string{"password": "new", "user": "admin"})},
+		},
+	}
+
+	It("searches the latest version by default", func() {
+		Expect(entry.Find(vault.FindOpts{Key: regexp.MustCompile("pass")})).To(Equal([]vault.FindMatch{
+			{Path: "secret/db", Key: "password", Value: "new", Version: 3},
+		}))
+	})
+
+	It("searches every live version with AllVersions", func() {
+		Expect(entry.Find(vault.FindOpts{
+			Value:       regexp.MustCompile("^(old|new)$"),
+			AllVersions: true,
+		})).To(Equal([]vault.FindMatch{
+			{Path: "secret/db", Key: "password", Value: "old", Version: 1},
+			{Path: "secret/db", Key: "password", Value: "new", Version: 3},
+		}))
+	})
+
+	It("matches values by their SHA-256 digest", func() {
+		// printf %s admin | sha256sum
+		matches := entry.Find(vault.FindOpts{ValueSHA256: "8C6976E5B5410415BDE908BD4DEE15DFB167A9C873FC4BB8A81F6F2AB448A918"})
+		Expect(matches).To(HaveLen(1))
+		Expect(matches[0].String(false)).To(Equal("secret/db:user"))
+		Expect(matches[0].String(true)).To(Equal("secret/db:user^3"))
+	})
+
+	It("filters by path before anything else", func() {
+		Expect(entry.Find(vault.FindOpts{Path: regexp.MustCompile("^secret/web")})).To(BeEmpty())
+	})
+
+	It("finds keys across a tree", func() {
+		fake, v := newFakeVault(map[string]map[string]string{
+			"secret/a":   {"token": "x"},
+			"secret/b/c": {"token": "y", "other": "x"},
+		})
+		defer fake.Close()
+
+		secrets, err := v.ConstructSecrets("secret", vault.TreeOpts{FetchKeys: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		var found []string
+		for _, s := range secrets {
+			for _, m := range s.Find(vault.FindOpts{Value: regexp.MustCompile("^x$")}) {
+				found = append(found, m.String(false))
+			}
+		}
+		Expect(found).To(Equal([]string{"secret/a:token", "secret/b/c:other"}))
+	})
+})
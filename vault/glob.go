@@ -0,0 +1,196 @@
+package vault
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HasGlob returns true if the secret part of the given path (i.e. not its key
+// or version) has a glob in it: * (anything within a segment of the path), **
+// (any number of segments) or {a,b} (either a or b).  A backslash escapes
+// each of these characters.
+func HasGlob(path string) bool {
+	secret, _, _ := ParsePath(path)
+	return segmentHasGlob(secret)
+}
+
+func segmentHasGlob(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '*', '{':
+			return true
+		}
+	}
+	return false
+}
+
+// Glob returns every secret whose path matches the given pattern, sorted, with
+// the key and version of the pattern (if it has them) added to each.  If
+// folders is set, the paths of folders that match are returned as well, for
+// commands that work on whole trees.  The first segment of the pattern (the
+// mount) can't be a glob.
+func (v *Vault) Glob(pattern string, folders bool) ([]string, error) {
+	secret, key, version := ParsePath(pattern)
+
+	found := map[string]bool{}
+	for _, expanded := range expandBraces(secret) {
+		segments := strings.Split(Canonicalize(expanded), "/")
+		if segmentHasGlob(segments[0]) {
+			return nil, fmt.Errorf("Cannot match mounts against `%s'; the first segment of a glob must be the name of a mount", pattern)
+		}
+		if len(segments) == 1 {
+			if folders {
+				found[unescapeGlob(segments[0])] = true
+			}
+			continue
+		}
+		if err := v.glob(unescapeGlob(segments[0]), segments[1:], folders, found); err != nil {
+			return nil, err
+		}
+	}
+
+	matches := make([]string, 0, len(found))
+	for match := range found {
+		matches = append(matches, match)
+	}
+	sort.Strings(matches)
+	for i, match := range matches {
+		matches[i] = EncodePath(match, key, version)
+	}
+	return matches, nil
+}
+
+// glob adds everything beneath dir that matches segments to found.
+func (v *Vault) glob(dir string, segments []string, folders bool, found map[string]bool) error {
+	seg, rest := segments[0], segments[1:]
+	if !segmentHasGlob(seg) && len(rest) > 0 {
+		return v.glob(dir+"/"+unescapeGlob(seg), rest, folders, found)
+	}
+
+	names, err := v.List(dir)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if seg == "**" {
+		//** matches nothing at all, or one more segment and then itself again
+		if len(rest) > 0 {
+			if err = v.glob(dir, rest, folders, found); err != nil {
+				return err
+			}
+		}
+		for _, name := range names {
+			if !strings.HasSuffix(name, "/") {
+				if len(rest) == 0 {
+					found[dir+"/"+name] = true
+				}
+				continue
+			}
+			if err = v.glob(dir+"/"+strings.TrimSuffix(name, "/"), segments, folders, found); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	match := globSegment(seg)
+	for _, name := range names {
+		folder := strings.HasSuffix(name, "/")
+		name = strings.TrimSuffix(name, "/")
+		if !match.MatchString(name) {
+			continue
+		}
+		switch {
+		case len(rest) == 0 && folder:
+			if folders {
+				found[dir+"/"+name] = true
+			}
+		case len(rest) == 0:
+			found[dir+"/"+name] = true
+		case folder:
+			if err = v.glob(dir+"/"+name, rest, folders, found); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// globSegment compiles a segment of a glob into a regular expression.
+func globSegment(seg string) *regexp.Regexp {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(seg); i++ {
+		switch {
+		case seg[i] == '\\' && i+1 < len(seg):
+			i++
+			re.WriteString(regexp.QuoteMeta(seg[i : i+1]))
+		case seg[i] == '*':
+			re.WriteString(".*")
+		default:
+			re.WriteString(regexp.QuoteMeta(seg[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String())
+}
+
+// unescapeGlob removes the backslashes from a segment of a glob with no globs
+// in it.
+func unescapeGlob(seg string) string {
+	var s strings.Builder
+	for i := 0; i < len(seg); i++ {
+		if seg[i] == '\\' && i+1 < len(seg) && strings.IndexByte(`*{},\`, seg[i+1]) >= 0 {
+			i++
+		}
+		s.WriteByte(seg[i])
+	}
+	return s.String()
+}
+
+// expandBraces expands each {a,b} in pattern, which can be nested, into the
+// patterns with a and with b instead.
+func expandBraces(pattern string) []string {
+	open, depth := -1, 0
+	var commas []int
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			if depth == 0 {
+				open, commas = i, nil
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+
+			var expanded []string
+			start := open + 1
+			for _, end := range append(commas, i) {
+				alt := pattern[:open] + pattern[start:end] + pattern[i+1:]
+				expanded = append(expanded, expandBraces(alt)...)
+				start = end + 1
+			}
+			return expanded
+		}
+	}
+	return []string{pattern}
+}
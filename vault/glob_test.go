@@ -0,0 +1,74 @@
+package vault_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Globs", func() {
+	var fake *fakeVault
+	var v *vault.Vault
+
+	BeforeEach(func() {
+		fake, v = newFakeVault(map[string]map[string]string{
+			"secret/app/db":        {"password": "1"},
+			"secret/web/db":        {"password": "2"},
+			"secret/web/cache":     {"password": "3"},
+			"secret/tmp-a":         {"k": "4"},
+			"secret/tmp-a/nested":  {"k": "5"},
+			"secret/tmp-b/x":       {"k": "6"},
+			"secret/certs/ca":      {"certificate": "7"},
+			"secret/certs/a/b/web": {"certificate": "8"},
+			"secret/odd:name":      {"k": "9"},
+			"secret/star*":         {"k": "10"},
+		})
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("knows which paths have globs in them", func() {
+		Expect(vault.HasGlob("secret/*/db:password")).To(BeTrue())
+		Expect(vault.HasGlob("secret/{a,b}")).To(BeTrue())
+		Expect(vault.HasGlob("secret/a:*")).To(BeFalse())
+		Expect(vault.HasGlob(`secret/star\*`)).To(BeFalse())
+		Expect(vault.HasGlob(`secret/odd\:name`)).To(BeFalse())
+	})
+
+	It("matches within a segment, keeping the key and version", func() {
+		Expect(v.Glob("secret/*/db:password", false)).To(Equal([]string{"secret/app/db:password", "secret/web/db:password"}))
+		Expect(v.Glob("secret/web/*^2", false)).To(Equal([]string{"secret/web/cache^2", "secret/web/db^2"}))
+		Expect(v.Glob("secret/*/nope", false)).To(BeEmpty())
+		Expect(v.Glob("secret/nope/*", false)).To(BeEmpty())
+	})
+
+	It("matches any number of segments with **", func() {
+		Expect(v.Glob("secret/certs/**", false)).To(Equal([]string{"secret/certs/a/b/web", "secret/certs/ca"}))
+		Expect(v.Glob("secret/**/web", false)).To(Equal([]string{"secret/certs/a/b/web"}))
+		Expect(v.Glob("secret/**/db", false)).To(Equal([]string{"secret/app/db", "secret/web/db"}))
+	})
+
+	It("matches each of a set of alternatives", func() {
+		Expect(v.Glob("secret/{app,web}/db", false)).To(Equal([]string{"secret/app/db", "secret/web/db"}))
+		Expect(v.Glob("secret/{app/db,web/{cache,nope}}", false)).To(Equal([]string{"secret/app/db", "secret/web/cache"}))
+	})
+
+	It("only matches folders when asked to", func() {
+		Expect(v.Glob("secret/tmp-*", false)).To(Equal([]string{"secret/tmp-a"}))
+		Expect(v.Glob("secret/tmp-*", true)).To(Equal([]string{"secret/tmp-a", "secret/tmp-b"}))
+	})
+
+	It("keeps escaped characters", func() {
+		Expect(v.Glob("secret/odd*", false)).To(Equal([]string{`secret/odd\:name`}))
+		Expect(v.Glob(`secret/odd\:*:k`, false)).To(Equal([]string{`secret/odd\:name:k`}))
+		Expect(v.Glob(`secret/{star\*,nope}`, false)).To(Equal([]string{"secret/star*"}))
+	})
+
+	It("will not match mounts", func() {
+		_, err := v.Glob("*/db", false)
+		Expect(err).To(HaveOccurred())
+	})
+})
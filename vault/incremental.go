@@ -0,0 +1,37 @@
+package vault
+
+import "time"
+
+// ChangedSince returns true if a version of the secret was created or deleted
+// after the given time, or if the secret has no timestamps to go by.
+func (s SecretEntry) ChangedSince(cutoff time.Time) bool {
+	timestamped := false
+	for _, version := range s.Versions {
+		if version.CreatedAt.IsZero() {
+			continue
+		}
+		timestamped = true
+		if version.CreatedAt.After(cutoff) || version.DeletedAt.After(cutoff) {
+			return true
+		}
+	}
+	return !timestamped
+}
+
+// RemovedSince returns true if the latest version of the secret is no longer
+// alive, and it may have stopped being so after the given time. Destroyed
+// versions do not record when they were destroyed, so they always count.
+func (s SecretEntry) RemovedSince(cutoff time.Time) bool {
+	if len(s.Versions) == 0 {
+		return false
+	}
+	latest := s.Versions[len(s.Versions)-1]
+	switch latest.State {
+	case SecretStateDestroyed:
+		return true
+	case SecretStateDeleted:
+		return latest.DeletedAt.IsZero() || latest.DeletedAt.After(cutoff)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,50 @@
+package vault_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Incremental exports", func() {
+	cutoff := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	before := cutoff.Add(-time.Hour)
+	after := cutoff.Add(time.Hour)
+
+	entry := func(versions ...vault.SecretVersion) vault.SecretEntry {
+		return vault.SecretEntry{Path: "secret/x", Versions: versions}
+	}
+
+	Describe("ChangedSince", func() {
+		It("finds versions created after the cutoff", func() {
+			Expect(entry(vault.SecretVersion{CreatedAt: before}, vault.SecretVersion{CreatedAt: after}).ChangedSince(cutoff)).To(BeTrue())
+			Expect(entry(vault.SecretVersion{CreatedAt: before}).ChangedSince(cutoff)).To(BeFalse())
+		})
+
+		It("finds versions deleted after the cutoff", func() {
+			Expect(entry(vault.SecretVersion{CreatedAt: before, DeletedAt: after, State: vault.SecretStateDeleted}).ChangedSince(cutoff)).To(BeTrue())
+		})
+
+		It("always includes secrets without timestamps", func() {
+			Expect(entry(vault.SecretVersion{}).ChangedSince(cutoff)).To(BeTrue())
+		})
+	})
+
+	Describe("RemovedSince", func() {
+		It("is true for secrets deleted after the cutoff", func() {
+			Expect(entry(vault.SecretVersion{CreatedAt: before, DeletedAt: after, State: vault.SecretStateDeleted}).RemovedSince(cutoff)).To(BeTrue())
+			Expect(entry(vault.SecretVersion{CreatedAt: before, DeletedAt: before, State: vault.SecretStateDeleted}).RemovedSince(cutoff)).To(BeFalse())
+		})
+
+		It("is always true for destroyed secrets", func() {
+			Expect(entry(vault.SecretVersion{CreatedAt: before, State: vault.SecretStateDestroyed}).RemovedSince(cutoff)).To(BeTrue())
+		})
+
+		It("is false for live secrets", func() {
+			Expect(entry(vault.SecretVersion{CreatedAt: after}).RemovedSince(cutoff)).To(BeFalse())
+		})
+	})
+})
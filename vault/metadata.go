@@ -0,0 +1,449 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+)
+
+// SecretMetadata holds the user-configurable settings stored alongside a
+// KV v2 secret. The version history itself is available through Versions.
+type SecretMetadata struct {
+	MaxVersions        uint              `json:"max_versions" yaml:"max_versions"`
+	CASRequired        bool              `json:"cas_required" yaml:"cas_required"`
+	DeleteVersionAfter string            `json:"delete_version_after" yaml:"delete_version_after"`
+	CustomMetadata     map[string]string `json:"custom_metadata,omitempty" yaml:"custom_metadata,omitempty"`
+}
+
+// IsDefault returns true if none of the settings differ from what Vault
+// assigns to a freshly created secret.
+func (m SecretMetadata) IsDefault() bool {
+	return m.MaxVersions == 0 && !m.CASRequired &&
+		isZeroDuration(m.DeleteVersionAfter) && len(m.CustomMetadata) == 0
+}
+
+// MountConfig holds the KV v2 settings that apply to every secret in a mount,
+// as exposed through the <mount>/config endpoint.
+type MountConfig struct {
+	MaxVersions        uint   `json:"max_versions" yaml:"max_versions"`
+	CASRequired        bool   `json:"cas_required" yaml:"cas_required"`
+	DeleteVersionAfter string `json:"delete_version_after" yaml:"delete_version_after"`
+}
+
+// IsDefault returns true if none of the settings differ from what Vault
+// assigns to a freshly enabled mount.
+func (c MountConfig) IsDefault() bool {
+	return c.MaxVersions == 0 && !c.CASRequired && isZeroDuration(c.DeleteVersionAfter)
+}
+
+// SetField changes one of the settings, named as it is in the Vault API, to
+// the given value, as given to safe meta set. Custom metadata is set as
+// custom_metadata.NAME, and removed by setting it to nothing.
+func (m *SecretMetadata) SetField(name, value string) error {
+	if key := strings.TrimPrefix(name, "custom_metadata."); key != name && key != "" {
+		if value == "" {
+			delete(m.CustomMetadata, key)
+			return nil
+		}
+		if m.CustomMetadata == nil {
+			m.CustomMetadata = map[string]string{}
+		}
+		m.CustomMetadata[key] = value
+		return nil
+	}
+	return setCommonField(name, value, &m.MaxVersions, &m.CASRequired, &m.DeleteVersionAfter)
+}
+
+// SetField changes one of the settings, named as it is in the Vault API, to
+// the given value.
+func (c *MountConfig) SetField(name, value string) error {
+	return setCommonField(name, value, &c.MaxVersions, &c.CASRequired, &c.DeleteVersionAfter)
+}
+
+// setCommonField sets one of the settings that secrets and mounts share.
+func setCommonField(name, value string, maxVersions *uint, casRequired *bool, deleteVersionAfter *string) error {
+	switch name {
+	case "max_versions":
+		n, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return fmt.Errorf("max_versions must be a number, not `%s'", value)
+		}
+		*maxVersions = uint(n)
+	case "cas_required":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("cas_required must be true or false, not `%s'", value)
+		}
+		*casRequired = b
+	case "delete_version_after":
+		d, err := parseSeconds(value)
+		if err != nil {
+			return fmt.Errorf("delete_version_after must be a duration (i.e. 768h), not `%s'", value)
+		}
+		*deleteVersionAfter = d.String()
+	default:
+		return fmt.Errorf("unknown setting `%s'", name)
+	}
+	return nil
+}
+
+// parseSeconds parses a duration like Vault does: either a Go duration, or a
+// plain number of seconds.
+func parseSeconds(s string) (time.Duration, error) {
+	if n, err := strconv.ParseUint(s, 10, 0); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func isZeroDuration(d string) bool {
+	return d == "" || d == "0s" || d == "0"
+}
+
+func normalizeDuration(d string) string {
+	if isZeroDuration(d) {
+		return "0s"
+	}
+	return d
+}
+
+// metadataPath splits the given secret path into its mount and the path of
+// the secret relative to that mount. It errors if the mount is not KV v2.
+func (v *Vault) metadataPath(path string) (mount, subpath string, err error) {
+	return v.v2Path(path, "metadata")
+}
+
+// v2Path is metadataPath, for the given KV v2 feature.
+func (v *Vault) v2Path(path, feature string) (mount, subpath string, err error) {
+	path = Canonicalize(path)
+	mountVersion, err := v.MountVersion(path)
+	if err != nil {
+		return "", "", err
+	}
+	if mountVersion != 2 {
+		return "", "", fmt.Errorf("`%s' is not in a KV v2 mount; %s is not supported", path, feature)
+	}
+
+	mount, err = v.client.MountPath(path)
+	if err != nil {
+		return "", "", err
+	}
+	mount = strings.Trim(mount, "/")
+	subpath = strings.Trim(strings.TrimPrefix(path, mount), "/")
+	return mount, subpath, nil
+}
+
+// Metadata retrieves the settings stored in the metadata of the KV v2 secret
+// at the given path.
+func (v *Vault) Metadata(path string) (SecretMetadata, error) {
+	secret, _, _ := ParsePath(path)
+	mount, subpath, err := v.metadataPath(secret)
+	if err != nil {
+		return SecretMetadata{}, err
+	}
+
+	var raw struct {
+		Data SecretMetadata `json:"data"`
+	}
+	err = v.curlJSON("GET", fmt.Sprintf("%s/metadata/%s", mount, subpath), nil, &raw)
+	if isStatus(err, 404) {
+		err = NewSecretNotFoundError(secret)
+	}
+	if err != nil {
+		return SecretMetadata{}, err
+	}
+
+	raw.Data.DeleteVersionAfter = normalizeDuration(raw.Data.DeleteVersionAfter)
+	return raw.Data, nil
+}
+
+// MetadataAll retrieves the metadata of each of the given KV v2 secrets, using
+// as many workers at once as the Vault is configured for. Secrets that are not
+// there are left out, as are those whose metadata the token may not read,
+// which are returned (sorted) instead.
+func (v *Vault) MetadataAll(paths []string) (map[string]SecretMetadata, []string, error) {
+	metadata := make(map[string]SecretMetadata, len(paths))
+	var forbidden []string
+	var lock sync.Mutex
+	var firstErr error
+
+	todo := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < v.concurrency.workers() && i < len(paths); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range todo {
+				meta, err := v.Metadata(path)
+				lock.Lock()
+				switch {
+				case err == nil:
+					metadata[path] = meta
+				case IsForbidden(err):
+					forbidden = append(forbidden, path)
+				case !IsNotFound(err) && firstErr == nil:
+					firstErr = err
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for _, path := range paths {
+		todo <- path
+	}
+	close(todo)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	sort.Strings(forbidden)
+	return metadata, forbidden, nil
+}
+
+// SetMetadata replaces the settings stored in the metadata of the KV v2 secret
+// at the given path. Settings left at their zero value are reset to the Vault
+// defaults.
+func (v *Vault) SetMetadata(path string, meta SecretMetadata) error {
+	secret, _, _ := ParsePath(path)
+	mount, subpath, err := v.metadataPath(secret)
+	if err != nil {
+		return err
+	}
+
+	meta.DeleteVersionAfter = normalizeDuration(meta.DeleteVersionAfter)
+	if meta.CustomMetadata == nil {
+		meta.CustomMetadata = map[string]string{}
+	}
+	return v.curlJSON("POST", fmt.Sprintf("%s/metadata/%s", mount, subpath), meta, nil)
+}
+
+// VersionHistory returns the metadata of every version of the KV v2 secret at
+// the given path, oldest first. Unlike Versions, it includes when each version
+// was deleted.
+func (v *Vault) VersionHistory(path string) ([]vaultkv.V2Version, error) {
+	secret, _, _ := ParsePath(path)
+	mount, subpath, err := v.metadataPath(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := v.client.Client.V2GetMetadata(mount, subpath)
+	if vaultkv.IsNotFound(err) {
+		return nil, NewSecretNotFoundError(secret)
+	}
+	return meta.Versions, err
+}
+
+// MountConfig retrieves the KV v2 configuration of the mount containing the
+// given path.
+func (v *Vault) MountConfig(path string) (MountConfig, error) {
+	mount, _, err := v.metadataPath(path)
+	if err != nil {
+		return MountConfig{}, err
+	}
+
+	var raw struct {
+		Data MountConfig `json:"data"`
+	}
+	err = v.curlJSON("GET", fmt.Sprintf("%s/config", mount), nil, &raw)
+	if err != nil {
+		return MountConfig{}, err
+	}
+
+	raw.Data.DeleteVersionAfter = normalizeDuration(raw.Data.DeleteVersionAfter)
+	return raw.Data, nil
+}
+
+// SetMountConfig replaces the KV v2 configuration of the mount containing the
+// given path.
+func (v *Vault) SetMountConfig(path string, conf MountConfig) error {
+	mount, _, err := v.metadataPath(path)
+	if err != nil {
+		return err
+	}
+
+	conf.DeleteVersionAfter = normalizeDuration(conf.DeleteVersionAfter)
+	return v.curlJSON("POST", fmt.Sprintf("%s/config", mount), conf, nil)
+}
+
+// MountTuning holds the settings of a mount that are changed through
+// sys/mounts/<mount>/tune. Lease TTLs are in seconds, with 0 meaning that the
+// system default applies.
+type MountTuning struct {
+	Description       string `json:"description" yaml:"description"`
+	DefaultLeaseTTL   uint   `json:"default_lease_ttl" yaml:"default_lease_ttl"`
+	MaxLeaseTTL       uint   `json:"max_lease_ttl" yaml:"max_lease_ttl"`
+	ListingVisibility string `json:"listing_visibility,omitempty" yaml:"listing_visibility,omitempty"`
+}
+
+// SetField changes one of the settings, named as it is in the Vault API, to
+// the given value.
+func (t *MountTuning) SetField(name, value string) error {
+	switch name {
+	case "description":
+		t.Description = value
+	case "default_lease_ttl", "max_lease_ttl":
+		d, err := parseSeconds(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a duration (i.e. 768h), not `%s'", name, value)
+		}
+		if name == "default_lease_ttl" {
+			t.DefaultLeaseTTL = uint(d / time.Second)
+		} else {
+			t.MaxLeaseTTL = uint(d / time.Second)
+		}
+	case "listing_visibility":
+		if value != "" && value != "hidden" && value != "unauth" {
+			return fmt.Errorf("listing_visibility must be hidden or unauth, not `%s'", value)
+		}
+		t.ListingVisibility = value
+	default:
+		return fmt.Errorf("unknown setting `%s'", name)
+	}
+	return nil
+}
+
+// MountSettings are all of the settings of a mount: its KV v2 configuration,
+// if it is a KV v2 mount, and its tuning.
+type MountSettings struct {
+	Config *MountConfig `json:"config,omitempty" yaml:"config,omitempty"`
+	Tuning MountTuning  `json:"tune" yaml:"tune"`
+}
+
+// SetField changes one of the settings of the mount, named as it is in the
+// Vault API, whether it is part of its configuration or its tuning.
+func (s *MountSettings) SetField(name, value string) error {
+	switch name {
+	case "max_versions", "cas_required", "delete_version_after":
+		if s.Config == nil {
+			return fmt.Errorf("%s can only be set on KV v2 mounts", name)
+		}
+		return s.Config.SetField(name, value)
+	}
+	return s.Tuning.SetField(name, value)
+}
+
+// mountOf returns the path of the mount containing the given path.
+func (v *Vault) mountOf(path string) (string, error) {
+	mount, err := v.client.MountPath(Canonicalize(path))
+	return strings.Trim(mount, "/"), err
+}
+
+// MountSettings retrieves the settings of the mount containing the given
+// path.
+func (v *Vault) MountSettings(path string) (MountSettings, error) {
+	mount, err := v.mountOf(path)
+	if err != nil {
+		return MountSettings{}, err
+	}
+
+	var ret MountSettings
+	var raw struct {
+		Data *MountTuning `json:"data"`
+		MountTuning
+	}
+	if err = v.curlJSON("GET", fmt.Sprintf("sys/mounts/%s/tune", mount), nil, &raw); err != nil {
+		return MountSettings{}, err
+	}
+	//Older Vaults only answer with the tuning at the top level
+	ret.Tuning = raw.MountTuning
+	if raw.Data != nil {
+		ret.Tuning = *raw.Data
+	}
+
+	if mountVersion, _ := v.MountVersion(mount); mountVersion == 2 {
+		conf, err := v.MountConfig(mount)
+		if err != nil {
+			return MountSettings{}, err
+		}
+		ret.Config = &conf
+	}
+	return ret, nil
+}
+
+// SetMountSettings changes the settings of the mount containing the given
+// path to s. Only the settings that differ from what they are now are sent,
+// since Vault reports the system defaults that a mount falls back on as its
+// own settings. Its KV v2 configuration is only written if Config is set.
+func (v *Vault) SetMountSettings(path string, s MountSettings) error {
+	mount, err := v.mountOf(path)
+	if err != nil {
+		return err
+	}
+	current, err := v.MountSettings(mount)
+	if err != nil {
+		return err
+	}
+
+	tune := map[string]interface{}{}
+	if s.Tuning.Description != current.Tuning.Description {
+		tune["description"] = s.Tuning.Description
+	}
+	if s.Tuning.DefaultLeaseTTL != current.Tuning.DefaultLeaseTTL {
+		tune["default_lease_ttl"] = fmt.Sprintf("%ds", s.Tuning.DefaultLeaseTTL)
+	}
+	if s.Tuning.MaxLeaseTTL != current.Tuning.MaxLeaseTTL {
+		tune["max_lease_ttl"] = fmt.Sprintf("%ds", s.Tuning.MaxLeaseTTL)
+	}
+	if s.Tuning.ListingVisibility != current.Tuning.ListingVisibility {
+		tune["listing_visibility"] = s.Tuning.ListingVisibility
+	}
+	if len(tune) > 0 {
+		if err = v.curlJSON("POST", fmt.Sprintf("sys/mounts/%s/tune", mount), tune, nil); err != nil {
+			return err
+		}
+	}
+
+	if s.Config != nil && (current.Config == nil || *s.Config != *current.Config) {
+		return v.SetMountConfig(mount, *s.Config)
+	}
+	return nil
+}
+
+// curlJSON sends in (if non-nil) as a JSON body to the given API path, and
+// decodes a JSON response body into out (if non-nil). Non-2xx responses are
+// returned as errors that retain the HTTP status code.
+func (v *Vault) curlJSON(method, path string, in, out interface{}) error {
+	return v.curlJSONWithHeaders(method, path, in, out, nil)
+}
+
+// curlJSONWithHeaders is curlJSON, with headers added to the request.
+func (v *Vault) curlJSONWithHeaders(method, path string, in, out interface{}, headers http.Header) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+
+	res, err := v.CurlWithHeaders(method, path, body, headers)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode/100 != 2 {
+		return apiError{code: res.StatusCode, err: DecodeErrorResponse(b)}
+	}
+
+	if out != nil && len(b) > 0 {
+		return json.Unmarshal(b, out)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package vault_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Metadata", func() {
+	Describe("SecretMetadata.IsDefault", func() {
+		It("is true for the zero value", func() {
+			Expect(vault.SecretMetadata{}.IsDefault()).To(BeTrue())
+		})
+
+		It("treats a zero delete_version_after as default", func() {
+			Expect(vault.SecretMetadata{DeleteVersionAfter: "0s"}.IsDefault()).To(BeTrue())
+		})
+
+		It("is false when max_versions is set", func() {
+			Expect(vault.SecretMetadata{MaxVersions: 3}.IsDefault()).To(BeFalse())
+		})
+
+		It("is false when cas_required is set", func() {
+			Expect(vault.SecretMetadata{CASRequired: true}.IsDefault()).To(BeFalse())
+		})
+
+		It("is false when delete_version_after is non-zero", func() {
+			Expect(vault.SecretMetadata{DeleteVersionAfter: "1h0m0s"}.IsDefault()).To(BeFalse())
+		})
+
+		It("is false when custom_metadata is present", func() {
+			Expect(vault.SecretMetadata{CustomMetadata: map[string]string{"owner": "ops"}}.IsDefault()).To(BeFalse())
+		})
+	})
+
+	Describe("MountConfig.IsDefault", func() {
+		It("is true for the zero value", func() {
+			Expect(vault.MountConfig{DeleteVersionAfter: "0s"}.IsDefault()).To(BeTrue())
+		})
+
+		It("is false when any setting differs", func() {
+			Expect(vault.MountConfig{MaxVersions: 10}.IsDefault()).To(BeFalse())
+			Expect(vault.MountConfig{CASRequired: true}.IsDefault()).To(BeFalse())
+			Expect(vault.MountConfig{DeleteVersionAfter: "768h0m0s"}.IsDefault()).To(BeFalse())
+		})
+	})
+
+	Describe("SecretMetadata.SetField", func() {
+		It("sets each of the settings", func() {
+			m := vault.SecretMetadata{}
+			Expect(m.SetField("max_versions", "5")).To(Succeed())
+			Expect(m.SetField("cas_required", "true")).To(Succeed())
+			Expect(m.SetField("delete_version_after", "768h")).To(Succeed())
+			Expect(m.SetField("custom_metadata.owner", "ops")).To(Succeed())
+			Expect(m).To(Equal(vault.SecretMetadata{
+				MaxVersions:        5,
+				CASRequired:        true,
+				DeleteVersionAfter: "768h0m0s",
+				CustomMetadata:     map[string]string{"owner": "ops"},
+			}))
+		})
+
+		It("takes durations in seconds", func() {
+			m := vault.SecretMetadata{}
+			Expect(m.SetField("delete_version_after", "3600")).To(Succeed())
+			Expect(m.DeleteVersionAfter).To(Equal("1h0m0s"))
+		})
+
+		It("removes custom metadata set to nothing", func() {
+			m := vault.SecretMetadata{CustomMetadata: map[string]string{"owner": "ops", "team": "a"}}
+			Expect(m.SetField("custom_metadata.owner", "")).To(Succeed())
+			Expect(m.CustomMetadata).To(Equal(map[string]string{"team": "a"}))
+		})
+
+		It("rejects unknown settings and bad values", func() {
+			m := vault.SecretMetadata{}
+			Expect(m.SetField("max_version", "5")).ToNot(Succeed())
+			Expect(m.SetField("max_versions", "lots")).ToNot(Succeed())
+			Expect(m.SetField("cas_required", "maybe")).ToNot(Succeed())
+			Expect(m.SetField("delete_version_after", "soon")).ToNot(Succeed())
+		})
+	})
+
+	Describe("VersionHistory", func() {
+		It("says when each version was deleted", func() {
+			deleted := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+			fake, v := newFakeVaultV2(map[string][]fakeVersion{
+				"secret/db": {{DeletedAt: deleted}, {Destroyed: true}, {Data: map[string]string{"k": "v"}}},
+			})
+			defer fake.Close()
+
+			versions, err := v.VersionHistory("secret/db:k")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(HaveLen(3))
+			Expect(*versions[0].DeletedAt).To(BeTemporally("==", deleted))
+			Expect(versions[1].Destroyed).To(BeTrue())
+			Expect(versions[2].DeletedAt).To(BeNil())
+
+			_, err = v.VersionHistory("secret/nope")
+			Expect(vault.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Describe("MetadataAll", func() {
+		It("reads each of them, setting aside those it may not read", func() {
+			fake, v := newFakeVaultV2(map[string][]fakeVersion{
+				"secret/a": aliveVersions(1),
+				"secret/b": aliveVersions(1),
+				"secret/c": aliveVersions(1),
+			})
+			defer fake.Close()
+			fake.metadata = map[string]vault.SecretMetadata{"secret/a": {MaxVersions: 5, CustomMetadata: map[string]string{"owner": "ops"}}}
+			fake.forbidden = map[string]bool{"secret/c": true}
+
+			metadata, forbidden, err := v.MetadataAll([]string{"secret/a", "secret/b", "secret/c", "secret/nope"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(forbidden).To(Equal([]string{"secret/c"}))
+			Expect(metadata).To(HaveLen(2))
+			Expect(metadata["secret/a"].MaxVersions).To(Equal(uint(5)))
+			Expect(metadata["secret/a"].CustomMetadata).To(Equal(map[string]string{"owner": "ops"}))
+			Expect(metadata["secret/b"].IsDefault()).To(BeTrue())
+		})
+	})
+
+	Describe("MountSettings", func() {
+		var fake *fakeVault
+		var v *vault.Vault
+
+		BeforeEach(func() {
+			fake, v = newFakeVaultV2(map[string][]fakeVersion{})
+			fake.tuning["description"] = "secrets"
+		})
+
+		AfterEach(func() {
+			fake.Close()
+		})
+
+		It("reads the tuning and KV v2 configuration of a mount", func() {
+			s, err := v.MountSettings("secret/some/path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.Tuning).To(Equal(vault.MountTuning{Description: "secrets", DefaultLeaseTTL: 2764800, MaxLeaseTTL: 2764800}))
+			Expect(s.Config).To(Equal(&vault.MountConfig{DeleteVersionAfter: "0s"}))
+		})
+
+		It("only sends the settings that changed", func() {
+			s, err := v.MountSettings("secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(s.SetField("max_lease_ttl", "48h")).To(Succeed())
+			Expect(s.SetField("description", "secrets")).To(Succeed())
+			Expect(v.SetMountSettings("secret", s)).To(Succeed())
+			Expect(fake.tuned).To(Equal([]map[string]interface{}{{"max_lease_ttl": "172800s"}}))
+			Expect(fake.configured).To(BeEmpty())
+
+			Expect(s.SetField("max_versions", "10")).To(Succeed())
+			Expect(v.SetMountSettings("secret", s)).To(Succeed())
+			Expect(fake.configured).To(HaveLen(1))
+			Expect(fake.configured[0]["max_versions"]).To(BeEquivalentTo(10))
+		})
+
+		It("only sets KV v2 configuration on KV v2 mounts", func() {
+			s := vault.MountSettings{}
+			Expect(s.SetField("max_versions", "10")).ToNot(Succeed())
+			Expect(s.SetField("listing_visibility", "sometimes")).ToNot(Succeed())
+			Expect(s.SetField("listing_visibility", "unauth")).To(Succeed())
+		})
+	})
+})
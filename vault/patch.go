@@ -0,0 +1,130 @@
+package vault
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxPatchAttempts is how many times Patch reads, updates and writes back a
+// secret with check-and-set before it gives up on getting its write in.
+const maxPatchAttempts = 10
+
+// Patch changes some of the keys of the secret at path, without losing any
+// changes made to its other keys at the same time. update is given the secret
+// as it is in Vault, and changes it; it may be called more than once.
+//
+// On KV v2, only the keys that update changed are sent, as a JSON merge patch
+// (on Vault 1.9 and up, to tokens with the patch capability), or else the
+// whole secret is written back with
+// check-and-set, and read and updated again if someone else got there first.
+// KV v1 has no way to do either, so there Patch just reads, updates and
+// writes the secret back, and opts.CAS is an error.
+func (v *Vault) Patch(path string, update func(*Secret) error, opts WriteOpts) error {
+	path, key, version := ParsePath(path)
+	if key != "" {
+		return fmt.Errorf("cannot write to paths in /path:key notation")
+	}
+	if version != 0 {
+		return fmt.Errorf("cannot write to paths in /path^version notation")
+	}
+
+	mountVersion, err := v.MountVersion(path)
+	if err != nil {
+		return err
+	}
+	if mountVersion != 2 {
+		if opts.CAS != nil {
+			return fmt.Errorf("`%s' is not in a KV v2 mount; check-and-set is not supported", path)
+		}
+		s, err := v.Read(path)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+		if err = update(s); err != nil {
+			return err
+		}
+		return v.Write(path, s)
+	}
+
+	for attempt := 1; ; attempt++ {
+		s, current, err := v.ReadVersion(path)
+		exists := err == nil
+		if IsSecretNotFound(err) {
+			s = NewSecret()
+			//The latest version may have been deleted, but it still counts
+			current, err = v.currentVersion(path)
+		}
+		if err != nil {
+			return err
+		}
+		if opts.CAS != nil && *opts.CAS != current {
+			return NewCASMismatchError(path, *opts.CAS)
+		}
+
+		before := make(map[string]string, len(s.data))
+		for k, val := range s.data {
+			before[k] = val
+		}
+		if err = update(s); err != nil {
+			return err
+		}
+		changes := mergePatch(before, s.data)
+		if len(changes) == 0 {
+			return nil
+		}
+
+		if exists {
+			err = v.patch(path, changes, opts.CAS)
+			if !isStatus(err, 405) && !isStatus(err, 403) && !isStatus(err, 404) {
+				return err
+			}
+			//Merge patches aren't supported, the token has update but not patch
+			// capability, or the latest version was deleted between reading and
+			// patching it; write the whole secret instead
+		}
+
+		_, err = v.WriteCAS(path, s, current)
+		if !IsCASMismatch(err) || opts.CAS != nil || attempt >= maxPatchAttempts {
+			return err
+		}
+	}
+}
+
+// mergePatch returns the JSON merge patch that turns before into after: the
+// keys that were added or changed, and nulls for the keys that were removed.
+func mergePatch(before, after map[string]string) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for k, val := range after {
+		if old, found := before[k]; !found || old != val {
+			patch[k] = val
+		}
+	}
+	for k := range before {
+		if _, found := after[k]; !found {
+			patch[k] = nil
+		}
+	}
+	return patch
+}
+
+// patch sends a JSON merge patch for the KV v2 secret at path, only to be
+// applied if its latest version is *cas, if cas is set.
+func (v *Vault) patch(path string, changes map[string]interface{}, cas *uint) error {
+	mount, subpath, err := v.v2Path(path, "patching")
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{"data": changes}
+	if cas != nil {
+		body["options"] = map[string]interface{}{"cas": *cas}
+	}
+	//Vault only takes PATCHes that are JSON merge patches
+	headers := http.Header{"Content-Type": {"application/merge-patch+json"}}
+	err = v.curlJSONWithHeaders("PATCH", fmt.Sprintf("%s/data/%s", mount, subpath), body, nil, headers)
+	if cas != nil && isStatus(err, 400) && strings.Contains(err.Error(), "check-and-set") {
+		return NewCASMismatchError(path, *cas)
+	}
+	return err
+}
@@ -3,6 +3,8 @@ package vault
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/cloudfoundry-community/vaultkv"
 )
@@ -11,12 +13,19 @@ import (
 // If there is nothing at that path, a nil *Secret will be returned, with no
 // error.
 func (v *Vault) Read(path string) (secret *Secret, err error) {
+	secret, _, err = v.ReadVersion(path)
+	return
+}
+
+// ReadVersion is like Read, but also returns the version of the secret that
+// it read, to be given to WriteCAS. The version is 0 for KV v1 secrets.
+func (v *Vault) ReadVersion(path string) (secret *Secret, number uint, err error) {
 	path, key, version := ParsePath(path)
 
 	secret = NewSecret()
 
 	raw := map[string]interface{}{}
-	_, err = v.client.Get(path, &raw, &vaultkv.KVGetOpts{Version: uint(version)})
+	meta, err := v.client.Get(path, &raw, &vaultkv.KVGetOpts{Version: uint(version)})
 	if err != nil {
 		if vaultkv.IsNotFound(err) {
 			err = NewSecretNotFoundError(path)
@@ -27,7 +36,7 @@ func (v *Vault) Read(path string) (secret *Secret, err error) {
 	if key != "" {
 		val, found := raw[key]
 		if !found {
-			return nil, NewKeyNotFoundError(path, key)
+			return nil, 0, NewKeyNotFoundError(path, key)
 		}
 		raw = map[string]interface{}{key: val}
 	}
@@ -47,9 +56,82 @@ func (v *Vault) Read(path string) (secret *Secret, err error) {
 		}
 	}
 
+	if mountVersion, _ := v.MountVersion(path); mountVersion == 2 {
+		number = meta.Version
+	}
 	return
 }
 
+// ReadAll reads the secrets at each of the given paths, as many at once as
+// the Vault's concurrency allows, and returns them keyed by path. Paths with
+// no secret (or key) there are left out, rather than being an error.
+func (v *Vault) ReadAll(paths []string) (map[string]*Secret, error) {
+	secrets := make(map[string]*Secret, len(paths))
+	var lock sync.Mutex
+	var firstErr error
+
+	todo := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < v.concurrency.workers() && i < len(paths); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range todo {
+				s, err := v.Read(path)
+				lock.Lock()
+				switch {
+				case err == nil:
+					secrets[path] = s
+				case !IsNotFound(err) && firstErr == nil:
+					firstErr = err
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for _, path := range paths {
+		todo <- path
+	}
+	close(todo)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return secrets, nil
+}
+
+// WriteCAS is like Write, but only writes the secret if the latest version of
+// it is still the given version, or if it does not exist yet when version is
+// 0. Otherwise, it returns an error for which IsCASMismatch is true. It
+// returns the version that it wrote. Only KV v2 secrets support this.
+func (v *Vault) WriteCAS(path string, s *Secret, version uint) (uint, error) {
+	path, key, pathVersion := ParsePath(path)
+	if key != "" {
+		return 0, fmt.Errorf("cannot write to paths in /path:key notation")
+	}
+	if pathVersion != 0 {
+		return 0, fmt.Errorf("cannot write to paths in /path^version notation")
+	}
+
+	mount, subpath, err := v.v2Path(path, "check-and-set")
+	if err != nil {
+		return 0, err
+	}
+
+	meta, err := v.client.Client.V2Set(mount, subpath, s.data, vaultkv.V2SetOpts{}.WithCAS(version))
+	if err != nil {
+		if vaultkv.IsBadRequest(err) && strings.Contains(err.Error(), "check-and-set") {
+			return 0, NewCASMismatchError(path, version)
+		}
+		if vaultkv.IsNotFound(err) {
+			err = NewSecretNotFoundError(path)
+		}
+		return 0, err
+	}
+	return meta.Version, nil
+}
+
 // List returns the set of (relative) paths that are directly underneath
 // the given path.  Intermediate path nodes are suffixed with a single "/",
 // whereas leaf nodes (the secrets themselves) are not.
@@ -64,8 +146,22 @@ func (v *Vault) List(path string) (paths []string, err error) {
 	return paths, err
 }
 
+// WriteOpts changes how Write and Patch write a secret.
+type WriteOpts struct {
+	//CAS, if set, only writes the secret if its latest version is still *CAS,
+	// or if it does not exist yet and *CAS is 0. Only KV v2 supports this.
+	CAS *uint
+}
+
 // Write takes a Secret and writes it to the Vault at the specified path.
-func (v *Vault) Write(path string, s *Secret) error {
+func (v *Vault) Write(path string, s *Secret, opts ...WriteOpts) error {
+	for _, o := range opts {
+		if o.CAS != nil {
+			_, err := v.WriteCAS(path, s, *o.CAS)
+			return err
+		}
+	}
+
 	path, key, version := ParsePath(path)
 	if key != "" {
 		return fmt.Errorf("cannot write to paths in /path:key notation")
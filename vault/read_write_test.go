@@ -0,0 +1,33 @@
+package vault_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Reading many secrets", func() {
+	var fake *fakeVault
+	var v *vault.Vault
+
+	BeforeEach(func() {
+		fake, v = newFakeVault(map[string]map[string]string{
+			"secret/a":   {"k": "1"},
+			"secret/b/c": {"k": "2", "l": "3"},
+		})
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("reads each of them, leaving out those that are not there", func() {
+		secrets, err := v.ReadAll([]string{"secret/a", "secret/b/c", "secret/b/c:l", "secret/nope", "secret/a:nope"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secrets).To(HaveLen(3))
+		Expect(secrets["secret/a"].Get("k")).To(Equal("1"))
+		Expect(secrets["secret/b/c"].Keys()).To(Equal([]string{"k", "l"}))
+		Expect(secrets["secret/b/c:l"].Keys()).To(Equal([]string{"l"}))
+	})
+})
@@ -0,0 +1,144 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MaxRefDepth is how many references in a row ResolveRef follows before it
+// gives up.
+const MaxRefDepth = 16
+
+// Ref is a reference from one path:key to another, found by FindRefs.
+type Ref struct {
+	From string
+	To   string
+}
+
+// RefLookup returns the keys of the secret at the given path (which may have
+// a version), or nil if there is no such secret.
+type RefLookup func(path string) (map[string]string, error)
+
+// ParseRef returns the path:key that value refers to, if it is a reference
+// (i.e. ((ref secret/shared/db:password))).
+func ParseRef(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "((") || !strings.HasSuffix(value, "))") {
+		return "", false
+	}
+	fields := strings.Fields(value[2 : len(value)-2])
+	if len(fields) != 2 || fields[0] != "ref" || !PathHasKey(fields[1]) {
+		return "", false
+	}
+	return fields[1], true
+}
+
+// FormatRef returns a value that refers to the given path:key.
+func FormatRef(ref string) string {
+	return "((ref " + ref + "))"
+}
+
+// ResolveRef returns value, unless it is a reference, in which case it returns
+// the value that it refers to, following any references that that is in turn.
+func ResolveRef(value string, lookup RefLookup) (string, error) {
+	var chain []string
+	for {
+		ref, isRef := ParseRef(value)
+		if !isRef {
+			return value, nil
+		}
+		for _, seen := range chain {
+			if seen == ref {
+				return "", fmt.Errorf("Reference cycle: %s -> %s", strings.Join(chain, " -> "), ref)
+			}
+		}
+		chain = append(chain, ref)
+		if len(chain) > MaxRefDepth {
+			return "", fmt.Errorf("Too many references in a row (more than %d), from %s", MaxRefDepth, chain[0])
+		}
+
+		path, key, version := ParsePath(ref)
+		data, err := lookup(EncodePath(path, "", version))
+		if err != nil {
+			return "", err
+		}
+		next, found := data[key]
+		if !found {
+			return "", fmt.Errorf("Reference to `%s', which does not exist", ref)
+		}
+		value = next
+	}
+}
+
+// ResolveRefs replaces each value of s that is a reference with the value it
+// refers to.  path is where s came from, for errors.
+func ResolveRefs(path string, s *Secret, lookup RefLookup) error {
+	for _, key := range s.Keys() {
+		value, err := ResolveRef(s.Get(key), lookup)
+		if err != nil {
+			return fmt.Errorf("%s:%s: %s", path, key, err)
+		}
+		s.Set(key, value, false)
+	}
+	return nil
+}
+
+// RefLookup returns a RefLookup that reads secrets from v, each just once.
+func (v *Vault) RefLookup() RefLookup {
+	var lock sync.Mutex
+	secrets := map[string]map[string]string{}
+	return func(path string) (map[string]string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if data, found := secrets[path]; found {
+			return data, nil
+		}
+
+		s, err := v.Read(path)
+		if err != nil && !IsNotFound(err) {
+			return nil, err
+		}
+		var data map[string]string
+		if err == nil {
+			data = make(map[string]string, len(s.Keys()))
+			for _, key := range s.Keys() {
+				data[key] = s.Get(key)
+			}
+		}
+		secrets[path] = data
+		return data, nil
+	}
+}
+
+// FindRefs returns every reference made by the latest versions of the given
+// secrets, sorted.
+func FindRefs(secrets Secrets) []Ref {
+	var refs []Ref
+	for _, s := range secrets {
+		if len(s.Versions) == 0 || s.Versions[len(s.Versions)-1].Data == nil {
+			continue
+		}
+		data := s.Versions[len(s.Versions)-1].Data
+		for _, key := range data.Keys() {
+			if to, isRef := ParseRef(data.Get(key)); isRef {
+				refs = append(refs, Ref{From: EncodePath(s.Path, key, 0), To: to})
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].From != refs[j].From {
+			return refs[i].From < refs[j].From
+		}
+		return refs[i].To < refs[j].To
+	})
+	return refs
+}
+
+// PathIsBeneath returns true if path is prefix, or somewhere beneath it.
+func PathIsBeneath(path, prefix string) bool {
+	path, _, _ = ParsePath(path)
+	path, prefix = Canonicalize(path), strings.TrimSuffix(Canonicalize(prefix), "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
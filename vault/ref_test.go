@@ -0,0 +1,123 @@
+package vault_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Secret references", func() {
+	secrets := map[string]map[string]string{
+		"secret/shared/db":   {"password": "p4ss", "old": "((ref secret/shared/db:password^1))"},
+		"secret/shared/db^1": {"password": "0ld"},
+		"secret/app/a":       {"db": "((ref secret/app/b:db))", "plain": "x"},
+		"secret/app/b":       {"db": " (( ref  secret/shared/db:password )) "},
+		"secret/loop/a":      {"k": "((ref secret/loop/b:k))"},
+		"secret/loop/b":      {"k": "((ref secret/loop/a:k))"},
+	}
+	lookup := func(path string) (map[string]string, error) {
+		return secrets[path], nil
+	}
+
+	It("recognizes references", func() {
+		ref, isRef := vault.ParseRef("((ref secret/shared/db:password))")
+		Expect(isRef).To(BeTrue())
+		Expect(ref).To(Equal("secret/shared/db:password"))
+		ref, _ = vault.ParseRef(vault.FormatRef("secret/a:b^2"))
+		Expect(ref).To(Equal("secret/a:b^2"))
+
+		for _, value := range []string{"((ref secret/shared/db))", "((secret/a:b))", "(ref secret/a:b)", "x ((ref secret/a:b))"} {
+			_, isRef = vault.ParseRef(value)
+			Expect(isRef).To(BeFalse(), value)
+		}
+	})
+
+	It("follows references through to the values they refer to", func() {
+		Expect(vault.ResolveRef("plain", lookup)).To(Equal("plain"))
+		Expect(vault.ResolveRef("((ref secret/app/a:db))", lookup)).To(Equal("p4ss"))
+		Expect(vault.ResolveRef("((ref secret/shared/db:old))", lookup)).To(Equal("0ld"))
+
+		s := vault.NewSecret()
+		s.Set("db", "((ref secret/app/b:db))", false)
+		s.Set("plain", "x", false)
+		Expect(vault.ResolveRefs("secret/app/c", s, lookup)).To(Succeed())
+		Expect(s.Get("db")).To(Equal("p4ss"))
+		Expect(s.Get("plain")).To(Equal("x"))
+	})
+
+	It("fails on references to nothing, cycles, and chains too long to follow", func() {
+		_, err := vault.ResolveRef("((ref secret/shared/db:nope))", lookup)
+		Expect(err).To(MatchError("Reference to `secret/shared/db:nope', which does not exist"))
+		_, err = vault.ResolveRef("((ref secret/nope:k))", lookup)
+		Expect(err).To(HaveOccurred())
+
+		_, err = vault.ResolveRef("((ref secret/loop/a:k))", lookup)
+		Expect(err).To(MatchError("Reference cycle: secret/loop/a:k -> secret/loop/b:k -> secret/loop/a:k"))
+
+		s := vault.NewSecret()
+		s.Set("k", "((ref secret/loop/b:k))", false)
+		Expect(vault.ResolveRefs("secret/x", s, lookup)).To(MatchError(HavePrefix("secret/x:k: Reference cycle")))
+
+		chain := func(path string) (map[string]string, error) {
+			return map[string]string{"k": vault.FormatRef(path + "/next:k")}, nil
+		}
+		_, err = vault.ResolveRef("((ref secret/deep:k))", chain)
+		Expect(err).To(MatchError(ContainSubstring("more than 16")))
+	})
+
+	It("finds the references that secrets make", func() {
+		data := func(kv ...string) *vault.Secret {
+			s := vault.NewSecret()
+			for i := 0; i < len(kv); i += 2 {
+				s.Set(kv[i], kv[i+1], false)
+			}
+			return s
+		}
+		refs := vault.FindRefs(vault.Secrets{
+			{Path: "secret/b", Versions: []vault.SecretVersion{{Data: data("x", "((ref secret/c:y))", "z", "plain")}}},
+			{Path: "secret/a", Versions: []vault.SecretVersion{
+				{Data: data("old", "((ref secret/gone:k))")},
+				{Data: data("k", "((ref secret/c:y^2))")},
+			}},
+			{Path: "secret/empty"},
+		})
+		Expect(refs).To(Equal([]vault.Ref{
+			{From: "secret/a:k", To: "secret/c:y^2"},
+			{From: "secret/b:x", To: "secret/c:y"},
+		}))
+	})
+
+	It("knows which paths are beneath others", func() {
+		Expect(vault.PathIsBeneath("secret/a/b:k", "secret/a")).To(BeTrue())
+		Expect(vault.PathIsBeneath("secret/a", "secret/a/")).To(BeTrue())
+		Expect(vault.PathIsBeneath("secret/ab", "secret/a")).To(BeFalse())
+	})
+
+	Context("against Vault", func() {
+		var fake *fakeVault
+		var v *vault.Vault
+
+		BeforeEach(func() {
+			fake, v = newFakeVault(map[string]map[string]string{
+				"secret/shared": {"password": "p4ss"},
+				"secret/app":    {"db": "((ref secret/shared:password))"},
+			})
+		})
+
+		AfterEach(func() {
+			fake.Close()
+		})
+
+		It("reads each secret it needs once", func() {
+			lookup := v.RefLookup()
+			Expect(vault.ResolveRef("((ref secret/app:db))", lookup)).To(Equal("p4ss"))
+			Expect(lookup("secret/nope")).To(BeNil())
+
+			fake.lock.Lock()
+			delete(fake.secrets, "secret/shared")
+			fake.lock.Unlock()
+			Expect(vault.ResolveRef("((ref secret/app:db))", lookup)).To(Equal("p4ss"))
+		})
+	})
+})
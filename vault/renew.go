@@ -1,5 +1,24 @@
 package vault
 
+import "time"
+
 func (v *Vault) RenewLease() error {
 	return v.client.Client.TokenRenewSelf()
 }
+
+// RenewLeaseBefore renews the token if it is renewable, and would otherwise
+// expire within the given time, or has less than a third of the TTL it was
+// created with left.  It returns true if it renewed it.
+func (v *Vault) RenewLeaseBefore(within time.Duration) (bool, error) {
+	info, err := v.client.Client.TokenInfoSelf()
+	if err != nil {
+		return false, err
+	}
+	if !info.Renewable || info.TTL == 0 {
+		return false, nil
+	}
+	if left := time.Until(info.ExpireTime); left > within && left > info.CreationTTL/3 {
+		return false, nil
+	}
+	return true, v.RenewLease()
+}
@@ -0,0 +1,203 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy says how often, and how patiently, requests that fail for
+// reasons that may pass (rate limiting, a 5xx from a node in the middle of a
+// failover, a dropped connection) are sent again. Only requests that can
+// safely be sent twice are retried: reads, and writes protected by a
+// check-and-set version.
+type RetryPolicy struct {
+	//MaxAttempts is how many times a request is sent in all; 1 turns retries
+	// off, and 0 means DefaultRetryPolicy
+	MaxAttempts int
+	//MinBackoff is the longest wait before the first retry; the wait doubles
+	// with each retry after that, up to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used for any part of a RetryPolicy left unset.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	MinBackoff:  250 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.MinBackoff <= 0 {
+		p.MinBackoff = DefaultRetryPolicy.MinBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	if p.MaxBackoff < p.MinBackoff {
+		p.MaxBackoff = p.MinBackoff
+	}
+	return p
+}
+
+// Backoff returns how long to wait before the given retry (counting from 1).
+// The wait is picked at random from up to twice the last one, so that many
+// clients backing off from the same Vault don't all come back at once.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	p = p.withDefaults()
+	limit := p.MinBackoff
+	for i := 1; i < retry && limit < p.MaxBackoff; i++ {
+		limit *= 2
+	}
+	if limit > p.MaxBackoff {
+		limit = p.MaxBackoff
+	}
+	return limit/2 + time.Duration(rand.Int63n(int64(limit/2)+1))
+}
+
+// retryTransport sends requests through the next RoundTripper, retrying
+// those that fail transiently according to its policy.
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+	debug  bool
+	sleep  func(time.Duration)
+}
+
+func newRetryTransport(next http.RoundTripper, policy RetryPolicy, debug bool) *retryTransport {
+	return &retryTransport{
+		next:   next,
+		policy: policy.withDefaults(),
+		debug:  debug,
+		sleep:  time.Sleep,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	attempts := 1
+	if retryableRequest(req, body) {
+		attempts = t.policy.MaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := t.next.RoundTrip(req)
+
+		reason, retry := transientFailure(resp, err)
+		if !retry || attempt >= attempts {
+			return resp, err
+		}
+
+		wait := t.policy.Backoff(attempt)
+		if resp != nil {
+			if after := retryAfter(resp); after > wait && after <= t.policy.MaxBackoff {
+				wait = after
+			}
+			//The response is being thrown away, but the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if t.debug {
+			fmt.Fprintf(os.Stderr, "DEBUG> %s %s failed (%s); retrying in %s (attempt %d of %d)\n",
+				req.Method, req.URL.Path, reason, wait.Round(time.Millisecond), attempt+1, attempts)
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		default:
+		}
+		t.sleep(wait)
+	}
+}
+
+// retryableRequest returns true if sending the request again can't do
+// anything that sending it once didn't.
+func retryableRequest(req *http.Request, body []byte) bool {
+	//The health endpoints answer with 429s and 5xxs on purpose, to describe
+	// standby and sealed nodes
+	if strings.HasSuffix(req.URL.Path, "/sys/health") || strings.HasSuffix(req.URL.Path, "/sys/seal-status") {
+		return false
+	}
+
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "LIST":
+		return true
+	case "PUT", "POST", "PATCH":
+		//A KV v2 write with a check-and-set version can only ever land once
+		var write struct {
+			Options struct {
+				CAS *uint `json:"cas"`
+			} `json:"options"`
+		}
+		return json.Unmarshal(body, &write) == nil && write.Options.CAS != nil
+	}
+	return false
+}
+
+// transientFailure returns why a request failed, and whether that is likely to
+// go away if it is sent again.
+func transientFailure(resp *http.Response, err error) (string, bool) {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return err.Error(), false
+		}
+		var unknownAuthority x509.UnknownAuthorityError
+		var invalidCert x509.CertificateInvalidError
+		var hostname x509.HostnameError
+		if errors.As(err, &unknownAuthority) || errors.As(err, &invalidCert) || errors.As(err, &hostname) {
+			return err.Error(), false
+		}
+		return err.Error(), true
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return resp.Status, true
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+		return resp.Status, true
+	}
+	return "", false
+}
+
+// retryAfter returns how long the response asks to be left alone for, if it
+// does.
+func retryAfter(resp *http.Response) time.Duration {
+	s := resp.Header.Get("Retry-After")
+	if s == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(s); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package vault_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Retries", func() {
+	var server *httptest.Server
+	var hits int32
+	var failures int32
+	var status int
+	var received atomic.Value
+	var v *vault.Vault
+
+	policy := vault.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	BeforeEach(func() {
+		hits, failures, status = 0, 0, http.StatusServiceUnavailable
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received.Store(r.Header.Clone())
+			n := atomic.AddInt32(&hits, 1)
+			if n <= atomic.LoadInt32(&failures) {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+		}))
+
+		var err error
+		v, err = vault.NewVault(vault.VaultConfig{URL: server.URL, Token: "token", Retry: policy})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("retries reads that fail transiently", func() {
+		failures = 2
+		res, err := v.Curl("GET", "secret/x", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(200))
+		Expect(hits).To(Equal(int32(3)))
+	})
+
+	It("retries when rate limited", func() {
+		failures, status = 1, http.StatusTooManyRequests
+		res, err := v.Curl("GET", "secret/x", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(200))
+		Expect(hits).To(Equal(int32(2)))
+	})
+
+	It("gives up after the last attempt", func() {
+		failures = 5
+		res, err := v.Curl("GET", "secret/x", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(hits).To(Equal(int32(3)))
+	})
+
+	It("does not retry errors that won't go away", func() {
+		failures, status = 1, http.StatusForbidden
+		res, err := v.Curl("GET", "secret/x", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusForbidden))
+		Expect(hits).To(Equal(int32(1)))
+	})
+
+	It("does not retry plain writes", func() {
+		failures = 1
+		res, err := v.Curl("POST", "secret/data/x", []byte(`{"data":{"a":"b"}}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(hits).To(Equal(int32(1)))
+	})
+
+	It("retries check-and-set writes", func() {
+		failures = 1
+		res, err := v.Curl("POST", "secret/data/x", []byte(`{"data":{"a":"b"},"options":{"cas":3}}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(200))
+		Expect(hits).To(Equal(int32(2)))
+	})
+
+	It("retries check-and-set patches", func() {
+		failures = 1
+		res, err := v.Curl("PATCH", "secret/data/x", []byte(`{"data":{"a":"b"},"options":{"cas":3}}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(200))
+		Expect(hits).To(Equal(int32(2)))
+	})
+
+	It("sends headers of its own with requests, as well as the token and namespace", func() {
+		v, err := vault.NewVault(vault.VaultConfig{URL: server.URL, Token: "token", Namespace: "team", Retry: policy})
+		Expect(err).ToNot(HaveOccurred())
+		failures = 1
+		res, err := v.CurlWithHeaders("PATCH", "secret/data/x", []byte(`{"data":{"a":"b"},"options":{"cas":3}}`),
+			http.Header{"Content-Type": {"application/merge-patch+json"}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(200))
+		Expect(hits).To(Equal(int32(2)))
+
+		header := received.Load().(http.Header)
+		Expect(header.Get("Content-Type")).To(Equal("application/merge-patch+json"))
+		Expect(header.Get("X-Vault-Token")).To(Equal("token"))
+		Expect(header.Get("X-Vault-Namespace")).To(Equal("team/"))
+	})
+
+	It("does not retry health checks", func() {
+		failures = 1
+		res, err := v.Curl("GET", "sys/health", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(hits).To(Equal(int32(1)))
+	})
+
+	Describe("Backoff", func() {
+		p := vault.RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: 400 * time.Millisecond}
+
+		It("grows with each retry, up to the maximum", func() {
+			for i := 0; i < 20; i++ {
+				Expect(p.Backoff(1)).To(BeNumerically("<=", 100*time.Millisecond))
+				Expect(p.Backoff(2)).To(BeNumerically("<=", 200*time.Millisecond))
+				Expect(p.Backoff(2)).To(BeNumerically(">=", 100*time.Millisecond))
+				Expect(p.Backoff(10)).To(BeNumerically("<=", 400*time.Millisecond))
+			}
+		})
+	})
+})
@@ -0,0 +1,111 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+)
+
+// What a point-in-time revert will do to a secret
+const (
+	//RevertSkip leaves the secret alone
+	RevertSkip uint = iota
+	//RevertRestore writes the data of an older version as a new version
+	RevertRestore
+	//RevertDelete deletes the latest version of a secret that did not exist
+	RevertDelete
+	//RevertUnavailable means the version to go back to can no longer be read
+	RevertUnavailable
+)
+
+// RevertPlan describes what reverting a secret to a point in time involves.
+type RevertPlan struct {
+	Path   string
+	Action uint
+	//Current is the latest version of the secret
+	Current uint
+	//Target is the version that was current at the time, or 0 if the secret
+	// did not exist then
+	Target uint
+	//Data is written as a new version, for RevertRestore
+	Data   *Secret
+	Reason string
+}
+
+// RevertPlan works out what it takes to put the secret back the way it was at
+// the given time. The entry must hold every version of the secret, with data.
+// Deleted versions only have data if they were fetched with
+// GetDeletedVersions, which haveDeleted says.
+func (s SecretEntry) RevertPlan(asOf time.Time, haveDeleted bool) RevertPlan {
+	p := RevertPlan{Path: s.Path, Action: RevertSkip}
+	if len(s.Versions) == 0 {
+		p.Reason = "no versions"
+		return p
+	}
+
+	latest := s.Versions[len(s.Versions)-1]
+	p.Current = latest.Number
+	if latest.CreatedAt.IsZero() {
+		p.Reason = "no version history (KV v1)"
+		return p
+	}
+	currentlyAlive := latest.State == SecretStateAlive
+
+	var target *SecretVersion
+	for i := range s.Versions {
+		if !s.Versions[i].CreatedAt.After(asOf) {
+			target = &s.Versions[i]
+		}
+	}
+
+	if target == nil || (target.State == SecretStateDeleted && !target.DeletedAt.IsZero() && !target.DeletedAt.After(asOf)) {
+		if !currentlyAlive {
+			p.Reason = "already deleted"
+			return p
+		}
+		p.Action = RevertDelete
+		if target == nil {
+			p.Reason = "created since"
+		} else {
+			p.Reason = fmt.Sprintf("version %d was deleted at the time", target.Number)
+		}
+		return p
+	}
+
+	p.Target = target.Number
+	switch target.State {
+	case SecretStateDestroyed:
+		p.Action = RevertUnavailable
+		p.Reason = fmt.Sprintf("version %d has since been destroyed", target.Number)
+		return p
+	case SecretStateDeleted:
+		if !haveDeleted {
+			p.Action = RevertUnavailable
+			p.Reason = fmt.Sprintf("version %d has since been deleted", target.Number)
+			return p
+		}
+	}
+
+	if currentlyAlive && (target.Number == latest.Number || sameData(target.Data, latest.Data)) {
+		p.Reason = "unchanged"
+		return p
+	}
+
+	p.Action = RevertRestore
+	p.Data = target.Data
+	return p
+}
+
+func sameData(a, b *Secret) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if len(a.data) != len(b.data) {
+		return false
+	}
+	for k, v := range a.data {
+		if other, found := b.data[k]; !found || other != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package vault_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Point-in-time reverts", func() {
+	asOf := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return asOf.Add(time.Duration(hours) * time.Hour) }
+
+	data := func(value string) *vault.Secret {
+		s := vault.NewSecret()
+		s.Set("key", value, false)
+		return s
+	}
+	version := func(n uint, created time.Time, value string) vault.SecretVersion {
+		return vault.SecretVersion{Number: n, CreatedAt: created, Data: data(value), State: vault.SecretStateAlive}
+	}
+	entry := func(versions ...vault.SecretVersion) vault.SecretEntry {
+		return vault.SecretEntry{Path: "secret/app/x", Versions: versions}
+	}
+
+	It("restores the version that was current at the time", func() {
+		p := entry(version(1, at(-2), "a"), version(2, at(-1), "b"), version(3, at(1), "c")).RevertPlan(asOf, false)
+		Expect(p.Action).To(Equal(vault.RevertRestore))
+		Expect(p.Target).To(Equal(uint(2)))
+		Expect(p.Current).To(Equal(uint(3)))
+		Expect(p.Data.Get("key")).To(Equal("b"))
+	})
+
+	It("skips secrets that have not changed since", func() {
+		p := entry(version(1, at(-2), "a")).RevertPlan(asOf, false)
+		Expect(p.Action).To(Equal(vault.RevertSkip))
+	})
+
+	It("skips secrets whose newer versions hold the same data", func() {
+		p := entry(version(1, at(-2), "a"), version(2, at(1), "a")).RevertPlan(asOf, false)
+		Expect(p.Action).To(Equal(vault.RevertSkip))
+	})
+
+	It("deletes secrets created since", func() {
+		p := entry(version(1, at(1), "a")).RevertPlan(asOf, false)
+		Expect(p.Action).To(Equal(vault.RevertDelete))
+	})
+
+	It("deletes secrets that were deleted at the time", func() {
+		v1 := version(1, at(-2), "a")
+		v1.State = vault.SecretStateDeleted
+		v1.DeletedAt = at(-1)
+		p := entry(v1, version(2, at(1), "b")).RevertPlan(asOf, false)
+		Expect(p.Action).To(Equal(vault.RevertDelete))
+	})
+
+	It("leaves secrets that are already deleted alone", func() {
+		v1 := version(1, at(1), "a")
+		v1.State = vault.SecretStateDeleted
+		v1.DeletedAt = at(2)
+		p := entry(v1).RevertPlan(asOf, false)
+		Expect(p.Action).To(Equal(vault.RevertSkip))
+	})
+
+	It("brings back secrets deleted since, if their data was read", func() {
+		v1 := version(1, at(-2), "a")
+		v1.State = vault.SecretStateDeleted
+		v1.DeletedAt = at(1)
+		Expect(entry(v1).RevertPlan(asOf, false).Action).To(Equal(vault.RevertUnavailable))
+
+		p := entry(v1).RevertPlan(asOf, true)
+		Expect(p.Action).To(Equal(vault.RevertRestore))
+		Expect(p.Data.Get("key")).To(Equal("a"))
+	})
+
+	It("cannot bring back destroyed versions", func() {
+		v1 := version(1, at(-2), "")
+		v1.State = vault.SecretStateDestroyed
+		p := entry(v1, version(2, at(1), "b")).RevertPlan(asOf, true)
+		Expect(p.Action).To(Equal(vault.RevertUnavailable))
+	})
+
+	It("skips secrets without version history", func() {
+		p := entry(vault.SecretVersion{Number: 1, Data: data("a")}).RevertPlan(asOf, false)
+		Expect(p.Action).To(Equal(vault.RevertSkip))
+	})
+})
@@ -0,0 +1,38 @@
+package vault
+
+import "time"
+
+// SecretSummary sums up a secret and its versions, for long listings.
+type SecretSummary struct {
+	Versions int
+	//State is the state of the latest version
+	State uint
+	//CreatedAt is when the oldest version still known was written, and
+	// UpdatedAt when the latest was; both are zero for KV v1 secrets
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	//Keys is how many keys the latest version has, and Size how many bytes
+	// their values come to; both are 0 if its keys were not fetched
+	Keys int
+	Size int
+}
+
+// Summary sums up the secret and the versions of it that were fetched.
+func (e SecretEntry) Summary() SecretSummary {
+	s := SecretSummary{Versions: len(e.Versions)}
+	if len(e.Versions) == 0 {
+		return s
+	}
+
+	latest := e.Versions[len(e.Versions)-1]
+	s.State = latest.State
+	s.CreatedAt = e.Versions[0].CreatedAt
+	s.UpdatedAt = latest.CreatedAt
+	if latest.Data != nil {
+		for _, key := range latest.Data.Keys() {
+			s.Keys++
+			s.Size += len(latest.Data.Get(key))
+		}
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package vault_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Summing up secrets", func() {
+	at := func(day int) time.Time { return time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC) }
+
+	It("counts versions, keys and bytes", func() {
+		data := vault.NewSecret()
+		data.Set("user", "admin", false)
+		data.Set("password", "p4ss", false)
+
+		s := vault.SecretEntry{Path: "secret/db", Versions: []vault.SecretVersion{
+			{Number: 1, State: vault.SecretStateDestroyed, CreatedAt: at(1), Data: vault.NewSecret()},
+			{Number: 2, State: vault.SecretStateAlive, CreatedAt: at(3), Data: data},
+		}}.Summary()
+		Expect(s).To(Equal(vault.SecretSummary{
+			Versions:  2,
+			State:     vault.SecretStateAlive,
+			CreatedAt: at(1),
+			UpdatedAt: at(3),
+			Keys:      2,
+			Size:      9,
+		}))
+
+		Expect(vault.SecretEntry{Path: "secret/x"}.Summary()).To(Equal(vault.SecretSummary{}))
+	})
+
+	It("only fetches the keys of the latest version when asked to", func() {
+		fake, v := newFakeVaultV2(map[string][]fakeVersion{
+			"secret/app": {
+				{Data: map[string]string{"key": "old"}, CreatedAt: at(1)},
+				{Data: map[string]string{"key": "gone"}, CreatedAt: at(2), DeletedAt: at(3)},
+				{Data: map[string]string{"key": "value"}, CreatedAt: at(4)},
+			},
+		})
+		defer fake.Close()
+
+		secrets, err := v.ConstructSecrets("secret/app", vault.TreeOpts{
+			FetchKeys:           true,
+			FetchAllVersions:    true,
+			FetchLatestKeysOnly: true,
+			GetOnly:             true,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secrets).To(HaveLen(1))
+		Expect(fake.reads).To(Equal([]string{"secret/app^3"}))
+		Expect(secrets[0].Summary()).To(Equal(vault.SecretSummary{
+			Versions:  3,
+			State:     vault.SecretStateAlive,
+			CreatedAt: at(1),
+			UpdatedAt: at(4),
+			Keys:      1,
+			Size:      5,
+		}))
+	})
+})
@@ -1,11 +1,12 @@
 package vault
 
 import (
+	"context"
 	"fmt"
-	"runtime"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry-community/vaultkv"
 	"github.com/jhunt/go-ansi"
@@ -107,6 +108,10 @@ func (w *workQueue) Close() {
 type workOrder struct {
 	insertInto *secretTree
 	operation  uint16
+	//owner is the secret this order fetches part of, if any
+	owner *pendingSecret
+	//depth is how many folders beneath the path being walked the node is
+	depth int
 }
 
 type secretTree struct {
@@ -118,14 +123,24 @@ type secretTree struct {
 	Version      uint
 	Deleted      bool
 	Destroyed    bool
+	CreatedAt    time.Time
+	DeletedAt    time.Time
+	//SkipKeys is set on versions whose keys are not to be fetched
+	SkipKeys bool
 }
 
 func (v *Vault) ConstructSecrets(path string, opts TreeOpts) (s Secrets, err error) {
+	return v.ConstructSecretsContext(context.Background(), path, opts)
+}
+
+// ConstructSecretsContext is ConstructSecrets, stopping early with the
+// context's error if the context is cancelled.
+func (v *Vault) ConstructSecretsContext(ctx context.Context, path string, opts TreeOpts) (s Secrets, err error) {
 	constructTreeOpts := opts
 	//It's easier to analyze which secrets to purge once we have it structured as an array.
 	//So we let the tree just naively fetch secrets, and then we can clean up the results later
 	constructTreeOpts.SkipVersionInfo = opts.AllowDeletedSecrets && opts.SkipVersionInfo
-	t, err := v.constructTree(path, constructTreeOpts)
+	t, err := v.constructTree(ctx, path, constructTreeOpts, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -217,39 +232,52 @@ func (t secretTree) convertToSecrets() Secrets {
 	var ret Secrets
 	t.DepthFirstMap(func(t *secretTree) {
 		if t.Type == treeTypeSecret || t.Type == treeTypeDirAndSecret {
-			thisEntry := SecretEntry{
-				Path: Canonicalize(t.Name),
-			}
+			ret = append(ret, t.secretEntry())
+		}
+	})
 
-			for _, version := range t.Branches {
-				if version.Type != treeTypeVersion {
-					continue
-				}
+	return ret
+}
 
-				thisVersion := SecretVersion{
-					Data:   NewSecret(),
-					Number: version.Version,
-					State:  SecretStateAlive,
-				}
+// secretEntry converts a secret node of the tree, with its versions, into a
+// SecretEntry. The node's branches are left as they are, because the
+// directories under a secret may still be being walked.
+func (t *secretTree) secretEntry() SecretEntry {
+	entry := SecretEntry{
+		Path: Canonicalize(t.Name),
+	}
 
-				if version.Destroyed {
-					thisVersion.State = SecretStateDestroyed
-				} else if version.Deleted {
-					thisVersion.State = SecretStateDeleted
-				}
+	var versions []*secretTree
+	for i := range t.Branches {
+		if t.Branches[i].Type == treeTypeVersion {
+			versions = append(versions, &t.Branches[i])
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
 
-				for _, key := range version.Branches {
-					thisVersion.Data.Set(key.Basename(), key.Value, false)
-				}
+	for _, version := range versions {
+		thisVersion := SecretVersion{
+			Data:      NewSecret(),
+			Number:    version.Version,
+			State:     SecretStateAlive,
+			CreatedAt: version.CreatedAt,
+			DeletedAt: version.DeletedAt,
+		}
 
-				thisEntry.Versions = append(thisEntry.Versions, thisVersion)
-			}
+		if version.Destroyed {
+			thisVersion.State = SecretStateDestroyed
+		} else if version.Deleted {
+			thisVersion.State = SecretStateDeleted
+		}
 
-			ret = append(ret, thisEntry)
+		for _, key := range version.Branches {
+			thisVersion.Data.Set(key.Basename(), key.Value, false)
 		}
-	})
 
-	return ret
+		entry.Versions = append(entry.Versions, thisVersion)
+	}
+
+	return entry
 }
 
 const (
@@ -290,6 +318,10 @@ type SecretVersion struct {
 	Data   *Secret
 	Number uint
 	State  uint
+	//CreatedAt and DeletedAt are zero for KV v1 secrets, and DeletedAt is
+	// zero for versions that have not been deleted
+	CreatedAt time.Time
+	DeletedAt time.Time
 }
 
 type TreeOpts struct {
@@ -304,17 +336,36 @@ type TreeOpts struct {
 	SkipVersionInfo bool
 	//Whether to get all versions of keys in the tree
 	FetchAllVersions bool
+	//With FetchAllVersions, FetchKeys only fetches the keys of the latest
+	// version of each secret; the others just have their version info
+	FetchLatestKeysOnly bool
 	//GetDeletedVersions tells the workers to temporarily undelete deleted
 	// keys to fetch their value, then delete them again
 	GetDeletedVersions bool
 	//Only perform gets. If the target is not a secret, then an error is returned
 	GetOnly bool
+	//MaxDepth, if above 0, is how many folders deep beneath the path to walk;
+	// the folders found there are not listed.  1 walks just what is in the
+	// path itself
+	MaxDepth int
+	//Progress, if set, is called whenever a secret is discovered or finishes
+	// being fetched. Calls are never made at the same time as each other.
+	Progress func(TreeProgress)
+}
+
+// TreeProgress says how far a walk of a tree of secrets has got.
+type TreeProgress struct {
+	//Discovered is how many secrets have been found so far
+	Discovered int
+	//Fetched is how many of those have had everything asked for fetched
+	Fetched int
 }
 
-func (v *Vault) constructTree(path string, opts TreeOpts) (*secretTree, error) {
-	numWorkers := runtime.NumCPU()
-	if numWorkers < 1 {
-		numWorkers = 1
+func (v *Vault) constructTree(ctx context.Context, path string, opts TreeOpts, emit func(*secretTree) error) (*secretTree, error) {
+	numWorkers := v.concurrency.workers()
+	if v.concurrency.Adaptive {
+		//The transport holds back the requests of workers beyond its limit
+		numWorkers = MaxAdaptiveWorkers
 	}
 
 	queue := newWorkQueue(numWorkers)
@@ -331,10 +382,12 @@ func (v *Vault) constructTree(path string, opts TreeOpts) (*secretTree, error) {
 	if opts.GetOnly && !(ret.Type == treeTypeSecret || ret.Type == treeTypeDirAndSecret) {
 		return nil, fmt.Errorf("`%s' is not a secret", path)
 	}
-	operation := ret.getWorkType(opts)
+	walk := &treeWalk{emit: emit, progress: opts.Progress}
+	operation := ret.getWorkType(opts, 0)
 	queue.Push(&workOrder{
 		insertInto: ret,
 		operation:  operation,
+		owner:      walk.ownerFor(ret, nil),
 	})
 
 	g := new(errgroup.Group)
@@ -343,6 +396,8 @@ func (v *Vault) constructTree(path string, opts TreeOpts) (*secretTree, error) {
 			vault:  v,
 			orders: queue,
 			opts:   opts,
+			ctx:    ctx,
+			walk:   walk,
 		}
 		g.Go(worker.work)
 	}
@@ -411,7 +466,7 @@ func (t *secretTree) populateNodeType(v *Vault) error {
 	return nil
 }
 
-func (t *secretTree) getWorkType(opts TreeOpts) uint16 {
+func (t *secretTree) getWorkType(opts TreeOpts, depth int) uint16 {
 	ret := opTypeNone
 
 	switch t.Type {
@@ -430,12 +485,12 @@ func (t *secretTree) getWorkType(opts TreeOpts) uint16 {
 			ret |= opTypeVersions
 		}
 	case treeTypeVersion:
-		if opts.FetchKeys && (opts.GetDeletedVersions || !(t.Deleted || t.Destroyed)) {
+		if opts.FetchKeys && !t.SkipKeys && (opts.GetDeletedVersions || !(t.Deleted || t.Destroyed)) {
 			ret = opTypeGet
 		}
 	}
 
-	if opts.GetOnly {
+	if opts.GetOnly || (opts.MaxDepth > 0 && depth >= opts.MaxDepth) {
 		ret &= (opTypeList ^ 0xFFFF)
 	}
 
@@ -468,46 +523,87 @@ type TreeCopyOpts struct {
 	Clear bool
 	//Pad will insert dummy versions that have been truncated by Vault
 	Pad bool
+	//Append keeps the versions already at the destination, and only writes
+	// the versions numbered after them (padding the gap, with Pad)
+	Append bool
+	//Checkpoint records how far the copy got, and lets an interrupted copy
+	// carry on without writing any version twice
+	Checkpoint *Checkpoint
 }
 
 func (s SecretEntry) Copy(v *Vault, dst string, opts TreeCopyOpts) error {
-	if opts.Clear {
-		err := v.Client().DestroyAll(dst)
-		if err != nil {
-			return fmt.Errorf("Could not wipe existing secret at path `%s': %w", dst, err)
+	base, resuming := opts.Checkpoint.started(dst)
+	if !resuming {
+		if opts.Clear {
+			err := v.Client().DestroyAll(dst)
+			if err != nil {
+				return fmt.Errorf("Could not wipe existing secret at path `%s': %w", dst, err)
+			}
 		}
-	}
 
-	var toDelete, toDestroy []uint
-
-	if opts.Pad && len(s.Versions) > 0 {
-		for i := uint(1); i < s.Versions[0].Number; i++ {
-			setMeta, err := v.Client().Set(dst, map[string]string{"TO_DESTROY": "TO_DESTROY"}, nil)
+		var err error
+		if opts.Checkpoint != nil || opts.Append {
+			base, err = v.currentVersion(dst)
 			if err != nil {
-				return fmt.Errorf("Could not write secret to path `%s': %w", dst, err)
+				return err
 			}
-
-			toDestroy = append(toDestroy, setMeta.Version)
+		}
+		err = opts.Checkpoint.start(dst, base)
+		if err != nil {
+			return err
 		}
 	}
 
-	for _, version := range s.Versions {
-		var toWrite map[string]string
-		if version.State == SecretStateDestroyed {
-			toWrite = map[string]string{"TO_DESTROY": "TO_DESTROY"}
-		} else {
-			toWrite = version.Data.data
+	//Writes are planned up front so that a resumed copy can tell which of
+	// them landed before it was interrupted
+	var toWrite []SecretVersion
+	versions, padFrom := s.Versions, uint(1)
+	if opts.Append {
+		for len(versions) > 0 && versions[0].Number <= base {
+			versions = versions[1:]
 		}
+		padFrom = base + 1
+	}
+	if opts.Pad && len(versions) > 0 {
+		for i := padFrom; i < versions[0].Number; i++ {
+			toWrite = append(toWrite, SecretVersion{State: SecretStateDestroyed})
+		}
+	}
+	toWrite = append(toWrite, versions...)
 
-		setMeta, err := v.Client().Set(dst, toWrite, nil)
+	var written uint
+	if resuming {
+		current, err := v.currentVersion(dst)
 		if err != nil {
-			return fmt.Errorf("Could not write secret to path `%s': %w", dst, err)
+			return err
+		}
+		if current > base {
+			written = current - base
+		}
+	}
+
+	var toDelete, toDestroy []uint
+	for i, version := range toWrite {
+		number := base + uint(i) + 1
+		if uint(i) >= written {
+			var data map[string]string
+			if version.State == SecretStateDestroyed {
+				data = map[string]string{"TO_DESTROY": "TO_DESTROY"}
+			} else {
+				data = version.Data.data
+			}
+
+			setMeta, err := v.Client().Set(dst, data, nil)
+			if err != nil {
+				return fmt.Errorf("Could not write secret to path `%s': %w", dst, err)
+			}
+			number = setMeta.Version
 		}
 
 		if version.State == SecretStateDestroyed {
-			toDestroy = append(toDestroy, setMeta.Version)
+			toDestroy = append(toDestroy, number)
 		} else if version.State == SecretStateDeleted {
-			toDelete = append(toDelete, setMeta.Version)
+			toDelete = append(toDelete, number)
 		}
 	}
 
@@ -527,6 +623,30 @@ func (s SecretEntry) Copy(v *Vault, dst string, opts TreeCopyOpts) error {
 	return nil
 }
 
+// currentVersion returns the number of the latest version of the secret at
+// the given path, or zero if there is none (or the mount is not versioned).
+func (v *Vault) currentVersion(path string) (uint, error) {
+	mountV, err := v.MountVersion(path)
+	if err != nil {
+		return 0, err
+	}
+	if mountV != 2 {
+		return 0, nil
+	}
+
+	versions, err := v.Versions(path)
+	if err != nil {
+		if IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, nil
+	}
+	return versions[len(versions)-1].Version, nil
+}
+
 func (t secretTree) Basename() string {
 	var ret string
 	switch t.Type {
@@ -676,6 +796,8 @@ type treeWorker struct {
 	vault  *Vault
 	orders *workQueue
 	opts   TreeOpts
+	ctx    context.Context
+	walk   *treeWalk
 }
 
 func (w *treeWorker) work() error {
@@ -689,6 +811,10 @@ func (w *treeWorker) work() error {
 
 	order, done := w.orders.Pop()
 	for !done {
+		if err = w.ctx.Err(); err != nil {
+			return handleError()
+		}
+
 		var answer []secretTree
 		var toAppend []secretTree
 		for _, op := range []struct {
@@ -727,12 +853,22 @@ func (w *treeWorker) work() error {
 
 		order.insertInto.Branches = append(order.insertInto.Branches, answer...)
 		for i, node := range order.insertInto.Branches {
+			depth := order.depth
+			if node.Type != treeTypeVersion && node.Type != treeTypeKey {
+				depth++
+			}
 			w.orders.Push(&workOrder{
 				insertInto: &(order.insertInto.Branches[i]),
-				operation:  node.getWorkType(w.opts),
+				operation:  node.getWorkType(w.opts, depth),
+				owner:      w.walk.ownerFor(&order.insertInto.Branches[i], order.owner),
+				depth:      depth,
 			})
 		}
 
+		if err = w.walk.finished(order.owner); err != nil {
+			return handleError()
+		}
+
 		order, done = w.orders.Pop()
 	}
 
@@ -880,7 +1016,7 @@ func (w *treeWorker) workVersions(t secretTree) ([]secretTree, error) {
 		}, nil
 	}
 
-	versions, err := w.vault.Versions(path)
+	versions, err := w.vault.VersionHistory(path)
 	//For v2 backends, this is the first non-list Vault access.
 	// If we're unable to get a path that we could list because of permissions,
 	// don't explode.
@@ -893,18 +1029,124 @@ func (w *treeWorker) workVersions(t secretTree) ([]secretTree, error) {
 
 	ret := []secretTree{}
 	for i := range versions {
-		ret = append(ret, secretTree{
+		node := secretTree{
 			Name:      t.Name,
 			Type:      treeTypeVersion,
 			Version:   versions[i].Version,
-			Deleted:   versions[i].Deleted,
+			Deleted:   versions[i].DeletedAt != nil,
 			Destroyed: versions[i].Destroyed,
-		})
+			CreatedAt: versions[i].CreatedAt,
+		}
+		if versions[i].DeletedAt != nil {
+			node.DeletedAt = *versions[i].DeletedAt
+		}
+		ret = append(ret, node)
 	}
 
 	if !w.opts.FetchAllVersions {
 		ret = ret[len(ret)-1:]
+	} else if w.opts.FetchLatestKeysOnly {
+		for i := 0; i < len(ret)-1; i++ {
+			ret[i].SkipKeys = true
+		}
 	}
 
 	return ret, nil
 }
+
+// pendingSecret counts the work orders still to be done to fetch a secret.
+type pendingSecret struct {
+	node   *secretTree
+	orders int
+}
+
+// treeWalk keeps track of which secrets have been completely fetched, so that
+// they can be reported and handed on without waiting for the rest of the tree.
+type treeWalk struct {
+	lock       sync.Mutex
+	emit       func(*secretTree) error
+	progress   func(TreeProgress)
+	discovered int
+	fetched    int
+}
+
+// ownerFor returns the secret that the work order for the given node belongs
+// to. Versions and keys belong to the secret they're under (parent); secrets
+// start a new one; directories belong to none.
+func (w *treeWalk) ownerFor(node *secretTree, parent *pendingSecret) *pendingSecret {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	switch node.Type {
+	case treeTypeSecret, treeTypeDirAndSecret:
+		w.discovered++
+		w.report()
+		return &pendingSecret{node: node, orders: 1}
+	case treeTypeVersion, treeTypeKey:
+		if parent != nil {
+			parent.orders++
+		}
+		return parent
+	}
+	return nil
+}
+
+// finished records that a work order for the given secret is done, handing
+// the secret on if it was the last one.
+func (w *treeWalk) finished(owner *pendingSecret) error {
+	if owner == nil {
+		return nil
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	owner.orders--
+	if owner.orders > 0 {
+		return nil
+	}
+	w.fetched++
+	w.report()
+	if w.emit == nil {
+		return nil
+	}
+	return w.emit(owner.node)
+}
+
+func (w *treeWalk) report() {
+	if w.progress != nil {
+		w.progress(TreeProgress{Discovered: w.discovered, Fetched: w.fetched})
+	}
+}
+
+// WalkSecrets walks the secrets under the given path like ConstructSecrets,
+// but calls fn with each secret as soon as it has been fetched, rather than
+// once the whole tree has. Secrets come in no particular order, and fn is
+// never called for two of them at once. The walk stops when the context is
+// cancelled, or fn returns an error, and WalkSecrets returns that error.
+func (v *Vault) WalkSecrets(ctx context.Context, path string, opts TreeOpts, fn func(SecretEntry) error) error {
+	constructTreeOpts := opts
+	constructTreeOpts.SkipVersionInfo = opts.AllowDeletedSecrets && opts.SkipVersionInfo
+
+	_, err := v.constructTree(ctx, path, constructTreeOpts, func(node *secretTree) error {
+		entry := node.secretEntry()
+		//The secret has been handed on, so its values needn't be kept around
+		// for the rest of the walk
+		for i := range node.Branches {
+			if node.Branches[i].Type == treeTypeVersion {
+				node.Branches[i].Branches = nil
+			}
+		}
+
+		if !opts.AllowDeletedSecrets {
+			if len(entry.Versions) == 0 || entry.Versions[len(entry.Versions)-1].State != SecretStateAlive {
+				return nil
+			}
+		}
+		if opts.SkipVersionInfo {
+			entry.Versions = nil
+		}
+		return fn(entry)
+	})
+	return err
+}
@@ -1,6 +1,8 @@
 package vault_test
 
 import (
+	"fmt"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/SomeBlackMagic/vault-cli-manager/vault"
@@ -176,4 +178,64 @@ var _ = Describe("Tree", func() {
 			Expect(e.Basename()).To(Equal(""))
 		})
 	})
+
+	Describe("SecretEntry.Copy", func() {
+		var fake *fakeVault
+		var v *vault.Vault
+
+		BeforeEach(func() {
+			fake, v = newFakeVaultV2(map[string][]fakeVersion{})
+		})
+
+		AfterEach(func() {
+			fake.Close()
+		})
+
+		entry := func(versions ...vault.SecretVersion) vault.SecretEntry {
+			for i := range versions {
+				if versions[i].State != vault.SecretStateDestroyed {
+					data := vault.NewSecret()
+					data.Set("k", fmt.Sprintf("v%d", versions[i].Number), false)
+					versions[i].Data = data
+				}
+			}
+			return vault.SecretEntry{Path: "secret/a", Versions: versions}
+		}
+
+		history := func() (values []string) {
+			fake.Update(func() {
+				for _, version := range fake.versions["secret/a"] {
+					switch {
+					case version.Destroyed:
+						values = append(values, "destroyed")
+					case !version.DeletedAt.IsZero():
+						values = append(values, "deleted")
+					default:
+						values = append(values, version.Data["k"])
+					}
+				}
+			})
+			return
+		}
+
+		It("keeps what is there when appending, and writes only the newer versions", func() {
+			full := entry(vault.SecretVersion{Number: 1}, vault.SecretVersion{Number: 2})
+			Expect(full.Copy(v, "secret/a", vault.TreeCopyOpts{Clear: true, Pad: true})).To(Succeed())
+			Expect(history()).To(Equal([]string{"v1", "v2"}))
+
+			incremental := entry(vault.SecretVersion{Number: 2}, vault.SecretVersion{Number: 3}, vault.SecretVersion{Number: 4, State: vault.SecretStateDeleted})
+			Expect(incremental.Copy(v, "secret/a", vault.TreeCopyOpts{Append: true, Pad: true})).To(Succeed())
+			Expect(history()).To(Equal([]string{"v1", "v2", "v3", "deleted"}))
+
+			gap := entry(vault.SecretVersion{Number: 6})
+			Expect(gap.Copy(v, "secret/a", vault.TreeCopyOpts{Append: true, Pad: true})).To(Succeed())
+			Expect(history()).To(Equal([]string{"v1", "v2", "v3", "deleted", "destroyed", "v6"}))
+		})
+
+		It("replaces what is there when clearing", func() {
+			Expect(entry(vault.SecretVersion{Number: 1}, vault.SecretVersion{Number: 2}).Copy(v, "secret/a", vault.TreeCopyOpts{Clear: true})).To(Succeed())
+			Expect(entry(vault.SecretVersion{Number: 3}).Copy(v, "secret/a", vault.TreeCopyOpts{Clear: true})).To(Succeed())
+			Expect(history()).To(Equal([]string{"v3"}))
+		})
+	})
 })
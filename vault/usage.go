@@ -0,0 +1,109 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FolderUsage sums up the secrets in a folder, and in every folder beneath it.
+type FolderUsage struct {
+	Path    string
+	Secrets int
+	//Keys is how many keys the latest versions of the secrets have, and Bytes
+	// how many bytes their values come to; both are 0 if keys were not fetched
+	Keys  int
+	Bytes int
+	//Versions counts every version still known, Deleted and Destroyed just
+	// those that were deleted or destroyed
+	Versions  int
+	Deleted   int
+	Destroyed int
+}
+
+// UsageFields are the fields that SortUsage can sort by.
+var UsageFields = []string{"path", "secrets", "keys", "bytes", "versions", "deleted", "destroyed"}
+
+// Usage sums up the secrets beneath root, for root and each folder beneath it
+// down to depth folders below root (or all of them, if depth is negative).
+// A folder deeper than that counts towards the one above it that is not.
+// The folders come back sorted by path.
+func (s Secrets) Usage(root string, depth int) []FolderUsage {
+	root = strings.TrimSuffix(Canonicalize(root), "/")
+	folders := map[string]*FolderUsage{root: {Path: root}}
+
+	for _, entry := range s {
+		if !PathIsBeneath(entry.Path, root) {
+			continue
+		}
+		summary := entry.Summary()
+		add := func(u *FolderUsage) {
+			u.Secrets++
+			u.Keys += summary.Keys
+			u.Bytes += summary.Size
+			u.Versions += summary.Versions
+			for _, v := range entry.Versions {
+				switch v.State {
+				case SecretStateDeleted:
+					u.Deleted++
+				case SecretStateDestroyed:
+					u.Destroyed++
+				}
+			}
+		}
+
+		add(folders[root])
+		path := root
+		segments := strings.Split(strings.TrimPrefix(strings.TrimPrefix(entry.Path, root), "/"), "/")
+		for i, segment := range segments[:len(segments)-1] {
+			if depth >= 0 && i >= depth {
+				break
+			}
+			path += "/" + segment
+			if folders[path] == nil {
+				folders[path] = &FolderUsage{Path: path}
+			}
+			add(folders[path])
+		}
+	}
+
+	usage := make([]FolderUsage, 0, len(folders))
+	for _, u := range folders {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Path < usage[j].Path })
+	return usage
+}
+
+// SortUsage sorts folders by one of the UsageFields; by path in order, and by
+// anything else largest first (then by path).
+func SortUsage(usage []FolderUsage, field string) error {
+	var value func(u FolderUsage) int
+	switch field {
+	case "", "path":
+		sort.SliceStable(usage, func(i, j int) bool { return usage[i].Path < usage[j].Path })
+		return nil
+	case "secrets":
+		value = func(u FolderUsage) int { return u.Secrets }
+	case "keys":
+		value = func(u FolderUsage) int { return u.Keys }
+	case "bytes":
+		value = func(u FolderUsage) int { return u.Bytes }
+	case "versions":
+		value = func(u FolderUsage) int { return u.Versions }
+	case "deleted":
+		value = func(u FolderUsage) int { return u.Deleted }
+	case "destroyed":
+		value = func(u FolderUsage) int { return u.Destroyed }
+	default:
+		return fmt.Errorf("Cannot sort by `%s' (expected one of %s)", field, strings.Join(UsageFields, ", "))
+	}
+
+	sort.SliceStable(usage, func(i, j int) bool {
+		if a, b := value(usage[i]), value(usage[j]); a != b {
+			return a > b
+		}
+		return usage[i].Path < usage[j].Path
+	})
+	return nil
+}
@@ -0,0 +1,71 @@
+package vault_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Folder usage", func() {
+	secret := func(path string, kv map[string]string, states ...uint) vault.SecretEntry {
+		e := vault.SecretEntry{Path: path}
+		for i, state := range states {
+			v := vault.SecretVersion{Number: uint(i + 1), State: state}
+			if i == len(states)-1 && kv != nil {
+				v.Data = vault.NewSecret()
+				for k, val := range kv {
+					v.Data.Set(k, val, false)
+				}
+			}
+			e.Versions = append(e.Versions, v)
+		}
+		return e
+	}
+
+	secrets := vault.Secrets{
+		secret("secret/app", map[string]string{"a": "1"}, vault.SecretStateAlive),
+		secret("secret/app/db", map[string]string{"user": "admin", "password": "p4ss"},
+			vault.SecretStateDestroyed, vault.SecretStateDeleted, vault.SecretStateAlive),
+		secret("secret/app/web/tls", map[string]string{"key": "xyz"}, vault.SecretStateAlive),
+		secret("secret/other", nil, vault.SecretStateDeleted),
+	}
+
+	It("sums up each folder and everything beneath it", func() {
+		Expect(secrets.Usage("secret/", -1)).To(Equal([]vault.FolderUsage{
+			{Path: "secret", Secrets: 4, Keys: 4, Bytes: 13, Versions: 6, Deleted: 2, Destroyed: 1},
+			{Path: "secret/app", Secrets: 2, Keys: 3, Bytes: 12, Versions: 4, Deleted: 1, Destroyed: 1},
+			{Path: "secret/app/web", Secrets: 1, Keys: 1, Bytes: 3, Versions: 1},
+		}))
+	})
+
+	It("stops at the given depth", func() {
+		Expect(secrets.Usage("secret", 0)).To(Equal([]vault.FolderUsage{
+			{Path: "secret", Secrets: 4, Keys: 4, Bytes: 13, Versions: 6, Deleted: 2, Destroyed: 1},
+		}))
+		Expect(secrets.Usage("secret/app", 1)).To(Equal([]vault.FolderUsage{
+			{Path: "secret/app", Secrets: 3, Keys: 4, Bytes: 13, Versions: 5, Deleted: 1, Destroyed: 1},
+			{Path: "secret/app/web", Secrets: 1, Keys: 1, Bytes: 3, Versions: 1},
+		}))
+	})
+
+	It("sorts by any field", func() {
+		usage := []vault.FolderUsage{
+			{Path: "secret/a", Secrets: 1, Bytes: 10},
+			{Path: "secret/b", Secrets: 3, Bytes: 5},
+			{Path: "secret/c", Secrets: 3, Bytes: 20},
+		}
+		Expect(vault.SortUsage(usage, "secrets")).To(Succeed())
+		Expect(usage[0].Path).To(Equal("secret/b"))
+		Expect(usage[1].Path).To(Equal("secret/c"))
+		Expect(usage[2].Path).To(Equal("secret/a"))
+
+		Expect(vault.SortUsage(usage, "bytes")).To(Succeed())
+		Expect(usage[0].Path).To(Equal("secret/c"))
+
+		Expect(vault.SortUsage(usage, "path")).To(Succeed())
+		Expect(usage[0].Path).To(Equal("secret/a"))
+
+		Expect(vault.SortUsage(usage, "size")).To(MatchError(ContainSubstring("Cannot sort by `size'")))
+	})
+})
@@ -17,8 +17,12 @@ import (
 )
 
 type Vault struct {
-	client *vaultkv.KV
-	debug  bool
+	client      *vaultkv.KV
+	debug       bool
+	concurrency Concurrency
+	//token is the one the client was made with, for the clients that send
+	// requests with headers of their own
+	token string
 }
 
 type VaultConfig struct {
@@ -27,6 +31,10 @@ type VaultConfig struct {
 	Namespace  string
 	CACerts    *x509.CertPool
 	SkipVerify bool
+	//Retry says how requests that fail transiently are retried
+	Retry RetryPolicy
+	//Concurrency says how many requests are made at once when walking a tree
+	Concurrency Concurrency
 }
 
 // NewVault creates a new Vault object.  If an empty token is specified,
@@ -63,20 +71,28 @@ func NewVault(conf VaultConfig) (*Vault, error) {
 		return nil, fmt.Errorf("Error setting up proxy: %w", err)
 	}
 
+	metered := &meteredTransport{
+		next: &http.Transport{
+			Proxy: proxyRouter.Proxy,
+			TLSClientConfig: &tls.Config{
+				RootCAs:            conf.CACerts,
+				InsecureSkipVerify: conf.SkipVerify,
+			},
+			MaxIdleConnsPerHost: 100,
+		},
+		metrics: defaultMetrics,
+	}
+	if conf.Concurrency.Adaptive {
+		metered.limiter = NewAdaptiveLimiter(conf.Concurrency.workers(), MaxAdaptiveWorkers)
+	}
+
 	return &Vault{
 		client: (&vaultkv.Client{
 			VaultURL:  vaultURL,
 			AuthToken: conf.Token,
 			Namespace: conf.Namespace,
 			Client: &http.Client{
-				Transport: &http.Transport{
-					Proxy: proxyRouter.Proxy,
-					TLSClientConfig: &tls.Config{
-						RootCAs:            conf.CACerts,
-						InsecureSkipVerify: conf.SkipVerify,
-					},
-					MaxIdleConnsPerHost: 100,
-				},
+				Transport: newRetryTransport(metered, conf.Retry, shouldDebug()),
 			},
 			Trace: func() (ret io.Writer) {
 				if shouldDebug() {
@@ -85,7 +101,9 @@ func NewVault(conf VaultConfig) (*Vault, error) {
 				return ret
 			}(),
 		}).NewKV(),
-		debug: shouldDebug(),
+		debug:       shouldDebug(),
+		concurrency: conf.Concurrency,
+		token:       conf.Token,
 	}, nil
 }
 
@@ -114,6 +132,29 @@ func shouldDebug() bool {
 }
 
 func (v *Vault) Curl(method string, path string, body []byte) (*http.Response, error) {
+	return v.curl(v.client.Client, method, path, body)
+}
+
+// CurlWithHeaders is Curl, sending the given headers as well as those every
+// request gets. vaultkv has no way to set headers, so the request is made by a
+// client of its own, which adds them on the way out.
+func (v *Vault) CurlWithHeaders(method string, path string, body []byte, headers http.Header) (*http.Response, error) {
+	if len(headers) == 0 {
+		return v.Curl(method, path, body)
+	}
+	c := v.client.Client
+	return v.curl(&vaultkv.Client{
+		VaultURL:  c.VaultURL,
+		AuthToken: v.token,
+		Namespace: c.Namespace,
+		Client: &http.Client{
+			Transport: &headerTransport{next: c.Client.Transport, header: headers},
+		},
+		Trace: c.Trace,
+	}, method, path, body)
+}
+
+func (v *Vault) curl(c *vaultkv.Client, method string, path string, body []byte) (*http.Response, error) {
 	path = Canonicalize(path)
 	u, err := url.Parse(path)
 	if err != nil {
@@ -125,7 +166,22 @@ func (v *Vault) Curl(method string, path string, body []byte) (*http.Response, e
 		return nil, fmt.Errorf("could not parse query: %w", err)
 	}
 
-	return v.client.Client.Curl(method, u.Path, query, bytes.NewBuffer(body))
+	return c.Curl(method, u.Path, query, bytes.NewBuffer(body))
+}
+
+// headerTransport adds headers to the requests it sends through the next
+// RoundTripper.
+type headerTransport struct {
+	next   http.RoundTripper
+	header http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.header {
+		req.Header[name] = values
+	}
+	return t.next.RoundTrip(req)
 }
 
 // errIfFolder returns an error with your provided message if the given path is a folder.
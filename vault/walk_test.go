@@ -0,0 +1,98 @@
+package vault_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Walking trees", func() {
+	var fake *fakeVault
+	var v *vault.Vault
+
+	BeforeEach(func() {
+		fake, v = newFakeVault(map[string]map[string]string{
+			"secret/a":     {"k": "1"},
+			"secret/b/c":   {"k": "2"},
+			"secret/b/d/e": {"k": "3", "l": "4"},
+			"secret/f":     {"k": "5"},
+		})
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	It("hands on every secret, with its keys", func() {
+		found := map[string]map[string]string{}
+		err := v.WalkSecrets(context.Background(), "secret", vault.TreeOpts{FetchKeys: true}, func(e vault.SecretEntry) error {
+			Expect(e.Versions).To(HaveLen(1))
+			data := map[string]string{}
+			for _, k := range e.Versions[0].Data.Keys() {
+				data[k] = e.Versions[0].Data.Get(k)
+			}
+			found[e.Path] = data
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(Equal(map[string]map[string]string{
+			"secret/a":     {"k": "1"},
+			"secret/b/c":   {"k": "2"},
+			"secret/b/d/e": {"k": "3", "l": "4"},
+			"secret/f":     {"k": "5"},
+		}))
+	})
+
+	It("finds the same secrets as ConstructSecrets", func() {
+		var walked vault.Secrets
+		err := v.WalkSecrets(context.Background(), "secret/b", vault.TreeOpts{}, func(e vault.SecretEntry) error {
+			walked = append(walked, e)
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		walked.Sort()
+
+		constructed, err := v.ConstructSecrets("secret/b", vault.TreeOpts{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(walked.Paths()).To(Equal(constructed.Paths()))
+	})
+
+	It("goes no deeper than it is asked to", func() {
+		secrets, err := v.ConstructSecrets("secret", vault.TreeOpts{FetchKeys: true, MaxDepth: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secrets.Paths()).To(Equal([]string{"secret/a:k", "secret/f:k"}))
+
+		secrets, err = v.ConstructSecrets("secret", vault.TreeOpts{FetchKeys: true, MaxDepth: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secrets.Paths()).To(Equal([]string{"secret/a:k", "secret/b/c:k", "secret/f:k"}))
+	})
+
+	It("reports progress", func() {
+		var last vault.TreeProgress
+		_, err := v.ConstructSecrets("secret", vault.TreeOpts{
+			FetchKeys: true,
+			Progress:  func(p vault.TreeProgress) { last = p },
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(last).To(Equal(vault.TreeProgress{Discovered: 4, Fetched: 4}))
+	})
+
+	It("stops when the callback fails", func() {
+		oops := errors.New("oops")
+		err := v.WalkSecrets(context.Background(), "secret", vault.TreeOpts{}, func(vault.SecretEntry) error {
+			return oops
+		})
+		Expect(err).To(Equal(oops))
+	})
+
+	It("stops when cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := v.ConstructSecretsContext(ctx, "secret", vault.TreeOpts{})
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+	})
+})
@@ -0,0 +1,141 @@
+package vault
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+)
+
+// WatchOpts says how often Watch polls, and how it copes when it can't.
+type WatchOpts struct {
+	//Interval is the time between polls, made up to Jitter (a fraction of
+	// Interval) longer or shorter at random each time, so that many watchers
+	// started at once don't all poll at once
+	Interval time.Duration
+	Jitter   float64
+	//Backoff says how long to wait after polls that fail, one after another.
+	// Its MinBackoff defaults to Interval, and its MaxBackoff to 5 minutes
+	Backoff RetryPolicy
+	//OnError, if set, is told of each poll that fails (or whose changes
+	// could not be handled), and how long it will be until the next one
+	OnError func(err error, wait time.Duration)
+}
+
+// DefaultWatchOpts is used for any part of a WatchOpts left unset.
+var DefaultWatchOpts = WatchOpts{
+	Interval: 30 * time.Second,
+	Jitter:   0.1,
+}
+
+func (o WatchOpts) withDefaults() WatchOpts {
+	if o.Interval <= 0 {
+		o.Interval = DefaultWatchOpts.Interval
+	}
+	if o.Jitter <= 0 || o.Jitter > 1 {
+		o.Jitter = DefaultWatchOpts.Jitter
+	}
+	if o.Backoff.MinBackoff <= 0 {
+		o.Backoff.MinBackoff = o.Interval
+	}
+	if o.Backoff.MaxBackoff <= 0 {
+		o.Backoff.MaxBackoff = 5 * time.Minute
+	}
+	return o
+}
+
+// wait returns how long to wait before the next poll.
+func (o WatchOpts) wait() time.Duration {
+	return time.Duration(float64(o.Interval) * (1 + o.Jitter*(2*rand.Float64()-1)))
+}
+
+// CurrentVersion returns the number of the latest version of the KV v2 secret
+// at the given path, or 0 if it has never been written.  Deleting or
+// destroying the latest version does not change it.
+func (v *Vault) CurrentVersion(path string) (uint, error) {
+	secret, _, _ := ParsePath(path)
+	mount, subpath, err := v.v2Path(secret, "watching")
+	if err != nil {
+		return 0, err
+	}
+
+	meta, err := v.client.Client.V2GetMetadata(mount, subpath)
+	if vaultkv.IsNotFound(err) {
+		return 0, nil
+	}
+	return meta.CurrentVersion, err
+}
+
+// Watch polls the current version of each of the given KV v2 secrets, and
+// calls changed with those whose version has changed since the last poll.
+// The first poll just notes the versions they start at.  The token is renewed
+// as it nears expiry, so that it never runs out between polls.
+//
+// Watch runs until ctx is done, when it returns nil.  Polls that fail are
+// retried with backoff, as are changes that changed returns an error for;
+// those are reported again (along with anything else that has changed since)
+// until changed handles them.
+func (v *Vault) Watch(ctx context.Context, paths []string, opts WatchOpts, changed func(paths []string) error) error {
+	opts = opts.withDefaults()
+	for _, path := range paths {
+		secret, _, _ := ParsePath(path)
+		if _, _, err := v.v2Path(secret, "watching"); err != nil {
+			return err
+		}
+	}
+
+	var versions map[string]uint
+	failures := 0
+	for {
+		current, err := v.pollVersions(paths)
+		wait := opts.wait()
+		if err == nil {
+			_, err = v.RenewLeaseBefore(2 * wait)
+		}
+
+		if err == nil {
+			var diff []string
+			for _, path := range paths {
+				if versions != nil && current[path] != versions[path] {
+					diff = append(diff, path)
+				}
+			}
+			if len(diff) > 0 {
+				err = changed(diff)
+			}
+		}
+
+		if err != nil {
+			failures++
+			wait = opts.Backoff.Backoff(failures)
+			if opts.OnError != nil {
+				opts.OnError(err, wait)
+			}
+		} else {
+			failures = 0
+			versions = current
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// pollVersions returns the current version of each of the given secrets.
+func (v *Vault) pollVersions(paths []string) (map[string]uint, error) {
+	versions := make(map[string]uint, len(paths))
+	for _, path := range paths {
+		version, err := v.CurrentVersion(path)
+		if err != nil {
+			return nil, err
+		}
+		versions[path] = version
+	}
+	return versions, nil
+}
@@ -0,0 +1,138 @@
+package vault_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/SomeBlackMagic/vault-cli-manager/vault"
+)
+
+var _ = Describe("Watching secrets", func() {
+	var fake *fakeVault
+	var v *vault.Vault
+
+	BeforeEach(func() {
+		fake, v = newFakeVaultV2(map[string][]fakeVersion{
+			"secret/a": aliveVersions(1),
+			"secret/b": aliveVersions(3),
+		})
+		fake.mounts["old"] = 1
+	})
+
+	AfterEach(func() {
+		fake.Close()
+	})
+
+	renewals := func() (n int) {
+		fake.Update(func() { n = fake.renewals })
+		return
+	}
+
+	It("looks up the current version of secrets", func() {
+		Expect(v.CurrentVersion("secret/b:key")).To(Equal(uint(3)))
+		Expect(v.CurrentVersion("secret/nope")).To(Equal(uint(0)))
+		_, err := v.CurrentVersion("old/a")
+		Expect(err).To(MatchError(ContainSubstring("not in a KV v2 mount")))
+	})
+
+	It("reports the secrets that change, until it is stopped", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		changes := make(chan []string, 10)
+		done := make(chan error)
+		go func() {
+			done <- v.Watch(ctx, []string{"secret/a", "secret/b", "secret/c"}, vault.WatchOpts{Interval: 10 * time.Millisecond},
+				func(paths []string) error {
+					changes <- paths
+					return nil
+				})
+		}()
+
+		Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+		fake.Update(func() {
+			fake.versions["secret/b"] = aliveVersions(4)
+			fake.versions["secret/c"] = aliveVersions(1)
+		})
+		Eventually(changes).Should(Receive(Equal([]string{"secret/b", "secret/c"})))
+		Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("backs off while polls fail, and carries on once they don't", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var errs []error
+		changes := make(chan []string, 10)
+		go v.Watch(ctx, []string{"secret/a"}, vault.WatchOpts{
+			Interval: 10 * time.Millisecond,
+			Backoff:  vault.RetryPolicy{MaxBackoff: 20 * time.Millisecond},
+			OnError: func(err error, wait time.Duration) {
+				fake.Update(func() { errs = append(errs, err) })
+				Expect(wait).To(BeNumerically("<=", 20*time.Millisecond))
+			},
+		}, func(paths []string) error {
+			changes <- paths
+			return nil
+		})
+
+		Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+		fake.Update(func() { fake.failing = true; fake.versions["secret/a"] = aliveVersions(2) })
+		Eventually(func() (n int) {
+			fake.Update(func() { n = len(errs) })
+			return
+		}).Should(BeNumerically(">=", 2))
+
+		fake.Update(func() { fake.failing = false })
+		Eventually(changes).Should(Receive(Equal([]string{"secret/a"})))
+	})
+
+	It("reports changes again, with backoff, until they are handled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		errs := make(chan error, 10)
+		changes := make(chan []string, 10)
+		calls := 0
+		go v.Watch(ctx, []string{"secret/a", "secret/b"}, vault.WatchOpts{
+			Interval: 10 * time.Millisecond,
+			Backoff:  vault.RetryPolicy{MaxBackoff: 20 * time.Millisecond},
+			OnError:  func(err error, wait time.Duration) { errs <- err },
+		}, func(paths []string) error {
+			changes <- paths
+			if calls++; calls < 3 {
+				return fmt.Errorf("not yet")
+			}
+			return nil
+		})
+
+		Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+		fake.Update(func() { fake.versions["secret/a"] = aliveVersions(2) })
+		for i := 0; i < 3; i++ {
+			Eventually(changes).Should(Receive(Equal([]string{"secret/a"})))
+		}
+		Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+		Expect(errs).To(HaveLen(2))
+		Expect(<-errs).To(MatchError("not yet"))
+	})
+
+	It("renews the token before it expires", func() {
+		fake.Update(func() { fake.ttl = 1000 })
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go v.Watch(ctx, []string{"secret/a"}, vault.WatchOpts{Interval: 10 * time.Millisecond},
+			func(paths []string) error { return nil })
+
+		Eventually(renewals).Should(Equal(1))
+		Consistently(renewals, 50*time.Millisecond).Should(Equal(1))
+	})
+
+	It("only watches KV v2 secrets", func() {
+		err := v.Watch(context.Background(), []string{"secret/a", "old/a"}, vault.WatchOpts{},
+			func(paths []string) error { return nil })
+		Expect(err).To(MatchError(ContainSubstring("not in a KV v2 mount")))
+	})
+})
@@ -2,8 +2,11 @@ package vaultsync
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
+
+	"github.com/jhunt/go-ansi"
 )
 
 // ComputeChanges compares local state vs remote state and returns a ChangeSet.
@@ -83,80 +86,152 @@ func mapsEqual(a, b map[string]interface{}) bool {
 	return true
 }
 
-// FormatDiff returns a colored string showing key-level diff for a single Change.
-// For keys whose values are nested JSON objects, uses DeepDiffJSON to show
-// only the changed fields within the object.
-func FormatDiff(c Change) string {
-	var sb strings.Builder
+// FormatDiff returns a string showing key-level diff for a single Change,
+// colored if color is set. For keys whose values are nested JSON objects, uses
+// DeepDiffJSON to show only the changed fields within the object. The string
+// is ready to print as it is, not as a format string.
+func FormatDiff(c Change, color bool) string {
+	return formatDiff(c, true, color)
+}
+
+// FormatDiffRedacted is like FormatDiff, but leaves out the values of the
+// keys and fields that differ, so that only their names are shown.
+func FormatDiffRedacted(c Change, color bool) string {
+	return formatDiff(c, false, color)
+}
+
+// diffWriter builds up a diff. Only its own format strings carry color
+// markup; paths, keys and values go in as arguments, so that nothing in them
+// is taken as markup or formatting directives.
+type diffWriter struct {
+	sb    strings.Builder
+	show  bool
+	color bool
+}
+
+var markup = regexp.MustCompile(`@[A-Za-z]{(.*?)}`)
+
+func (w *diffWriter) printf(format string, args ...interface{}) {
+	if w.color {
+		w.sb.WriteString(ansi.Sprintf(format, args...))
+		return
+	}
+	w.sb.WriteString(fmt.Sprintf(markup.ReplaceAllString(format, "$1"), args...))
+}
+
+// line writes the name of a key or field, with its value, or its old and new
+// values, unless they are not to be shown.
+func (w *diffWriter) line(format, name string, vals ...interface{}) {
+	if !w.show {
+		w.printf(format+"\n", name)
+		return
+	}
+	formatted := make([]string, len(vals))
+	for i, v := range vals {
+		formatted[i] = formatValue(v)
+	}
+	w.printf(format+": %s\n", name, strings.Join(formatted, " => "))
+}
+
+func formatDiff(c Change, show, color bool) string {
+	w := &diffWriter{show: show, color: color}
 
 	switch c.Type {
 	case ChangeAdd:
-		sb.WriteString(fmt.Sprintf("@G{+ %s}\n", c.Path))
-		keys := sortedKeys(c.LocalData)
-		for _, k := range keys {
-			sb.WriteString(fmt.Sprintf("    @G{+ %s}: %s\n", k, formatValue(c.LocalData[k])))
+		w.printf("@G{+ %s}\n", c.Path)
+	case ChangeDelete:
+		w.printf("@R{- %s}\n", c.Path)
+	case ChangeModify:
+		w.printf("@Y{~ %s}\n", c.Path)
+	case ChangeNone:
+		w.printf("  %s\n", c.Path)
+	}
+
+	nested := ""
+	for _, kc := range c.KeyChanges() {
+		indent, name := "    ", kc.Key
+		if kc.Field != "" {
+			if kc.Key != nested {
+				w.printf("    @Y{~ %s}:\n", kc.Key)
+				nested = kc.Key
+			}
+			indent, name = "        ", kc.Field
+		}
+
+		switch kc.Type {
+		case ChangeAdd:
+			w.line(indent+"@G{+ %s}", name, kc.NewValue)
+		case ChangeDelete:
+			w.line(indent+"@R{- %s}", name, kc.OldValue)
+		default:
+			w.line(indent+"@Y{~ %s}", name, kc.OldValue, kc.NewValue)
+		}
+	}
+
+	return w.sb.String()
+}
+
+// KeyChanges lists the keys that differ between the two sides of the change,
+// in order. For keys whose values are JSON objects or arrays on both sides,
+// it uses DeepDiffJSON to list only the fields within them that differ.
+func (c Change) KeyChanges() []KeyChange {
+	var changes []KeyChange
+
+	switch c.Type {
+	case ChangeAdd:
+		for _, k := range sortedKeys(c.LocalData) {
+			changes = append(changes, KeyChange{Type: ChangeAdd, Key: k, NewValue: c.LocalData[k]})
 		}
 
 	case ChangeDelete:
-		sb.WriteString(fmt.Sprintf("@R{- %s}\n", c.Path))
-		keys := sortedKeys(c.RemoteData)
-		for _, k := range keys {
-			sb.WriteString(fmt.Sprintf("    @R{- %s}: %s\n", k, formatValue(c.RemoteData[k])))
+		for _, k := range sortedKeys(c.RemoteData) {
+			changes = append(changes, KeyChange{Type: ChangeDelete, Key: k, OldValue: c.RemoteData[k]})
 		}
 
 	case ChangeModify:
-		sb.WriteString(fmt.Sprintf("@Y{~ %s}\n", c.Path))
-		allKeys := mergedKeys(c.LocalData, c.RemoteData)
-		for _, k := range allKeys {
+		for _, k := range mergedKeys(c.LocalData, c.RemoteData) {
 			localVal, localHas := c.LocalData[k]
 			remoteVal, remoteHas := c.RemoteData[k]
 
 			if !remoteHas {
 				// Key only in local (added)
-				sb.WriteString(fmt.Sprintf("    @G{+ %s}: %s\n", k, formatValue(localVal)))
+				changes = append(changes, KeyChange{Type: ChangeAdd, Key: k, NewValue: localVal})
 			} else if !localHas {
 				// Key only in remote (deleted)
-				sb.WriteString(fmt.Sprintf("    @R{- %s}: %s\n", k, formatValue(remoteVal)))
+				changes = append(changes, KeyChange{Type: ChangeDelete, Key: k, OldValue: remoteVal})
 			} else if !ValuesEqual(localVal, remoteVal) {
 				// Key in both but differs
-				sb.WriteString(formatKeyDiff(k, remoteVal, localVal))
+				changes = append(changes, keyChanges(k, remoteVal, localVal)...)
 			}
 		}
-
-	case ChangeNone:
-		sb.WriteString(fmt.Sprintf("  %s\n", c.Path))
 	}
 
-	return sb.String()
+	return changes
 }
 
-// formatKeyDiff formats a single key diff, with nested JSON support.
-func formatKeyDiff(key string, oldVal, newVal interface{}) string {
-	var sb strings.Builder
-
+// keyChanges lists how a single key differs, with nested JSON support.
+func keyChanges(key string, oldVal, newVal interface{}) []KeyChange {
 	// Check if both values are structured (map or slice) for nested diff
 	_, oldIsMap := oldVal.(map[string]interface{})
 	_, newIsMap := newVal.(map[string]interface{})
 	_, oldIsSlice := oldVal.([]interface{})
 	_, newIsSlice := newVal.([]interface{})
 
-	if (oldIsMap && newIsMap) || (oldIsSlice && newIsSlice) {
-		sb.WriteString(fmt.Sprintf("    @Y{~ %s}:\n", key))
-		fieldChanges := DeepDiffJSON(oldVal, newVal, "")
-		for _, fc := range fieldChanges {
-			if fc.OldValue == nil {
-				sb.WriteString(fmt.Sprintf("        @G{+ %s}: %s\n", fc.Path, formatValue(fc.NewValue)))
-			} else if fc.NewValue == nil {
-				sb.WriteString(fmt.Sprintf("        @R{- %s}: %s\n", fc.Path, formatValue(fc.OldValue)))
-			} else {
-				sb.WriteString(fmt.Sprintf("        @Y{~ %s}: %s => %s\n", fc.Path, formatValue(fc.OldValue), formatValue(fc.NewValue)))
-			}
-		}
-	} else {
-		sb.WriteString(fmt.Sprintf("    @Y{~ %s}: %s => %s\n", key, formatValue(oldVal), formatValue(newVal)))
+	if !(oldIsMap && newIsMap) && !(oldIsSlice && newIsSlice) {
+		return []KeyChange{{Type: ChangeModify, Key: key, OldValue: oldVal, NewValue: newVal}}
 	}
 
-	return sb.String()
+	var changes []KeyChange
+	for _, fc := range DeepDiffJSON(oldVal, newVal, "") {
+		kc := KeyChange{Type: ChangeModify, Key: key, Field: fc.Path, OldValue: fc.OldValue, NewValue: fc.NewValue}
+		if fc.OldValue == nil {
+			kc.Type = ChangeAdd
+		} else if fc.NewValue == nil {
+			kc.Type = ChangeDelete
+		}
+		changes = append(changes, kc)
+	}
+	return changes
 }
 
 // FormatChangeSummary returns "Plan: X to add, Y to change, Z to destroy."
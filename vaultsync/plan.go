@@ -28,12 +28,12 @@ func Plan(v VaultAccessor, vaultPath, localDir string) (ChangeSet, error) {
 
 	// Print diff
 	for _, c := range cs.Changes {
-		fmt.Fprintf(os.Stderr, "%s", FormatDiff(c))
+		fmt.Fprintf(os.Stderr, "%s", FormatDiff(c, fmt.ShouldColorize(os.Stderr)))
 	}
 
 	// Print summary
 	if cs.HasChanges() {
-		fmt.Fprintf(os.Stderr, "\n%s\n", FormatChangeSummary(cs))
+		fmt.Fprintf(os.Stderr, "\n"+FormatChangeSummary(cs)+"\n")
 	} else {
 		fmt.Fprintf(os.Stderr, "No changes. Infrastructure is up-to-date.\n")
 	}
@@ -72,7 +72,7 @@ func Pull(v VaultAccessor, vaultPath, localDir string) error {
 			LocalData:  localData,
 			RemoteData: remoteExpanded,
 		}
-		fmt.Fprintf(os.Stderr, "%s", FormatDiff(change))
+		fmt.Fprintf(os.Stderr, "%s", FormatDiff(change, fmt.ShouldColorize(os.Stderr)))
 
 		if !isTTY {
 			// Non-interactive: keep remote (safe default)
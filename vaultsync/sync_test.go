@@ -42,7 +42,7 @@ func (m *mockVault) Read(path string) (*vault.Secret, error) {
 	return s, nil
 }
 
-func (m *mockVault) Write(path string, s *vault.Secret) error {
+func (m *mockVault) Write(path string, s *vault.Secret, opts ...vault.WriteOpts) error {
 	m.written[path] = s
 	m.secrets[path] = s
 	return nil
@@ -373,7 +373,7 @@ var _ = Describe("Diff", func() {
 				Path:      "secret/new",
 				LocalData: map[string]interface{}{"key": "val"},
 			}
-			output := vaultsync.FormatDiff(c)
+			output := vaultsync.FormatDiff(c, false)
 			Expect(output).To(ContainSubstring("secret/new"))
 			Expect(output).To(ContainSubstring("key"))
 		})
@@ -384,7 +384,7 @@ var _ = Describe("Diff", func() {
 				Path:       "secret/old",
 				RemoteData: map[string]interface{}{"key": "val"},
 			}
-			output := vaultsync.FormatDiff(c)
+			output := vaultsync.FormatDiff(c, false)
 			Expect(output).To(ContainSubstring("secret/old"))
 		})
 
@@ -399,20 +399,70 @@ var _ = Describe("Diff", func() {
 					"config": map[string]interface{}{"host": "old-host", "port": float64(5432)},
 				},
 			}
-			output := vaultsync.FormatDiff(c)
+			output := vaultsync.FormatDiff(c, false)
 			Expect(output).To(ContainSubstring("secret/app"))
 			Expect(output).To(ContainSubstring("config"))
 			Expect(output).To(ContainSubstring("host"))
 		})
 
+		It("leaves values out when redacted", func() {
+			c := vaultsync.Change{
+				Type: vaultsync.ChangeModify,
+				Path: "secret/app",
+				LocalData: map[string]interface{}{
+					"password": "new-password",
+					"config":   map[string]interface{}{"host": "new-host"},
+					"added":    "added-value",
+				},
+				RemoteData: map[string]interface{}{
+					"password": "old-password",
+					"config":   map[string]interface{}{"host": "old-host"},
+				},
+			}
+			output := vaultsync.FormatDiffRedacted(c, false)
+			Expect(output).To(ContainSubstring("password"))
+			Expect(output).To(ContainSubstring("host"))
+			Expect(output).To(ContainSubstring("added"))
+			Expect(output).ToNot(ContainSubstring("-password"))
+			Expect(output).ToNot(ContainSubstring("-host"))
+			Expect(output).ToNot(ContainSubstring("added-value"))
+
+			Expect(vaultsync.FormatDiff(c, false)).To(ContainSubstring(`"old-password" => "new-password"`))
+		})
+
 		It("formats no-change", func() {
 			c := vaultsync.Change{
 				Type: vaultsync.ChangeNone,
 				Path: "secret/same",
 			}
-			output := vaultsync.FormatDiff(c)
+			output := vaultsync.FormatDiff(c, false)
 			Expect(output).To(ContainSubstring("secret/same"))
 		})
+
+		It("prints nested fields beneath the key they are in", func() {
+			c := vaultsync.Change{
+				Type:       vaultsync.ChangeModify,
+				Path:       "secret/app",
+				LocalData:  map[string]interface{}{"config": map[string]interface{}{"host": "b", "port": "1"}},
+				RemoteData: map[string]interface{}{"config": map[string]interface{}{"host": "a"}},
+			}
+			Expect(c.KeyChanges()).To(Equal([]vaultsync.KeyChange{
+				{Type: vaultsync.ChangeModify, Key: "config", Field: "host", OldValue: "a", NewValue: "b"},
+				{Type: vaultsync.ChangeAdd, Key: "config", Field: "port", NewValue: "1"},
+			}))
+			Expect(vaultsync.FormatDiff(c, false)).To(Equal("~ secret/app\n    ~ config:\n        ~ host: \"a\" => \"b\"\n        + port: \"1\"\n"))
+		})
+
+		It("prints paths, keys and values exactly as they are", func() {
+			c := vaultsync.Change{
+				Type:       vaultsync.ChangeModify,
+				Path:       "secret/@R{100%}",
+				LocalData:  map[string]interface{}{"@G{key}": "50%d @Y{new}"},
+				RemoteData: map[string]interface{}{"@G{key}": "old"},
+			}
+			Expect(vaultsync.FormatDiff(c, false)).To(Equal("~ secret/@R{100%}\n    ~ @G{key}: \"old\" => \"50%d @Y{new}\"\n"))
+			Expect(vaultsync.FormatDiff(c, true)).To(ContainSubstring("secret/@R{100%}\033[00m"))
+		})
 	})
 })
 
@@ -22,6 +22,16 @@ type Change struct {
 	RemoteData map[string]interface{} // nil if local-only
 }
 
+// KeyChange is a key that differs between the two sides of a Change, or a
+// field of its value, when that is a JSON object or array on both sides.
+type KeyChange struct {
+	Type     ChangeType
+	Key      string
+	Field    string      // "" for the whole value of the key
+	OldValue interface{} // nil if added
+	NewValue interface{} // nil if removed
+}
+
 // ChangeSet holds all changes between local and remote state.
 type ChangeSet struct {
 	Changes []Change
@@ -57,7 +67,7 @@ type LocalSecret struct {
 // VaultAccessor abstracts Vault operations for testability.
 type VaultAccessor interface {
 	Read(path string) (*vault.Secret, error)
-	Write(path string, s *vault.Secret) error
+	Write(path string, s *vault.Secret, opts ...vault.WriteOpts) error
 	Delete(path string, opts vault.DeleteOpts) error
 	List(path string) ([]string, error)
 	ConstructSecrets(path string, opts vault.TreeOpts) (vault.Secrets, error)